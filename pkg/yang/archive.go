@@ -0,0 +1,70 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the parsing of YANG source from compressed files and
+// archives.
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ParseGzip reads gzip compressed YANG source from r and adds it to ms, as
+// ParseReader does.  The name should reflect the source of r, such as the
+// name of a file.  An error is returned if r cannot be read or is not gzip
+// compressed.
+func (ms *Modules) ParseGzip(r io.Reader, name string) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	defer zr.Close()
+	return ms.ParseReader(zr, name)
+}
+
+// ParseZip adds each of the files with the extension ".yang" in the zip
+// archive zipPath to ms, as Parse does, in the order they are in the
+// archive.  Each file is named by zipPath followed by its path within the
+// archive, such as "models.zip/ietf/ietf-interfaces.yang".  Other files in
+// the archive are ignored.  An error is returned if the archive cannot be
+// read or if a file fails to parse.
+func (ms *Modules) ParseZip(zipPath string) error {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || path.Ext(f.Name) != ".yang" {
+			continue
+		}
+		name := zipPath + "/" + strings.TrimPrefix(f.Name, "/")
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		err = ms.ParseReader(rc, name)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
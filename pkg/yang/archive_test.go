@@ -0,0 +1,114 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestParseGzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(`module z { prefix "z"; namespace "urn:z"; }`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ms := NewModules()
+	if err := ms.ParseGzip(&buf, "z.yang.gz"); err != nil {
+		t.Fatalf("ParseGzip: %v", err)
+	}
+	if ms.Modules["z"] == nil {
+		t.Errorf("module z not added by ParseGzip")
+	}
+
+	err := ms.ParseGzip(strings.NewReader("module plain {}"), "plain.yang")
+	if diff := errdiff.Substring(err, "plain.yang: gzip: invalid header"); diff != "" {
+		t.Errorf("ParseGzip of uncompressed source: %s", diff)
+	}
+}
+
+func TestParseZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parsezip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// writeZip writes a zip archive named name containing files.
+	writeZip := func(name string, files [][2]string) string {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, f := range files {
+			w, err := zw.Create(f[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(f[1])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	good := writeZip("good.zip", [][2]string{
+		{"models/a.yang", `module a { prefix "a"; namespace "urn:a"; import b { prefix b; } }`},
+		{"models/b.yang", `module b { prefix "b"; namespace "urn:b"; }`},
+		{"README", "not yang"},
+	})
+	ms := NewModules()
+	if err := ms.ParseZip(good); err != nil {
+		t.Fatalf("ParseZip: %v", err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if ms.Modules[name] == nil {
+			t.Errorf("module %s not added by ParseZip", name)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Errorf("Process: %v", errs)
+	}
+
+	bad := writeZip("bad.zip", [][2]string{
+		{"bad.yang", `module bad { prefix "b"; namespace "urn:b"; bogus; }`},
+	})
+	err = NewModules().ParseZip(bad)
+	if diff := errdiff.Substring(err, "bad.zip/bad.yang:1:45: unknown module field: bogus"); diff != "" {
+		t.Errorf("ParseZip of invalid module: %s", diff)
+	}
+
+	err = NewModules().ParseZip(filepath.Join(dir, "missing.zip"))
+	if diff := errdiff.Substring(err, "missing.zip"); diff != "" {
+		t.Errorf("ParseZip of missing archive: %s", diff)
+	}
+}
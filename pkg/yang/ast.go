@@ -162,6 +162,11 @@ func build(s *Statement, p reflect.Value) (v reflect.Value, err error) {
 			if y.addext == nil {
 				return nilValue, fmt.Errorf("%s: no extension function", ss.Location())
 			}
+			if ParseOptions.StrictStatements {
+				if err := checkExtensionStatements(ss); err != nil {
+					return nilValue, err
+				}
+			}
 			y.addext(ss, v, p)
 		default:
 			return nilValue, fmt.Errorf("%s: unknown %s field: %s", ss.Location(), s.Keyword, ss.Keyword)
@@ -196,6 +201,35 @@ func build(s *Statement, p reflect.Value) (v reflect.Value, err error) {
 	return v, nil
 }
 
+// isKeyword reports whether kw is a keyword of a YANG statement.
+func isKeyword(kw string) bool {
+	if nameMap[kw] != nil || aliases[kw] != "" {
+		return true
+	}
+	for _, y := range typeMap {
+		if y.funcs[kw] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExtensionStatements returns an error for the first substatement of
+// the extension statement s, or of its substatements, whose keyword has no
+// prefix and is not a YANG keyword.  Such statements cannot be extensions, so
+// they are most likely misspelled YANG statements.
+func checkExtensionStatements(s *Statement) error {
+	for _, ss := range s.statements {
+		if !strings.Contains(ss.Keyword, ":") && !isKeyword(ss.Keyword) {
+			return fmt.Errorf("%s: unknown statement: %s", ss.Location(), ss.Keyword)
+		}
+		if err := checkExtensionStatements(ss); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // initTypes builds up the functions necessary to parse a Statement into the
 // type at.  at must be a of type pointer to structure and that structure should
 // implement Node.  For each field of the structure with a yang tag (e.g.,
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
 )
 
 type MainNode struct {
@@ -34,11 +36,13 @@ type MainNode struct {
 	ReqNode    *ReqNode   `yang:"req_node"`
 }
 
-func (MainNode) Kind() string             { return "main_node" }
-func (m *MainNode) ParentNode() Node      { return m.Parent }
-func (m *MainNode) NName() string         { return m.Name }
-func (m *MainNode) Statement() *Statement { return m.Source }
-func (m *MainNode) Exts() []*Statement    { return m.Extensions }
+func (MainNode) Kind() string                    { return "main_node" }
+func (m *MainNode) ParentNode() Node             { return m.Parent }
+func (m *MainNode) NName() string                { return m.Name }
+func (m *MainNode) Statement() *Statement        { return m.Source }
+func (m *MainNode) Exts() []*Statement           { return m.Extensions }
+func (m *MainNode) NumChildren() int             { return numChildNodes(m) }
+func (m *MainNode) NthChild(n int) (Node, error) { return nthChild(m, childNodes(m), n) }
 
 func (m *MainNode) checkEqual(n Node) string {
 	o, ok := n.(*MainNode)
@@ -114,11 +118,13 @@ type SubNode struct {
 	SubField *Value `yang:"sub_field"`
 }
 
-func (SubNode) Kind() string             { return "sub_node" }
-func (s *SubNode) ParentNode() Node      { return s.Parent }
-func (s *SubNode) NName() string         { return s.Name }
-func (s *SubNode) Statement() *Statement { return s.Source }
-func (s *SubNode) Exts() []*Statement    { return s.Extensions }
+func (SubNode) Kind() string                    { return "sub_node" }
+func (s *SubNode) ParentNode() Node             { return s.Parent }
+func (s *SubNode) NName() string                { return s.Name }
+func (s *SubNode) Statement() *Statement        { return s.Source }
+func (s *SubNode) Exts() []*Statement           { return s.Extensions }
+func (s *SubNode) NumChildren() int             { return numChildNodes(s) }
+func (s *SubNode) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 func (s *SubNode) checkEqual(o *SubNode) string {
 	if s.Name != o.Name {
@@ -151,11 +157,13 @@ type ReqNode struct {
 	Field    *Value `yang:"field"`
 }
 
-func (ReqNode) Kind() string             { return "req_node" }
-func (s *ReqNode) ParentNode() Node      { return s.Parent }
-func (s *ReqNode) NName() string         { return s.Name }
-func (s *ReqNode) Statement() *Statement { return s.Source }
-func (s *ReqNode) Exts() []*Statement    { return s.Extensions }
+func (ReqNode) Kind() string                    { return "req_node" }
+func (s *ReqNode) ParentNode() Node             { return s.Parent }
+func (s *ReqNode) NName() string                { return s.Name }
+func (s *ReqNode) Statement() *Statement        { return s.Source }
+func (s *ReqNode) Exts() []*Statement           { return s.Extensions }
+func (s *ReqNode) NumChildren() int             { return numChildNodes(s) }
+func (s *ReqNode) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 func (s *ReqNode) checkEqual(o *ReqNode) string {
 	if s.Name != o.Name {
@@ -344,3 +352,51 @@ main_node the_node {
 		}
 	}
 }
+
+func TestStrictStatements(t *testing.T) {
+	const in = `module m {
+  prefix "m";
+  namespace "urn:m";
+  extension ext { argument name; }
+  m:ext value {
+    %s
+  }
+}`
+	tests := []struct {
+		desc       string
+		strict     bool
+		sub        string
+		wantErrSub string
+	}{{
+		desc: "lenient",
+		sub:  `descriptoin "typo";`,
+	}, {
+		desc:       "strict typo",
+		strict:     true,
+		sub:        `descriptoin "typo";`,
+		wantErrSub: "m.yang:6:5: unknown statement: descriptoin",
+	}, {
+		desc:       "strict nested typo",
+		strict:     true,
+		sub:        `m:ext inner { refrence "typo"; }`,
+		wantErrSub: "unknown statement: refrence",
+	}, {
+		desc:   "strict YANG statement",
+		strict: true,
+		sub:    `description "ok";`,
+	}, {
+		desc:   "strict extension",
+		strict: true,
+		sub:    `m:ext inner;`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ParseOptions.StrictStatements = tt.strict
+			defer func() { ParseOptions.StrictStatements = false }()
+			err := NewModules().Parse(fmt.Sprintf(in, tt.sub), "m.yang")
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("Parse: %s", diff)
+			}
+		})
+	}
+}
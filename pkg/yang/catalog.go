@@ -0,0 +1,121 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements writing the metadata of modules in the JSON format of
+// the YANG Catalog (https://yangcatalog.org), as defined by the yang-catalog
+// module.
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// catalogModule is an entry of the module list of a YANG Catalog.
+type catalogModule struct {
+	Name         string              `json:"name"`
+	Revision     string              `json:"revision,omitempty"`
+	Organization string              `json:"organization,omitempty"`
+	Namespace    string              `json:"namespace,omitempty"`
+	Schema       string              `json:"schema,omitempty"`
+	ModuleType   string              `json:"module-type"`
+	Prefix       string              `json:"prefix,omitempty"`
+	YangVersion  string              `json:"yang-version"`
+	BelongsTo    string              `json:"belongs-to,omitempty"`
+	Contact      string              `json:"contact,omitempty"`
+	Description  string              `json:"description,omitempty"`
+	Dependencies []catalogDependency `json:"dependencies,omitempty"`
+	Submodules   []catalogDependency `json:"submodule,omitempty"`
+}
+
+// catalogDependency is a module imported, or a submodule included, by a
+// catalogModule.
+type catalogDependency struct {
+	Name     string `json:"name"`
+	Revision string `json:"revision,omitempty"`
+}
+
+// WriteYANGCatalog writes the modules and submodules of ms to w as YANG
+// Catalog JSON, sorted by name and revision.  The revision of each module is
+// its most recent revision, and its schema is the name of the file it was
+// parsed from, if any.  The dependencies of a module are the modules it
+// imports, with the revision-date of the import, if any, and its submodules
+// are those it includes.
+func (ms *Modules) WriteYANGCatalog(w io.Writer) error {
+	// ms.Modules and ms.SubModules have an entry for both the name and the
+	// name@revision of each module.
+	var mods []*Module
+	seen := map[*Module]bool{}
+	for _, mm := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		for _, m := range mm {
+			if !seen[m] {
+				seen[m] = true
+				mods = append(mods, m)
+			}
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].Name != mods[j].Name {
+			return mods[i].Name < mods[j].Name
+		}
+		return mods[i].Current() < mods[j].Current()
+	})
+
+	entries := []catalogModule{}
+	for _, m := range mods {
+		cm := catalogModule{
+			Name:         m.Name,
+			Revision:     m.Current(),
+			Organization: m.OrganizationString(),
+			Namespace:    m.Namespace.asString(),
+			ModuleType:   m.Kind(),
+			Prefix:       m.GetPrefix(),
+			YangVersion:  "1.0",
+			Contact:      m.ContactString(),
+			Description:  m.DescriptionString(),
+		}
+		if m.Source != nil {
+			cm.Schema = m.Source.file
+		}
+		if m.YangVersion != nil {
+			cm.YangVersion = m.YangVersion.Name
+			if cm.YangVersion == "1" {
+				cm.YangVersion = "1.0"
+			}
+		}
+		if m.BelongsTo != nil {
+			cm.BelongsTo = m.BelongsTo.Name
+		}
+		for _, i := range m.Import {
+			cm.Dependencies = append(cm.Dependencies, catalogDependency{Name: i.Name, Revision: i.RevisionDate.asString()})
+		}
+		for _, i := range m.Include {
+			cm.Submodules = append(cm.Submodules, catalogDependency{Name: i.Name, Revision: i.RevisionDate.asString()})
+		}
+		entries = append(entries, cm)
+	}
+
+	catalog := map[string]interface{}{
+		"yang-catalog:catalog": map[string]interface{}{
+			"modules": map[string]interface{}{
+				"module": entries,
+			},
+		},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(catalog)
+}
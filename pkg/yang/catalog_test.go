@@ -0,0 +1,116 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteYANGCatalog(t *testing.T) {
+	// Discard the typedefs of modules parsed, but not processed, by other
+	// tests.
+	typeDict = typeDictionary{dict: map[Node]map[string]*Typedef{}}
+	ms := NewModules()
+	for name, in := range map[string]string{
+		"models/m.yang": `module m {
+  yang-version 1.1;
+  prefix "m";
+  namespace "urn:m";
+  organization "Example";
+  contact "noc@example.com";
+  description "Module m.";
+  revision 2021-01-01;
+  revision 2020-01-01;
+  import dep { prefix d; revision-date 2019-01-01; }
+  include s;
+}`,
+		"dep.yang": `module dep { prefix "d"; namespace "urn:d"; revision 2019-01-01; }`,
+		"s.yang":   `submodule s { belongs-to m { prefix "m"; } }`,
+	} {
+		if err := ms.Parse(in, name); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	var b bytes.Buffer
+	if err := ms.WriteYANGCatalog(&b); err != nil {
+		t.Fatalf("WriteYANGCatalog: %v", err)
+	}
+	want := `{
+  "yang-catalog:catalog": {
+    "modules": {
+      "module": [
+        {
+          "name": "dep",
+          "revision": "2019-01-01",
+          "namespace": "urn:d",
+          "schema": "dep.yang",
+          "module-type": "module",
+          "prefix": "d",
+          "yang-version": "1.0"
+        },
+        {
+          "name": "m",
+          "revision": "2021-01-01",
+          "organization": "Example",
+          "namespace": "urn:m",
+          "schema": "models/m.yang",
+          "module-type": "module",
+          "prefix": "m",
+          "yang-version": "1.1",
+          "contact": "noc@example.com",
+          "description": "Module m.",
+          "dependencies": [
+            {
+              "name": "dep",
+              "revision": "2019-01-01"
+            }
+          ],
+          "submodule": [
+            {
+              "name": "s"
+            }
+          ]
+        },
+        {
+          "name": "s",
+          "schema": "s.yang",
+          "module-type": "submodule",
+          "prefix": "m",
+          "yang-version": "1.0",
+          "belongs-to": "m"
+        }
+      ]
+    }
+  }
+}
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("WriteYANGCatalog (-want, +got):\n%s", diff)
+	}
+
+	b.Reset()
+	if err := NewModules().WriteYANGCatalog(&b); err != nil {
+		t.Fatalf("WriteYANGCatalog of no modules: %v", err)
+	}
+	if got, want := b.String(), "{\n  \"yang-catalog:catalog\": {\n    \"modules\": {\n      \"module\": []\n    }\n  }\n}\n"; got != want {
+		t.Errorf("WriteYANGCatalog of no modules = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codegen generates Go source code from processed YANG Entry trees.
+//
+// EntryToGoStruct generates a Go struct for each container and list of an
+// Entry tree, with a field for each of their data nodes, and a Go type with
+// constants for each enumeration used by the leaves of the tree.  The
+// generated code does not depend on any package.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// goTypes maps the kinds of YANG types to the Go types used for them.  The
+// kinds not listed are mapped by generator.leafType.
+var goTypes = map[yang.TypeKind]string{
+	yang.Yint8:               "int8",
+	yang.Yint16:              "int16",
+	yang.Yint32:              "int32",
+	yang.Yint64:              "int64",
+	yang.Yuint8:              "uint8",
+	yang.Yuint16:             "uint16",
+	yang.Yuint32:             "uint32",
+	yang.Yuint64:             "uint64",
+	yang.Ybool:               "bool",
+	yang.Ystring:             "string",
+	yang.Ybinary:             "[]byte",
+	yang.Ydecimal64:          "float64",
+	yang.Yempty:              "bool",
+	yang.Ybits:               "string",
+	yang.Yidentityref:        "string",
+	yang.YinstanceIdentifier: "string",
+	yang.Yunion:              "interface{}",
+}
+
+// EntryToGoStruct returns the gofmt formatted source of a Go file in the
+// package pkgName that defines a struct for e, which must be a container,
+// list or module, and for each container and list within e.
+//
+// The struct of e is named by the CamelCase form of the name of e, as
+// returned by yang.CamelCase, and the struct of each container and list
+// within e is named by the names of the nodes on the path from e to it,
+// joined by underscores, such as Device_Interfaces_Interface.  The
+// characters that cannot be in a Go identifier are dropped from all the
+// generated names.  Each struct
+// has a field for each of the data nodes that are children of its node in
+// the data tree, with the data nodes within choices and cases embedded
+// directly in the struct.  Each field has a json tag with the name of its
+// node.
+//
+// Leaves are pointers to the Go type of the built-in type their type is
+// derived from, such as *uint32 for a leaf of a typedef of uint32, and
+// leaf-lists are slices of it.  Leaves of type empty are bool, leaves of
+// type binary are []byte, and leaves of type union are interface{}.  Leaves
+// of type bits, identityref and instance-identifier are strings.  A leafref
+// has the Go type of the leaf it refers to, or string if its path cannot be
+// resolved.  Containers are pointers to their struct, and lists are slices
+// of pointers to their struct.
+//
+// An enumeration is generated as an int64 type, with a constant for each of
+// its enums and a String method that returns the name of an enum.  An
+// enumeration defined by a typedef is named E_ followed by the CamelCase
+// typedef name, unless a struct or another enumeration has that name, and
+// is shared by all the leaves of that typedef.  Other enumerations are
+// named E_ followed by the name of the struct of the leaf and the name of
+// the leaf.  Each constant is named by its enumeration and the CamelCase
+// enum name, with a numeric suffix if the name is already used.
+//
+// RPCs, notifications, anydata and anyxml nodes are not generated.  An error
+// is returned if two nodes of a struct have the same CamelCase name, or if a
+// leaf has no type.
+func EntryToGoStruct(e *yang.Entry, pkgName string) ([]byte, error) {
+	if !token.IsIdentifier(pkgName) {
+		return nil, fmt.Errorf("invalid package name %q", pkgName)
+	}
+	if e == nil {
+		return nil, fmt.Errorf("no entry to generate")
+	}
+	if !e.IsDir() || e.RPC != nil {
+		return nil, fmt.Errorf("%s is not a container, list or module", e.Path())
+	}
+	g := &generator{
+		enumNames: map[*yang.EnumType]string{},
+		usedNames: map[string]bool{},
+	}
+	g.printf("package %s\n", pkgName)
+	name := goName(e.Name, "Module")
+	// The names of the structs are reserved first, so that no enumeration
+	// is given the name of a struct.
+	g.reserveStructs(e, name)
+	if err := g.genStruct(e, name); err != nil {
+		return nil, err
+	}
+	for _, en := range g.enums {
+		g.genEnum(en)
+	}
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+// An enum is an enumeration to be generated.
+type enum struct {
+	name string
+	path string // the path of the first leaf using the enumeration
+	enum *yang.EnumType
+}
+
+// A generator holds the state of the code being generated.
+type generator struct {
+	buf       bytes.Buffer
+	enums     []enum                    // enumerations in order of first use
+	enumNames map[*yang.EnumType]string // names of the enumerations in enums
+	usedNames map[string]bool           // names of the generated types
+}
+
+// printf writes the formatted string to the generated code.
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// goName returns the CamelCase form of the YANG name s, as returned by
+// yang.CamelCase, without the characters that cannot be in a Go identifier,
+// or def if that leaves nothing.
+func goName(s, def string) string {
+	id := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, yang.CamelCase(s))
+	if id == "" {
+		return def
+	}
+	return id
+}
+
+// fieldEntries returns the entries of the fields of the struct for e.
+func fieldEntries(e *yang.Entry) []*yang.Entry {
+	var fields []*yang.Entry
+	for _, c := range e.Flatten() {
+		if c.RPC == nil && (c.Kind == yang.LeafEntry || c.Kind == yang.DirectoryEntry) {
+			fields = append(fields, c)
+		}
+	}
+	return fields
+}
+
+// reserveStructs marks name, the name of the struct for e, and the names of
+// the structs for the containers and lists within e, as used.
+func (g *generator) reserveStructs(e *yang.Entry, name string) {
+	g.usedNames[name] = true
+	for _, c := range fieldEntries(e) {
+		if c.IsDir() {
+			g.reserveStructs(c, name+"_"+goName(c.Name, "Field"))
+		}
+	}
+}
+
+// genStruct generates the struct named name for e, followed by the structs
+// for the containers and lists within e.
+func (g *generator) genStruct(e *yang.Entry, name string) error {
+	type field struct {
+		name, goType, tag string
+	}
+	var fields []field
+	var children []*yang.Entry
+	fieldNodes := map[string]*yang.Entry{}
+	for _, c := range fieldEntries(e) {
+		fname := goName(c.Name, "Field")
+		if o := fieldNodes[fname]; o != nil {
+			return fmt.Errorf("%s: %s and %s both have the Go name %s", yang.Source(c.Node), c.Path(), o.Path(), fname)
+		}
+		fieldNodes[fname] = c
+		f := field{name: fname, tag: fmt.Sprintf("`json:\"%s,omitempty\"`", c.Name)}
+		switch {
+		case c.IsList():
+			f.goType = "[]*" + name + "_" + fname
+			children = append(children, c)
+		case c.IsDir():
+			f.goType = "*" + name + "_" + fname
+			children = append(children, c)
+		default:
+			t, err := g.leafType(c, name)
+			if err != nil {
+				return err
+			}
+			switch {
+			case c.IsLeafList():
+				f.goType = "[]" + t
+			case strings.HasPrefix(t, "[]") || t == "interface{}" || c.Type.Kind == yang.Yempty:
+				f.goType = t
+			default:
+				f.goType = "*" + t
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	g.printf("\n// %s represents the %s YANG schema element.\n", name, e.Path())
+	g.printf("type %s struct {\n", name)
+	for _, f := range fields {
+		g.printf("\t%s %s %s\n", f.name, f.goType, f.tag)
+	}
+	g.printf("}\n")
+
+	for _, c := range children {
+		if err := g.genStruct(c, name+"_"+goName(c.Name, "Field")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leafType returns the Go type of the leaf or leaf-list e, a field of the
+// struct named structName.
+func (g *generator) leafType(e *yang.Entry, structName string) (string, error) {
+	t := e.Type
+	if t == nil {
+		return "", fmt.Errorf("%s: leaf %s has no type", yang.Source(e.Node), e.Path())
+	}
+	leafName := structName + "_" + goName(e.Name, "Field")
+	for seen := map[*yang.Entry]bool{e: true}; t.Kind == yang.Yleafref; {
+		target, err := e.ResolveLeafref(t)
+		if err != nil || target.Type == nil || seen[target] {
+			return "string", nil
+		}
+		seen[target] = true
+		e, t = target, target.Type
+	}
+	if t.Kind == yang.Yenum {
+		return g.enumName(t, leafName, e.Path()), nil
+	}
+	if gt, ok := goTypes[t.Kind]; ok {
+		return gt, nil
+	}
+	return "", fmt.Errorf("%s: leaf %s has unsupported type %s", yang.Source(e.Node), e.Path(), t.Name)
+}
+
+// enumName returns the name of the Go type of the enumeration t, adding it
+// to the enumerations to generate if it has not already been added.  The
+// enumeration is named from the typedef of t, if any, or else from
+// leafName.
+func (g *generator) enumName(t *yang.YangType, leafName, path string) string {
+	if name, ok := g.enumNames[t.Enum]; ok {
+		return name
+	}
+	name := "E_" + leafName
+	if t.Name != "enumeration" {
+		if tn := "E_" + goName(t.Name, "Enum"); !g.usedNames[tn] {
+			name = tn
+		}
+	}
+	for base, i := name, 2; g.usedNames[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	g.usedNames[name] = true
+	g.enumNames[t.Enum] = name
+	g.enums = append(g.enums, enum{name: name, path: path, enum: t.Enum})
+	return name
+}
+
+// genEnum generates the Go type, constants and String method of en.
+func (g *generator) genEnum(en enum) {
+	values := en.enum.Values()
+	consts := g.enumConstNames(en, values)
+	g.printf("\n// %s is the enumeration used by %s.\n", en.name, en.path)
+	g.printf("type %s int64\n", en.name)
+	g.printf("\nconst (\n")
+	for i, v := range values {
+		g.printf("\t// %s is the enum %s.\n", consts[i], en.enum.Name(v))
+		g.printf("\t%s %s = %d\n", consts[i], en.name, v)
+	}
+	g.printf(")\n")
+
+	g.printf("\n// String returns the YANG name of e, or the empty string if e is not\n// a value of %s.\n", en.name)
+	g.printf("func (e %s) String() string {\n\tswitch e {\n", en.name)
+	for i, v := range values {
+		g.printf("\tcase %s:\n\t\treturn %q\n", consts[i], en.enum.Name(v))
+	}
+	g.printf("\t}\n\treturn \"\"\n}\n")
+}
+
+// enumConstNames returns the names of the constants for values, the values
+// of en.  Each is the name of en and the goName of the enum.  A numeric
+// suffix is added to a name that is already used.
+func (g *generator) enumConstNames(en enum, values []int64) []string {
+	names := make([]string, len(values))
+	for i, v := range values {
+		name := en.name + "_" + goName(en.enum.Name(v), "Enum")
+		for base, n := name, 2; g.usedNames[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		g.usedNames[name] = true
+		names[i] = name
+	}
+	return names
+}
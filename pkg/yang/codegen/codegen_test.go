@@ -0,0 +1,311 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+const deviceModule = `module device {
+  prefix "d";
+  namespace "urn:d";
+
+  typedef admin-state {
+    type enumeration {
+      enum up { value 1; }
+      enum down { value 2; }
+    }
+  }
+  typedef counter { type uint64; }
+
+  container interfaces {
+    list interface {
+      key "name";
+      leaf name { type string; }
+      leaf mtu { type uint16; }
+      leaf admin { type admin-state; }
+      leaf oper { type admin-state; }
+      leaf-list address { type string; }
+      leaf in-octets { type counter; }
+      leaf enabled { type empty; }
+      leaf mac { type binary; }
+      leaf speed {
+        type enumeration {
+          enum slow;
+          enum fast;
+        }
+      }
+      leaf parent { type leafref { path "../../interface[name = current()/../name]/mtu"; } }
+      choice addressing {
+        case static {
+          leaf ip { type string; }
+        }
+        leaf dhcp { type boolean; }
+      }
+    }
+  }
+  leaf hostname { type string; }
+  leaf value { type union { type int8; type string; } }
+  rpc reboot {}
+}`
+
+const deviceGo = `package device
+
+// Device represents the /device YANG schema element.
+type Device struct {
+	Interfaces *Device_Interfaces ` + "`json:\"interfaces,omitempty\"`" + `
+	Hostname   *string            ` + "`json:\"hostname,omitempty\"`" + `
+	Value      interface{}        ` + "`json:\"value,omitempty\"`" + `
+}
+
+// Device_Interfaces represents the /device/interfaces YANG schema element.
+type Device_Interfaces struct {
+	Interface []*Device_Interfaces_Interface ` + "`json:\"interface,omitempty\"`" + `
+}
+
+// Device_Interfaces_Interface represents the /device/interfaces/interface YANG schema element.
+type Device_Interfaces_Interface struct {
+	Name     *string                              ` + "`json:\"name,omitempty\"`" + `
+	Mtu      *uint16                              ` + "`json:\"mtu,omitempty\"`" + `
+	Admin    *E_AdminState                        ` + "`json:\"admin,omitempty\"`" + `
+	Oper     *E_AdminState                        ` + "`json:\"oper,omitempty\"`" + `
+	Address  []string                             ` + "`json:\"address,omitempty\"`" + `
+	InOctets *uint64                              ` + "`json:\"in-octets,omitempty\"`" + `
+	Enabled  bool                                 ` + "`json:\"enabled,omitempty\"`" + `
+	Mac      []byte                               ` + "`json:\"mac,omitempty\"`" + `
+	Speed    *E_Device_Interfaces_Interface_Speed ` + "`json:\"speed,omitempty\"`" + `
+	Parent   *uint16                              ` + "`json:\"parent,omitempty\"`" + `
+	Ip       *string                              ` + "`json:\"ip,omitempty\"`" + `
+	Dhcp     *bool                                ` + "`json:\"dhcp,omitempty\"`" + `
+}
+
+// E_AdminState is the enumeration used by /device/interfaces/interface/admin.
+type E_AdminState int64
+
+const (
+	// E_AdminState_Up is the enum up.
+	E_AdminState_Up E_AdminState = 1
+	// E_AdminState_Down is the enum down.
+	E_AdminState_Down E_AdminState = 2
+)
+
+// String returns the YANG name of e, or the empty string if e is not
+// a value of E_AdminState.
+func (e E_AdminState) String() string {
+	switch e {
+	case E_AdminState_Up:
+		return "up"
+	case E_AdminState_Down:
+		return "down"
+	}
+	return ""
+}
+
+// E_Device_Interfaces_Interface_Speed is the enumeration used by /device/interfaces/interface/speed.
+type E_Device_Interfaces_Interface_Speed int64
+
+const (
+	// E_Device_Interfaces_Interface_Speed_Slow is the enum slow.
+	E_Device_Interfaces_Interface_Speed_Slow E_Device_Interfaces_Interface_Speed = 0
+	// E_Device_Interfaces_Interface_Speed_Fast is the enum fast.
+	E_Device_Interfaces_Interface_Speed_Fast E_Device_Interfaces_Interface_Speed = 1
+)
+
+// String returns the YANG name of e, or the empty string if e is not
+// a value of E_Device_Interfaces_Interface_Speed.
+func (e E_Device_Interfaces_Interface_Speed) String() string {
+	switch e {
+	case E_Device_Interfaces_Interface_Speed_Slow:
+		return "slow"
+	case E_Device_Interfaces_Interface_Speed_Fast:
+		return "fast"
+	}
+	return ""
+}
+`
+
+// getEntry returns the Entry of the module named name parsed from text.
+func getEntry(t *testing.T, text, name string) *yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	if err := ms.Parse(text, name+".yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	e, errs := ms.GetModule(name)
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	return e
+}
+
+func TestEntryToGoStruct(t *testing.T) {
+	e := getEntry(t, deviceModule, "device")
+	src, err := EntryToGoStruct(e, "device")
+	if err != nil {
+		t.Fatalf("EntryToGoStruct: %v", err)
+	}
+	if diff := cmp.Diff(deviceGo, string(src)); diff != "" {
+		t.Errorf("EntryToGoStruct (-want, +got):\n%s", diff)
+	}
+
+	// The generated code must compile.
+	typeCheck(t, "device", src)
+}
+
+// typeCheck reports an error if src, the generated source of package pkg,
+// does not type check.
+func typeCheck(t *testing.T, pkg string, src []byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, pkg+".go", src, 0)
+	if err != nil {
+		t.Fatalf("parsing generated code: %v", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check(pkg, fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("type checking generated code: %v\n%s", err, src)
+	}
+}
+
+func TestEntryToGoStructEnumNames(t *testing.T) {
+	e := getEntry(t, `module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf e {
+      type enumeration {
+        enum foo-bar;
+        enum foo_bar;
+        enum "1+1";
+        enum "+";
+        enum "-";
+      }
+    }
+  }
+}`, "m")
+	src, err := EntryToGoStruct(e, "m")
+	if err != nil {
+		t.Fatalf("EntryToGoStruct: %v", err)
+	}
+	typeCheck(t, "m", src)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "m.go", src, 0)
+	if err != nil {
+		t.Fatalf("parsing generated code: %v", err)
+	}
+	var got []string
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.CONST {
+			for _, spec := range gd.Specs {
+				for _, n := range spec.(*ast.ValueSpec).Names {
+					got = append(got, n.Name)
+				}
+			}
+		}
+	}
+	want := []string{"E_M_C_E_FooBar", "E_M_C_E_FooBar_2", "E_M_C_E_11", "E_M_C_E_Enum", "E_M_C_E_X"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("enum constants (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEntryToGoStructTypeNames(t *testing.T) {
+	e := getEntry(t, `module e {
+  prefix "e";
+  namespace "urn:e";
+  typedef state { type enumeration { enum up; } }
+  typedef my.enum { type enumeration { enum a.b; } }
+  leaf s { type state; }
+  leaf p { type e:my.enum; }
+  leaf a.b { type string; }
+  container state { leaf x.y { type enumeration { enum k.l; } } }
+}`, "e")
+	src, err := EntryToGoStruct(e, "e")
+	if err != nil {
+		t.Fatalf("EntryToGoStruct: %v", err)
+	}
+	typeCheck(t, "e", src)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "e.go", src, 0)
+	if err != nil {
+		t.Fatalf("parsing generated code: %v", err)
+	}
+	var got []string
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+			for _, spec := range gd.Specs {
+				got = append(got, spec.(*ast.TypeSpec).Name.Name)
+			}
+		}
+	}
+	// The enumeration of typedef state is not named E_State, which is
+	// the name of the struct of container state.
+	want := []string{"E", "E_State", "E_E_S", "E_MyEnum", "E_E_State_XY"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("types (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEntryToGoStructErrors(t *testing.T) {
+	tests := []struct {
+		desc       string
+		in         string
+		path       string
+		pkg        string
+		wantErrSub string
+	}{{
+		desc:       "invalid package",
+		in:         `module m { prefix "m"; namespace "urn:m"; }`,
+		pkg:        "not a package",
+		wantErrSub: `invalid package name "not a package"`,
+	}, {
+		desc:       "leaf",
+		in:         `module m { prefix "m"; namespace "urn:m"; leaf l { type string; } }`,
+		path:       "l",
+		pkg:        "m",
+		wantErrSub: "/m/l is not a container, list or module",
+	}, {
+		desc: "name collision",
+		in: `module m { prefix "m"; namespace "urn:m";
+  leaf a-b { type string; }
+  leaf a_b { type string; }
+}`,
+		pkg:        "m",
+		wantErrSub: "both have the Go name AB",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			e := getEntry(t, tt.in, "m")
+			if tt.path != "" {
+				e = e.Dir[tt.path]
+			}
+			_, err := EntryToGoStruct(e, tt.pkg)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("EntryToGoStruct: %s", diff)
+			}
+		})
+	}
+}
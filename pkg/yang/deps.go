@@ -0,0 +1,187 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements finding the typedefs, groupings and identities that
+// a module uses.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// A Definition is a typedef, grouping or identity used by a module.
+type Definition struct {
+	Kind   string // "typedef", "grouping" or "identity"
+	Name   string // the name of the definition
+	Module string // the module it is defined in, or that its submodule belongs to
+	Node   Node   // the *Typedef, *Grouping or *Identity
+}
+
+// String returns d as module:name.
+func (d *Definition) String() string {
+	return d.Module + ":" + d.Name
+}
+
+// skipDependencyFields are the fields of AST nodes that are not searched for
+// uses of definitions.  Definitions are only searched once they are used.
+var skipDependencyFields = map[string]bool{
+	"Parent":     true,
+	"Source":     true,
+	"Extensions": true,
+	"Extension":  true,
+	"Feature":    true,
+	"Grouping":   true,
+	"Identity":   true,
+	"Import":     true,
+	"Include":    true,
+	"Typedef":    true,
+	"Values":     true, // the identities derived from an identity
+}
+
+// UsedDefinitions returns the typedefs, groupings and identities used by
+// the named module and the submodules it includes, including those used
+// through imported modules and by the definitions they use, sorted by
+// module, kind and name.  A typedef is used by the type statements of a
+// module that name it, a grouping by the uses statements that name it, and
+// an identity by the identityref types that have it as their base.  An
+// identity also uses its own base identity.  The modules of ms must have
+// been processed.
+func (ms *Modules) UsedDefinitions(name string) ([]*Definition, error) {
+	m := ms.Modules[name]
+	if m == nil {
+		return nil, fmt.Errorf("no such module: %s", name)
+	}
+	d := &depWalker{
+		visited: map[Node]bool{},
+		found:   map[Node]*Definition{},
+	}
+	d.walk(m)
+	for includes := m.Include; len(includes) > 0; {
+		i := includes[0]
+		includes = includes[1:]
+		if i.Module == nil {
+			return nil, fmt.Errorf("%s: submodule %s has not been processed", Source(i), i.Name)
+		}
+		if !d.visited[i.Module] {
+			d.walk(i.Module)
+			includes = append(includes, i.Module.Include...)
+		}
+	}
+
+	defs := make([]*Definition, 0, len(d.found))
+	for _, def := range d.found {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		a, b := defs[i], defs[j]
+		switch {
+		case a.Module != b.Module:
+			return a.Module < b.Module
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return defs, nil
+}
+
+// A depWalker searches AST nodes for the definitions they use.
+type depWalker struct {
+	visited map[Node]bool
+	found   map[Node]*Definition
+}
+
+// add records the definition n, if it has not already been found, and
+// searches it for the definitions it uses.
+func (d *depWalker) add(n Node) {
+	if d.found[n] != nil {
+		return
+	}
+	d.found[n] = &Definition{
+		Kind:   n.Kind(),
+		Name:   n.NName(),
+		Module: moduleName(n),
+		Node:   n,
+	}
+	d.walk(n)
+}
+
+// walk searches n, and the nodes within it, for the definitions they use.
+func (d *depWalker) walk(n Node) {
+	if n == nil || d.visited[n] {
+		return
+	}
+	d.visited[n] = true
+
+	switch n := n.(type) {
+	case *Type:
+		if y := n.YangType; y != nil {
+			if y.Base != nil {
+				if td, ok := y.Base.Parent.(*Typedef); ok {
+					d.add(td)
+				}
+			}
+			if y.IdentityBase != nil {
+				d.add(y.IdentityBase)
+			}
+		}
+	case *Uses:
+		if g := FindGrouping(n, n.Name, map[string]bool{}); g != nil {
+			d.add(g)
+		}
+	case *Identity:
+		if n.Base != nil {
+			identities.mu.Lock()
+			base, errs := RootNode(n).findIdentityBase(n.Base.asString())
+			identities.mu.Unlock()
+			if errs == nil && base.Identity != nil {
+				d.add(base.Identity)
+			}
+		}
+	}
+
+	v := reflect.ValueOf(n).Elem()
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if skipDependencyFields[t.Field(i).Name] {
+			continue
+		}
+		f := v.Field(i)
+		if f.Kind() != reflect.Slice {
+			d.walkValue(f)
+			continue
+		}
+		for j := 0; j < f.Len(); j++ {
+			d.walkValue(f.Index(j))
+		}
+	}
+}
+
+// walkValue walks v if it is an AST node other than a *Value or *Statement.
+func (d *depWalker) walkValue(v reflect.Value) {
+	if k := v.Kind(); k != reflect.Ptr && k != reflect.Interface || !v.CanInterface() || v.IsNil() {
+		return
+	}
+	switch n := v.Interface().(type) {
+	case *Value, *Statement:
+	case Node:
+		d.walk(n)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestUsedDefinitions(t *testing.T) {
+	// Discard the typedefs of modules parsed, but not processed, by other
+	// tests.
+	typeDict = typeDictionary{dict: map[Node]map[string]*Typedef{}}
+	ms := NewModules()
+	for name, text := range map[string]string{
+		"lib": `module lib {
+  prefix "l";
+  namespace "urn:l";
+
+  typedef base-type { type uint32; }
+  typedef derived-type { type base-type { range "1..10"; } }
+  typedef unused-type { type string; }
+
+  identity root-id;
+  identity child-id { base root-id; }
+  identity unused-id;
+
+  grouping outer {
+    leaf o { type derived-type; }
+    uses inner;
+  }
+  grouping inner {
+    leaf i { type identityref { base child-id; } }
+  }
+  grouping unused-grouping { leaf u { type string; } }
+}`,
+		"app": `module app {
+  prefix "a";
+  namespace "urn:a";
+  import lib { prefix "l"; }
+  include app-sub;
+
+  typedef local-type { type l:base-type; }
+  typedef unused-local { type string; }
+
+  container c {
+    uses l:outer;
+    leaf local { type local-type; }
+  }
+}`,
+		"app-sub": `submodule app-sub {
+  belongs-to app { prefix "a"; }
+  import lib { prefix "l"; }
+
+  grouping sub-grouping {
+    leaf s { type union { type string; type l:base-type; } }
+  }
+  container sc { uses sub-grouping; }
+}`,
+	} {
+		if err := ms.Parse(text, name+".yang"); err != nil {
+			t.Fatalf("Parse %s: %v", name, err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+
+	defs, err := ms.UsedDefinitions("app")
+	if err != nil {
+		t.Fatalf("UsedDefinitions: %v", err)
+	}
+	var got []string
+	for _, d := range defs {
+		got = append(got, d.Kind+" "+d.String())
+	}
+	want := []string{
+		"grouping app:sub-grouping",
+		"typedef app:local-type",
+		"grouping lib:inner",
+		"grouping lib:outer",
+		"identity lib:child-id",
+		"identity lib:root-id",
+		"typedef lib:base-type",
+		"typedef lib:derived-type",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("UsedDefinitions (-want, +got):\n%s", diff)
+	}
+	if _, ok := defs[1].Node.(*Typedef); !ok {
+		t.Errorf("UsedDefinitions: Node of %s is %T, want *Typedef", defs[1], defs[1].Node)
+	}
+
+	_, err = ms.UsedDefinitions("missing")
+	if diff := errdiff.Substring(err, "no such module: missing"); diff != "" {
+		t.Errorf("UsedDefinitions(missing): %s", diff)
+	}
+}
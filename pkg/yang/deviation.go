@@ -0,0 +1,285 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the checking of deviations against the rules of RFC
+// 7950 section 7.20.3.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A DeviationError reports a deviation that is not permitted by RFC 7950
+// section 7.20.3.
+type DeviationError struct {
+	Deviation *Deviation // the deviation statement
+	Target    *Entry     // the node being deviated, nil if not found
+	Err       error      // why the deviation is not permitted
+}
+
+// Error implements the error interface.
+func (e DeviationError) Error() string {
+	return e.Err.Error()
+}
+
+// deviateProperties lists, for each argument of the deviate statement, the
+// properties that it may act on.
+var deviateProperties = map[string]map[string]bool{
+	"add": {
+		"config": true, "default": true, "mandatory": true, "max-elements": true,
+		"min-elements": true, "must": true, "unique": true, "units": true,
+	},
+	"replace": {
+		"config": true, "default": true, "mandatory": true, "max-elements": true,
+		"min-elements": true, "type": true, "units": true,
+	},
+	"delete": {
+		"default": true, "must": true, "unique": true, "units": true,
+	},
+}
+
+// deviateVerbs maps the arguments of the deviate statement to the past
+// tense used in errors.
+var deviateVerbs = map[string]string{
+	"add":     "added",
+	"replace": "replaced",
+	"delete":  "deleted",
+}
+
+// CheckDeviationValidity returns an error for each deviation in the modules
+// and submodules of ms that is not permitted by RFC 7950 section 7.20.3:
+//
+//   - deviate not-supported must be the only deviate statement of its
+//     deviation and must have no substatements.
+//   - deviate add must not add a property, other than a must, unique, or
+//     leaf-list default, that the target node already has.
+//   - deviate replace may only replace the config, default, mandatory,
+//     max-elements, min-elements, type, and units of the target node, and
+//     the default and units it replaces must exist.
+//   - deviate delete may only delete the default, must, unique, and units of
+//     the target node, and the value deleted must match that of the target.
+//   - a property may only be deviated on a node that can have it, for
+//     example, max-elements only on a list or leaf-list.
+//
+// Each deviation is checked against the schema tree as it was immediately
+// before Process applied the deviation, so Process must be called before
+// CheckDeviationValidity.  The errors of modules, ordered by name, come
+// before those of submodules, ordered by name, and then follow the order the
+// deviations are defined.  Process applies the deviations
+// regardless.
+func (ms *Modules) CheckDeviationValidity() []DeviationError {
+	var errs []DeviationError
+	for _, mods := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		for _, name := range sortedModuleNames(mods, true) {
+			for _, d := range ToEntry(mods[name]).Deviations {
+				errs = append(errs, d.invalid...)
+			}
+		}
+	}
+	return errs
+}
+
+// check returns the errors for the ways in which d may not deviate target,
+// which is nil if the target of d cannot be found.
+func (d *DeviatedEntry) check(target *Entry) []DeviationError {
+	dev, ok := d.Node.(*Deviation)
+	if !ok {
+		return nil
+	}
+	var errs []DeviationError
+	fail := func(n Node, format string, args ...interface{}) {
+		errs = append(errs, DeviationError{
+			Deviation: dev,
+			Target:    target,
+			Err:       fmt.Errorf("%s: "+format, append([]interface{}{Source(n)}, args...)...),
+		})
+	}
+	if target == nil {
+		fail(dev, "cannot find target node to deviate, %s", d.DeviatedPath)
+		return errs
+	}
+	for _, dv := range dev.Deviate {
+		if dv.Name == "not-supported" {
+			if len(dev.Deviate) > 1 {
+				fail(dv, "deviate not-supported of %s cannot be combined with other deviate statements", target.Path())
+			}
+			for _, s := range dv.Statement().SubStatements() {
+				if !strings.Contains(s.Keyword, ":") {
+					fail(dv, "deviate not-supported of %s cannot have a %s statement", target.Path(), s.Keyword)
+				}
+			}
+			continue
+		}
+		allowed := deviateProperties[dv.Name]
+		if allowed == nil {
+			// ToEntry reports unknown deviate arguments.
+			continue
+		}
+		for _, p := range deviateValues(dv) {
+			if !allowed[p.keyword] {
+				fail(dv, "%s of %s cannot be %s by a deviation", p.keyword, target.Path(), deviateVerbs[dv.Name])
+				continue
+			}
+			if !deviable(target, p.keyword) {
+				fail(dv, "%s cannot be deviated on %s, which is a %s", p.keyword, target.Path(), target.Node.Kind())
+				continue
+			}
+			have := targetValues(target, p.keyword)
+			switch dv.Name {
+			case "add":
+				multi := p.keyword == "must" || p.keyword == "unique" || (p.keyword == "default" && target.IsLeafList())
+				if !multi && len(have) > 0 {
+					fail(dv, "cannot add %s to %s, which already has %s %q", p.keyword, target.Path(), p.keyword, have[0])
+				}
+			case "replace":
+				// The other properties that may be replaced always have a
+				// value, either inherited or implied.
+				if (p.keyword == "default" || p.keyword == "units") && len(have) == 0 {
+					fail(dv, "cannot replace %s of %s, which has none", p.keyword, target.Path())
+				}
+			case "delete":
+				for _, v := range p.values {
+					if !hasString(have, v) {
+						fail(dv, "cannot delete %s %q of %s, which does not have it", p.keyword, v, target.Path())
+					}
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// A deviateValue is a property named by a deviate statement along with the
+// values given for it.
+type deviateValue struct {
+	keyword string
+	values  []string
+}
+
+// deviateValues returns the properties of dv in the order of RFC 7950.
+func deviateValues(dv *Deviate) []deviateValue {
+	var props []deviateValue
+	add := func(keyword string, v *Value) {
+		if v != nil {
+			props = append(props, deviateValue{keyword, []string{v.Name}})
+		}
+	}
+	add("config", dv.Config)
+	add("default", dv.Default)
+	add("mandatory", dv.Mandatory)
+	add("max-elements", dv.MaxElements)
+	add("min-elements", dv.MinElements)
+	if len(dv.Must) > 0 {
+		p := deviateValue{keyword: "must"}
+		for _, m := range dv.Must {
+			p.values = append(p.values, m.Name)
+		}
+		props = append(props, p)
+	}
+	if dv.Type != nil {
+		props = append(props, deviateValue{"type", []string{dv.Type.Name}})
+	}
+	if len(dv.Unique) > 0 {
+		p := deviateValue{keyword: "unique"}
+		for _, u := range dv.Unique {
+			p.values = append(p.values, strings.Join(strings.Fields(u.Name), " "))
+		}
+		props = append(props, p)
+	}
+	add("units", dv.Units)
+	return props
+}
+
+// deviable reports whether a node of the kind of e can have the property
+// named by keyword.
+func deviable(e *Entry, keyword string) bool {
+	switch keyword {
+	case "default":
+		return e.IsLeaf() || e.IsLeafList() || e.IsChoice()
+	case "mandatory":
+		return e.IsLeaf() || e.IsChoice() || e.Kind == AnyDataEntry || e.Kind == AnyXMLEntry
+	case "max-elements", "min-elements":
+		return e.IsList() || e.IsLeafList()
+	case "type", "units":
+		return e.IsLeaf() || e.IsLeafList()
+	case "unique":
+		return e.IsList()
+	case "must":
+		return e.IsContainer() || e.IsList() || e.IsLeaf() || e.IsLeafList() || e.Kind == AnyDataEntry || e.Kind == AnyXMLEntry
+	}
+	return true
+}
+
+// targetValues returns the values that e explicitly has for the property
+// named by keyword.
+func targetValues(e *Entry, keyword string) []string {
+	var values []string
+	switch keyword {
+	case "config":
+		if e.Config != TSUnset {
+			values = append(values, e.Config.String())
+		}
+	case "default":
+		if e.Kind == LeafEntry {
+			if defaults, fromType := e.DefaultValues(); !fromType {
+				values = defaults
+			}
+		} else if e.Default != "" {
+			values = append(values, e.Default)
+		}
+	case "mandatory":
+		if e.Mandatory != TSUnset {
+			values = append(values, e.Mandatory.String())
+		}
+	case "max-elements":
+		if e.ListAttr != nil && e.ListAttr.MaxElements != nil {
+			values = append(values, e.ListAttr.MaxElements.Name)
+		}
+	case "min-elements":
+		if e.ListAttr != nil && e.ListAttr.MinElements != nil {
+			values = append(values, e.ListAttr.MinElements.Name)
+		}
+	case "must":
+		for _, m := range e.MustConditions() {
+			values = append(values, m.XPath)
+		}
+	case "type":
+		if e.Type != nil {
+			values = append(values, e.Type.Name)
+		}
+	case "unique":
+		if e.ListAttr != nil {
+			for _, u := range e.ListAttr.Unique {
+				values = append(values, strings.Join(u, " "))
+			}
+		}
+	case "units":
+		if e.Units != "" {
+			values = append(values, e.Units)
+		}
+	}
+	return values
+}
+
+// hasString reports whether values contains v.
+func hasString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckDeviationValidity(t *testing.T) {
+	const target = `
+module target {
+  prefix "t";
+  namespace "urn:t";
+
+  container c {
+    must "count(l) < 10";
+    leaf name { type string; units "seconds"; default "x"; config true; }
+    leaf bare { type string; }
+    leaf-list ll { type string; default "a"; }
+    list l {
+      key "k";
+      unique "a b";
+      leaf k { type string; }
+      leaf a { type string; }
+      leaf b { type string; }
+    }
+  }
+}`
+	tests := []struct {
+		desc       string
+		deviations string
+		want       []string
+	}{{
+		desc: "valid deviations",
+		deviations: `
+  deviation /t:c/t:bare {
+    deviate add { units "ms"; default "y"; mandatory false; }
+  }
+  deviation /t:c/t:name {
+    deviate replace { units "ms"; default "z"; type uint8; }
+  }
+  deviation /t:c/t:ll {
+    deviate add { default "b"; }
+  }
+  deviation /t:c/t:l {
+    deviate delete { unique "a  b"; }
+    deviate add { unique "b"; max-elements 4; }
+  }
+  deviation /t:c {
+    deviate delete { must "count(l) < 10"; }
+  }`,
+	}, {
+		desc: "add of existing properties",
+		deviations: `
+  deviation /t:c/t:name {
+    deviate add { units "ms"; default "y"; config false; }
+  }`,
+		want: []string{
+			"dev.yang:6:5: cannot add config to /target/c/name, which already has config \"true\"",
+			"dev.yang:6:5: cannot add default to /target/c/name, which already has default \"x\"",
+			"dev.yang:6:5: cannot add units to /target/c/name, which already has units \"seconds\"",
+		},
+	}, {
+		desc: "replace of missing properties",
+		deviations: `
+  deviation /t:c/t:bare {
+    deviate replace { units "ms"; default "y"; must "1"; }
+  }`,
+		want: []string{
+			"dev.yang:6:5: cannot replace default of /target/c/bare, which has none",
+			"dev.yang:6:5: must of /target/c/bare cannot be replaced by a deviation",
+			"dev.yang:6:5: cannot replace units of /target/c/bare, which has none",
+		},
+	}, {
+		desc: "delete of values the target does not have",
+		deviations: `
+  deviation /t:c/t:name {
+    deviate delete { units "ms"; default "x"; mandatory true; }
+  }
+  deviation /t:c/t:l {
+    deviate delete { unique "b"; }
+  }`,
+		want: []string{
+			"dev.yang:6:5: mandatory of /target/c/name cannot be deleted by a deviation",
+			"dev.yang:6:5: cannot delete units \"ms\" of /target/c/name, which does not have it",
+			"dev.yang:9:5: cannot delete unique \"b\" of /target/c/l, which does not have it",
+		},
+	}, {
+		desc: "properties that do not apply to the target",
+		deviations: `
+  deviation /t:c {
+    deviate add { max-elements 3; units "ms"; }
+  }
+  deviation /t:c/t:name {
+    deviate add { unique "k"; }
+  }`,
+		want: []string{
+			"dev.yang:6:5: max-elements cannot be deviated on /target/c, which is a container",
+			"dev.yang:6:5: units cannot be deviated on /target/c, which is a container",
+			"dev.yang:9:5: unique cannot be deviated on /target/c/name, which is a leaf",
+		},
+	}, {
+		desc: "not-supported with other deviates",
+		deviations: `
+  deviation /t:c/t:bare {
+    deviate not-supported;
+    deviate add { units "ms"; }
+  }`,
+		want: []string{
+			"dev.yang:6:5: deviate not-supported of /target/c/bare cannot be combined with other deviate statements",
+		},
+	}, {
+		desc: "missing target",
+		deviations: `
+  deviation /t:c/t:nosuch {
+    deviate not-supported;
+  }`,
+		want: []string{
+			"dev.yang:5:3: cannot find target node to deviate, /t:c/t:nosuch",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(target, "target.yang"); err != nil {
+				t.Fatal(err)
+			}
+			dev := `module dev {
+  prefix "d";
+  namespace "urn:d";
+  import target { prefix "t"; }` + tt.deviations + `
+}`
+			if err := ms.Parse(dev, "dev.yang"); err != nil {
+				t.Fatal(err)
+			}
+			ms.Process()
+			var got []string
+			for _, err := range ms.CheckDeviationValidity() {
+				if err.Deviation == nil {
+					t.Errorf("%v: no deviation statement", err)
+				}
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("CheckDeviationValidity (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckDeviationValidityRevisions(t *testing.T) {
+	ms := NewModules()
+	for name, text := range map[string]string{
+		"target.yang": `module target { prefix "t"; namespace "urn:t";
+  leaf a { type string; }
+}`,
+		"dev-old.yang": `module dev { prefix "d"; namespace "urn:d";
+  import target { prefix "t"; }
+  revision 2020-01-01;
+  deviation /t:a { deviate delete { units "ms"; } }
+}`,
+		"dev.yang": `module dev { prefix "d"; namespace "urn:d";
+  import target { prefix "t"; }
+  revision 2021-01-01;
+  deviation /t:a { deviate delete { default "x"; } }
+}`,
+	} {
+		if err := ms.Parse(text, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ms.Process()
+	var got []string
+	for _, err := range ms.CheckDeviationValidity() {
+		got = append(got, err.Error())
+	}
+	// The deviations of the older revision are checked too.
+	want := []string{
+		`dev.yang:4:20: cannot delete default "x" of /target/a, which does not have it`,
+		`dev-old.yang:4:20: cannot delete units "ms" of /target/a, which does not have it`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CheckDeviationValidity (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the formatting of the documentation of entries.
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Documentation returns the description, units and reference of e as
+// paragraphs of plain text, separated by blank lines.  The units and reference
+// paragraphs are introduced by "Units: " and "Reference: ".  The units of e
+// are those of its units statement, or else of its type.  Paragraphs for
+// statements e does not have are omitted, so the empty string is returned if
+// e has none of them.
+func (e *Entry) Documentation() string {
+	return e.documentation("Units: ", "Reference: ")
+}
+
+// DocumentationMarkdown is like Documentation but introduces the units and
+// reference paragraphs with the Markdown emphasized headers "**Units:** " and
+// "**Reference:** ".  The text of the statements is not escaped.
+func (e *Entry) DocumentationMarkdown() string {
+	return e.documentation("**Units:** ", "**Reference:** ")
+}
+
+// documentation returns the documentation of e with the units and reference
+// paragraphs introduced by units and reference.
+func (e *Entry) documentation(units, reference string) string {
+	var paras []string
+	if d := strings.TrimSpace(e.Description); d != "" {
+		paras = append(paras, d)
+	}
+	u := e.Units
+	if u == "" && e.Type != nil {
+		u = e.Type.Units
+	}
+	if u != "" {
+		paras = append(paras, units+u)
+	}
+	if r := strings.TrimSpace(e.reference()); r != "" {
+		paras = append(paras, reference+r)
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// reference returns the argument of the reference statement of e, or the
+// empty string if e has none.  A reference set by a refine statement replaces
+// that of the node of e.
+func (e *Entry) reference() string {
+	if rs := e.Extra["reference"]; len(rs) > 0 {
+		if v, ok := rs[len(rs)-1].(*Value); ok {
+			return v.asString()
+		}
+	}
+	if e.Node == nil {
+		return ""
+	}
+	v := reflect.ValueOf(e.Node)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.Elem().FieldByName("Reference"); f.IsValid() {
+		if r, ok := f.Interface().(*Value); ok {
+			return r.asString()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+)
+
+func TestDocumentation(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef speed { type uint32; units "Mbps"; }
+  grouping g {
+    container r { description "grouped"; reference "RFC 1"; }
+  }
+  container c {
+    description "A container.";
+    reference "RFC 7950";
+    leaf mtu { type uint16; units "octets"; description "The MTU."; }
+    leaf speed { type speed; reference "IEEE 802.3"; }
+    leaf bare { type string; }
+  }
+  container refined { uses g { refine r { reference "RFC 2"; } } }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+	tests := []struct {
+		path         string
+		want, wantMD string
+	}{{
+		path:   "c",
+		want:   "A container.\n\nReference: RFC 7950",
+		wantMD: "A container.\n\n**Reference:** RFC 7950",
+	}, {
+		path:   "c/mtu",
+		want:   "The MTU.\n\nUnits: octets",
+		wantMD: "The MTU.\n\n**Units:** octets",
+	}, {
+		path:   "c/speed",
+		want:   "Units: Mbps\n\nReference: IEEE 802.3",
+		wantMD: "**Units:** Mbps\n\n**Reference:** IEEE 802.3",
+	}, {
+		path: "c/bare",
+	}, {
+		path:   "refined/r",
+		want:   "grouped\n\nReference: RFC 2",
+		wantMD: "grouped\n\n**Reference:** RFC 2",
+	}}
+	for _, tt := range tests {
+		e := mod.Find(tt.path)
+		if e == nil {
+			t.Errorf("%s not found", tt.path)
+			continue
+		}
+		if got := e.Documentation(); got != tt.want {
+			t.Errorf("%s: Documentation() = %q, want %q", tt.path, got, tt.want)
+		}
+		if got := e.DocumentationMarkdown(); got != tt.wantMD {
+			t.Errorf("%s: DocumentationMarkdown() = %q, want %q", tt.path, got, tt.wantMD)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the output of the module dependency graph in the
+// Graphviz DOT language.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteDOT writes the dependency graph of the modules and submodules in ms to
+// w as a Graphviz DOT directed graph.  There is one node for each module and
+// submodule name, with submodules drawn as boxes.  There is an edge from a
+// module to each module it imports and a dashed edge to each submodule it
+// includes.  An edge is labeled with the revision-date of its import or
+// include statement, if any.  Modules that are imported or included but not
+// in ms also have a node.
+func (ms *Modules) WriteDOT(w io.Writer) error {
+	var nodes, edges []string
+	seen := map[string]bool{}
+	addNode := func(name, attrs string) {
+		if !seen[name] {
+			seen[name] = true
+			nodes = append(nodes, fmt.Sprintf("  %q%s;\n", name, attrs))
+		}
+	}
+	addEdge := func(from, to string, rev *Value, style string) {
+		var attrs []string
+		if rev != nil {
+			attrs = append(attrs, fmt.Sprintf("label=%q", rev.Name))
+		}
+		if style != "" {
+			attrs = append(attrs, "style="+style)
+		}
+		edge := fmt.Sprintf("  %q -> %q", from, to)
+		if len(attrs) > 0 {
+			edge += " [" + strings.Join(attrs, ", ") + "]"
+		}
+		if !seen[edge] {
+			seen[edge] = true
+			edges = append(edges, edge+";\n")
+		}
+	}
+
+	for _, mods := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		for _, m := range mods {
+			if m.BelongsTo != nil {
+				addNode(m.Name, " [shape=box]")
+			} else {
+				addNode(m.Name, "")
+			}
+		}
+	}
+	for _, mods := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		for _, m := range mods {
+			for _, i := range m.Import {
+				addNode(i.Name, "")
+				addEdge(m.Name, i.Name, i.RevisionDate, "")
+			}
+			for _, i := range m.Include {
+				addNode(i.Name, " [shape=box]")
+				addEdge(m.Name, i.Name, i.RevisionDate, "dashed")
+			}
+		}
+	}
+	sort.Strings(nodes)
+	sort.Strings(edges)
+
+	var b bytes.Buffer
+	b.WriteString("digraph modules {\n")
+	for _, n := range nodes {
+		b.WriteString(n)
+	}
+	for _, e := range edges {
+		b.WriteString(e)
+	}
+	b.WriteString("}\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
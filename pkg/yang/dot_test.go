@@ -0,0 +1,70 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteDOT(t *testing.T) {
+	ms := NewModules()
+	for name, text := range map[string]string{
+		"dot-a": `module dot-a {
+  prefix "a";
+  namespace "urn:a";
+  import dot-b { prefix "b"; revision-date 2020-01-01; }
+  import dot-c { prefix "c"; }
+  include dot-sub;
+}`,
+		"dot-b": `module dot-b {
+  prefix "b";
+  namespace "urn:b";
+  revision 2020-01-01;
+  import dot-c { prefix "c"; }
+}`,
+		"dot-c": `module dot-c { prefix "c"; namespace "urn:c"; }`,
+		"dot-sub": `submodule dot-sub {
+  belongs-to dot-a { prefix "a"; }
+  import dot-c { prefix "c"; }
+}`,
+	} {
+		if err := ms.Parse(text, name+".yang"); err != nil {
+			t.Fatalf("Parse(%s): %v", name, err)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := ms.WriteDOT(&b); err != nil {
+		t.Fatalf("WriteDOT: %v", err)
+	}
+	want := `digraph modules {
+  "dot-a";
+  "dot-b";
+  "dot-c";
+  "dot-sub" [shape=box];
+  "dot-a" -> "dot-b" [label="2020-01-01"];
+  "dot-a" -> "dot-c";
+  "dot-a" -> "dot-sub" [style=dashed];
+  "dot-b" -> "dot-c";
+  "dot-sub" -> "dot-c";
+}
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("WriteDOT (-want, +got):\n%s", diff)
+	}
+}
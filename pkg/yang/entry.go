@@ -62,6 +62,48 @@ func (t TriState) String() string {
 	}
 }
 
+// A Status is the status of a schema node, as given by a status statement.
+// The statuses are ordered such that a greater Status is less current.
+type Status int
+
+// The possible values of a Status.
+const (
+	StatusCurrent = Status(iota)
+	StatusDeprecated
+	StatusObsolete
+)
+
+// String returns the YANG keyword for s.
+func (s Status) String() string {
+	switch s {
+	case StatusCurrent:
+		return "current"
+	case StatusDeprecated:
+		return "deprecated"
+	case StatusObsolete:
+		return "obsolete"
+	default:
+		return fmt.Sprintf("status-%d", s)
+	}
+}
+
+// statusValue returns the Status named by v, which is StatusCurrent if v is
+// nil.  An error is returned if v does not name a status.
+func statusValue(v *Value) (Status, error) {
+	if v == nil {
+		return StatusCurrent, nil
+	}
+	switch v.Name {
+	case "current":
+		return StatusCurrent, nil
+	case "deprecated":
+		return StatusDeprecated, nil
+	case "obsolete":
+		return StatusObsolete, nil
+	}
+	return StatusCurrent, fmt.Errorf("%s: invalid status value: %s", Source(v), v.Name)
+}
+
 // An Entry represents a single node (directory or leaf) created from the
 // AST.  Directory entries have a non-nil Dir entry.  Leaf nodes have a nil
 // Dir entry.  If Errors is not nil then the only other valid field is Node.
@@ -77,10 +119,18 @@ type Entry struct {
 	Config      TriState  // config state of this entry, if known
 	Prefix      *Value    `json:",omitempty"` // prefix to use from this point down
 	Mandatory   TriState  `json:",omitempty"` // whether this entry is mandatory in the tree
+	Status      Status    `json:",omitempty"` // status of this entry, see EffectiveStatus
 
 	// Fields associated with directory nodes
-	Dir map[string]*Entry `json:",omitempty"`
-	Key string            `json:",omitempty"` // Optional key name for lists (i.e., maps)
+	Dir      map[string]*Entry `json:",omitempty"`
+	Key      string            `json:",omitempty"` // Optional key name for lists (i.e., maps)
+	Presence *Value            `json:",omitempty"` // presence statement of a container, if any
+
+	// OrderedDir holds the entries of Dir in the order they were defined
+	// in the YANG source, as returned by OrderedChildren.  It is kept in
+	// sync with Dir by ToEntry and Modules.Process; SyncOrderedDir must be
+	// called after changing the Dir of an Entry.
+	OrderedDir []NamedEntry `json:"-"`
 
 	// Fields associated with leaf nodes
 	Type *YangType    `json:",omitempty"`
@@ -101,6 +151,11 @@ type Entry struct {
 	Deviate    map[deviationType][]*Entry `json:"-"`
 	Uses       []*UsesStmt                `json:",omitempty"` // Uses merged into this entry.
 
+	// Case is the case this entry was defined in when choice and case
+	// nodes have been flattened by the FlattenChoices option.  The choice
+	// of the case is Case.Parent.
+	Case *Entry `json:"-"`
+
 	// Extra maps all the unsupported fields to their values
 	Extra map[string][]interface{} `json:"-"`
 
@@ -115,19 +170,87 @@ type Entry struct {
 	// the augmenting entity per RFC6020 Section 7.15.2. The namespace
 	// of the Entry should be accessed using the Namespace function.
 	namespace *Value
+
+	// order records the names of the entries in Dir in the order they
+	// were defined.  The children of the Entry should be accessed in
+	// order using the OrderedChildren function.
+	order []string
+
+	// groupingOrigin is the grouping the Entry was defined in, if it was
+	// added to the tree by the expansion of a uses statement.
+	groupingOrigin *Grouping
+	// usesOrigin is the uses statement whose expansion of groupingOrigin
+	// added the Entry to the tree.
+	usesOrigin *Uses
+
+	// refinements are the sources of the refine statements applied to
+	// the Entry.
+	refinements []*Statement
+
+	// defaults are the values of the default statements of a leaf-list,
+	// the first of which is also Default.
+	defaults []string
+
+	// inheritedWhen are the when statements of the augment and uses
+	// statements that added the Entry to the tree, outermost first.
+	inheritedWhen []WhenCondition
+
+	// stats caches the statistics of the sub-tree rooted at the Entry.
+	// It is cleared when a descendant is added or removed.
+	stats *SchemaStats
+	// requiredBy are the entries with must statements that refer to the
+	// Entry, as found by Process.
+	requiredBy []*Entry
 }
 
-// An RPCEntry contains information related to an RPC Node.
+// A NamedEntry is an entry of the Dir of an Entry, and the name it has in
+// Dir.
+type NamedEntry struct {
+	Name  string
+	Entry *Entry
+}
+
+// An RPCEntry contains information related to an RPC Node.  Input and Output
+// are also the children named input and output in the Dir of the rpc or
+// action.
 type RPCEntry struct {
 	Input  *Entry
 	Output *Entry
 }
 
+// RPCInput returns the input of e, an rpc or action, or an error if e is not
+// an rpc or action or has no input statement.
+func (e *Entry) RPCInput() (*Entry, error) {
+	if e.RPC == nil {
+		return nil, fmt.Errorf("%s: %s is not an rpc or action", Source(e.Node), e.Path())
+	}
+	if e.RPC.Input == nil {
+		return nil, fmt.Errorf("%s: %s has no input", Source(e.Node), e.Path())
+	}
+	return e.RPC.Input, nil
+}
+
+// RPCOutput returns the output of e, an rpc or action, or an error if e is
+// not an rpc or action or has no output statement.
+func (e *Entry) RPCOutput() (*Entry, error) {
+	if e.RPC == nil {
+		return nil, fmt.Errorf("%s: %s is not an rpc or action", Source(e.Node), e.Path())
+	}
+	if e.RPC.Output == nil {
+		return nil, fmt.Errorf("%s: %s has no output", Source(e.Node), e.Path())
+	}
+	return e.RPC.Output, nil
+}
+
 // A ListAttr is associated with an Entry that represents a List node
 type ListAttr struct {
 	MinElements *Value // leaf-list or list MUST have at least min-elements
 	MaxElements *Value // leaf-list or list has at most max-elements
 	OrderedBy   *Value // order of entries determined by "system" or "user"
+
+	// Unique holds the descendant schema node identifiers named by each
+	// unique statement of a list, in the order they were given.
+	Unique [][]string `json:",omitempty"`
 }
 
 // A UsesStmt associates a *Uses with its referenced grouping *Entry
@@ -136,6 +259,80 @@ type UsesStmt struct {
 	Grouping *Entry
 }
 
+// Ancestors returns the ancestors of e, from its parent up to the root of its
+// Entry tree, which is normally the Entry of a module.  An Entry without a
+// parent returns nil.
+func (e *Entry) Ancestors() []*Entry {
+	var ancestors []*Entry
+	for p := e.Parent; p != nil; p = p.Parent {
+		ancestors = append(ancestors, p)
+	}
+	return ancestors
+}
+
+// Module returns the Entry of the module or submodule at the root of the
+// Entry tree of e, which is e itself if e is the Entry of a module.  Module
+// returns nil if the root of the tree of e is not the Entry of a module.
+func (e *Entry) Module() *Entry {
+	for e.Parent != nil {
+		e = e.Parent
+	}
+	if _, ok := e.Node.(*Module); !ok {
+		return nil
+	}
+	return e
+}
+
+// Submodule returns the name of the submodule whose statement defines e, or
+// the empty string if e is defined by a module rather than a submodule.  An
+// Entry expanded from a grouping is defined by the statement in the grouping,
+// so it returns the submodule of the grouping.  The namespace of e, and the
+// module returned by InstantiatingModule, are still those of the module the
+// submodule belongs to.
+func (e *Entry) Submodule() string {
+	if e.Node == nil {
+		return ""
+	}
+	if m := RootNode(e.Node); m != nil && m.Kind() == "submodule" {
+		return m.Name
+	}
+	return ""
+}
+
+// Sibling returns the sibling of e in the data tree named name: the data node
+// named name that has the same parent in the data tree as e, as would be
+// named by the path "../name" from e.  Choices and cases are not data nodes,
+// so the data nodes within the cases of the choices of the parent are
+// siblings, while the choices and cases themselves are not.  An error is
+// returned if e has no parent or no such sibling.
+func (e *Entry) Sibling(name string) (*Entry, error) {
+	p := e.DataParent()
+	if p == nil {
+		return nil, fmt.Errorf("%s has no parent", e.Path())
+	}
+	if s := p.dataChild(name); s != nil && s != e {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%s has no sibling %s", e.Path(), name)
+}
+
+// Siblings returns the siblings of e in the data tree, as defined by Sibling,
+// in the order they were defined.  An Entry without a parent has no
+// siblings.
+func (e *Entry) Siblings() []*Entry {
+	p := e.DataParent()
+	if p == nil {
+		return nil
+	}
+	var siblings []*Entry
+	for _, s := range p.Flatten() {
+		if s != e {
+			siblings = append(siblings, s)
+		}
+	}
+	return siblings
+}
+
 // Modules returns the Modules structure that e is part of.  This is needed
 // when looking for rooted nodes not part of this Entry tree.
 func (e *Entry) Modules() *Modules {
@@ -161,6 +358,37 @@ func (e *Entry) IsLeafList() bool {
 	return !e.IsDir() && e.Kind == LeafEntry && e.ListAttr != nil
 }
 
+// IsEmptyLeaf returns true if e is a leaf or leaf-list of type empty, or of
+// a type derived from it.  The value of such a leaf is its presence in the
+// data tree: it has no value, and is encoded as [null] in JSON (RFC 7951
+// section 6.9) and as an empty element in XML.
+func (e *Entry) IsEmptyLeaf() bool {
+	return !e.IsDir() && e.Kind == LeafEntry && e.Type != nil && e.Type.Kind == Yempty
+}
+
+// EnumValues returns the enums of e, a leaf or leaf-list of an enumeration
+// type, in the order they were declared.  An enum of a type derived from an
+// enumeration has the description and reference of the base enum unless it
+// declares its own.  An error is returned if e is not of an enumeration type.
+func (e *Entry) EnumValues() ([]EnumValue, error) {
+	if e.Type == nil || e.Type.Kind != Yenum {
+		return nil, fmt.Errorf("%s is not of an enumeration type", e.Path())
+	}
+	return append([]EnumValue(nil), e.Type.enumValues...), nil
+}
+
+// ValidateEnum returns an error if s is not the name of an enum of e, a leaf
+// or leaf-list of an enumeration type.
+func (e *Entry) ValidateEnum(s string) error {
+	if e.Type == nil || e.Type.Kind != Yenum || e.Type.Enum == nil {
+		return fmt.Errorf("%s is not of an enumeration type", e.Path())
+	}
+	if !e.Type.Enum.IsDefined(s) {
+		return fmt.Errorf("%s: %q is not a valid enum", e.Path(), s)
+	}
+	return nil
+}
+
 // IsList returns true if e is a list.
 func (e *Entry) IsList() bool {
 	return e.IsDir() && e.ListAttr != nil
@@ -171,6 +399,13 @@ func (e *Entry) IsContainer() bool {
 	return e.Kind == DirectoryEntry && e.ListAttr == nil
 }
 
+// IsPresenceContainer returns true if e is a container with a presence
+// statement.  The existence of a presence container in the data tree has
+// meaning even when the container is empty.
+func (e *Entry) IsPresenceContainer() bool {
+	return e.IsContainer() && e.Presence != nil
+}
+
 // IsChoice returns true if the entry is a choice node within the schema.
 func (e *Entry) IsChoice() bool {
 	return e.Kind == ChoiceEntry
@@ -181,6 +416,670 @@ func (e *Entry) IsCase() bool {
 	return e.Kind == CaseEntry
 }
 
+// AsModule returns the module or submodule e was created from, if e is a
+// module or submodule.
+func (e *Entry) AsModule() (*Module, bool) {
+	m, ok := e.Node.(*Module)
+	return m, ok
+}
+
+// AsContainer returns the container statement e was created from, if e is a
+// container.
+func (e *Entry) AsContainer() (*Container, bool) {
+	c, ok := e.Node.(*Container)
+	return c, ok
+}
+
+// AsList returns the list statement e was created from, if e is a list.
+func (e *Entry) AsList() (*List, bool) {
+	l, ok := e.Node.(*List)
+	return l, ok
+}
+
+// AsLeaf returns the leaf statement e was created from, if e is a leaf.
+func (e *Entry) AsLeaf() (*Leaf, bool) {
+	if e.ListAttr != nil {
+		return nil, false
+	}
+	l, ok := e.Node.(*Leaf)
+	return l, ok
+}
+
+// AsLeafList returns the leaf-list statement e was created from, if e is a
+// leaf-list.  The Node of a leaf-list Entry is a leaf made from the
+// leaf-list, so the leaf-list is found among the leaf-lists of the parent of
+// that leaf.
+func (e *Entry) AsLeafList() (*LeafList, bool) {
+	l, ok := e.Node.(*Leaf)
+	if !ok || e.ListAttr == nil || l.Parent == nil {
+		return nil, false
+	}
+	v := reflect.ValueOf(l.Parent).Elem()
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	f := v.FieldByName("LeafList")
+	if !f.IsValid() {
+		return nil, false
+	}
+	lls, _ := f.Interface().([]*LeafList)
+	for _, ll := range lls {
+		if ll.Source == l.Source {
+			return ll, true
+		}
+	}
+	return nil, false
+}
+
+// AsChoice returns the choice statement e was created from, if e is a
+// choice.
+func (e *Entry) AsChoice() (*Choice, bool) {
+	c, ok := e.Node.(*Choice)
+	return c, ok
+}
+
+// AsCase returns the case statement e was created from, if e is a case.  The
+// case of a shorthand case statement of a choice, which has no case statement,
+// is a case made from the shorthand statement.
+func (e *Entry) AsCase() (*Case, bool) {
+	c, ok := e.Node.(*Case)
+	return c, ok
+}
+
+// EffectiveStatus returns the status of e taking into account the status of
+// its ancestors: a node within a deprecated node is at least deprecated, and
+// a node within an obsolete node is obsolete.
+func (e *Entry) EffectiveStatus() Status {
+	st := StatusCurrent
+	for ; e != nil; e = e.Parent {
+		if e.Status > st {
+			st = e.Status
+		}
+	}
+	return st
+}
+
+// IsCurrent returns true if the effective status of e, as returned by
+// EffectiveStatus, is current.
+func (e *Entry) IsCurrent() bool {
+	return e.EffectiveStatus() == StatusCurrent
+}
+
+// IsDeprecated returns true if the effective status of e, as returned by
+// EffectiveStatus, is deprecated.  An obsolete entry is not deprecated.
+func (e *Entry) IsDeprecated() bool {
+	return e.EffectiveStatus() == StatusDeprecated
+}
+
+// IsObsolete returns true if the effective status of e, as returned by
+// EffectiveStatus, is obsolete.
+func (e *Entry) IsObsolete() bool {
+	return e.EffectiveStatus() == StatusObsolete
+}
+
+// raiseStatus sets the Status of e to st if st is less current than the
+// Status of e.
+func (e *Entry) raiseStatus(st Status) {
+	if st > e.Status {
+		e.Status = st
+	}
+}
+
+// typeStatus returns the least current status of the typedefs that y is
+// derived from, or StatusCurrent if y is not derived from a typedef.
+func typeStatus(y *YangType) Status {
+	st := StatusCurrent
+	for ; y != nil && y.Base != nil; y = y.Base.YangType {
+		td, ok := y.Base.Parent.(*Typedef)
+		if !ok {
+			break
+		}
+		if ts, err := statusValue(td.Status); err == nil && ts > st {
+			st = ts
+		}
+		if y.Base.YangType == y {
+			break
+		}
+	}
+	return st
+}
+
+// IsMandatory returns true if e is a mandatory node: a leaf, anydata or
+// anyxml with "mandatory true", a choice with "mandatory true" and no default
+// case, or a list or leaf-list with a min-elements greater than zero.  Unlike
+// the Mandatory field, which records the mandatory statement of e as written,
+// IsMandatory reports whether instance data must contain e.
+func (e *Entry) IsMandatory() bool {
+	switch {
+	case e.minElements() > 0:
+		return true
+	case e.Mandatory != TSTrue:
+		return false
+	case e.Kind == ChoiceEntry:
+		return e.Default == ""
+	}
+	return true
+}
+
+// MandatoryChildren returns the immediate children of e that are mandatory
+// nodes, as reported by IsMandatory, sorted by name.
+func (e *Entry) MandatoryChildren() []*Entry {
+	var names []string
+	for k, ce := range e.Dir {
+		if ce.IsMandatory() {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	children := make([]*Entry, 0, len(names))
+	for _, k := range names {
+		children = append(children, e.Dir[k])
+	}
+	return children
+}
+
+// OrderedChildren returns the children of e in the order they were defined
+// in the YANG source.  Children merged from a grouping appear at the position
+// of the uses statement, in the order they were defined in the grouping.
+// Children added by an augment follow the children defined by e itself.
+func (e *Entry) OrderedChildren() []*Entry {
+	var children []*Entry
+	for _, k := range e.orderedKeys() {
+		children = append(children, e.Dir[k])
+	}
+	return children
+}
+
+// Flatten returns the children of e in the order they were defined,
+// replacing each choice and case by its own flattened children.  The result
+// contains only the data nodes (leaves, leaf-lists, containers, lists,
+// anydata and anyxml) that are children of e in the data tree.
+func (e *Entry) Flatten() []*Entry {
+	var entries []*Entry
+	for _, c := range e.OrderedChildren() {
+		switch c.Kind {
+		case ChoiceEntry, CaseEntry:
+			entries = append(entries, c.Flatten()...)
+		default:
+			entries = append(entries, c)
+		}
+	}
+	return entries
+}
+
+// walk calls f for e and each of its descendants, including the input and
+// output of an rpc or action, in the order they are defined and each before
+// its own descendants.  The descendants of an entry for which f returns
+// false are not walked.
+func (e *Entry) walk(f func(*Entry) bool) {
+	if e == nil || !f(e) {
+		return
+	}
+	for _, c := range e.OrderedChildren() {
+		c.walk(f)
+	}
+}
+
+// FlattenAll is like Flatten but also includes the flattened descendants of
+// each container and list, each immediately following its parent.
+func (e *Entry) FlattenAll() []*Entry {
+	var entries []*Entry
+	for _, c := range e.Flatten() {
+		entries = append(entries, c)
+		if c.IsContainer() || c.IsList() {
+			entries = append(entries, c.FlattenAll()...)
+		}
+	}
+	return entries
+}
+
+// ToFlatMap returns the descendants of e, including the choices and cases and
+// the input and output of RPCs, keyed by their Path.
+func (e *Entry) ToFlatMap() map[string]*Entry {
+	m := map[string]*Entry{}
+	var add func(e *Entry)
+	add = func(e *Entry) {
+		for _, c := range e.Dir {
+			m[c.Path()] = c
+			add(c)
+		}
+	}
+	add(e)
+	return m
+}
+
+// MaxDepth returns the length of the longest path from e to any of its
+// descendants in the Entry tree, including the input and output of an RPC, so
+// a leaf or an empty container has a depth of 0.  Choice and case entries are
+// counted as levels of the tree.  An entry that is its own ancestor, which
+// can only occur in trees that have been built or changed by hand, is not
+// descended into again.
+func (e *Entry) MaxDepth() int {
+	return e.maxDepth(map[*Entry]bool{})
+}
+
+// maxDepth returns the MaxDepth of e, not descending into the entries of
+// ancestors.
+func (e *Entry) maxDepth(ancestors map[*Entry]bool) int {
+	if e == nil || ancestors[e] {
+		return 0
+	}
+	ancestors[e] = true
+	defer delete(ancestors, e)
+
+	depth := 0
+	for _, c := range e.Dir {
+		if c == nil || ancestors[c] {
+			continue
+		}
+		if d := c.maxDepth(ancestors) + 1; d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// KeyEntries returns the key leaves of the list e in the order they are named
+// by e's key statement.  An error is returned if a key does not name a leaf
+// that is a direct child of e, or if a key leaf is config true while e is
+// config false, or vice versa.  KeyEntries returns nil if e has no key.  A key
+// may be written with a prefix.
+func (e *Entry) KeyEntries() ([]*Entry, error) {
+	var keys []*Entry
+	for _, k := range strings.Fields(e.Key) {
+		_, name := getPrefix(k)
+		ke := e.Dir[name]
+		switch {
+		case ke == nil:
+			return nil, fmt.Errorf("%s: key %q is not a child of list %s", Source(e.Node), k, e.Path())
+		case !ke.IsLeaf():
+			return nil, fmt.Errorf("%s: key %q of list %s is not a leaf", Source(e.Node), k, e.Path())
+		case ke.ReadOnly() != e.ReadOnly():
+			return nil, fmt.Errorf("%s: key %q of list %s has a different config value than the list", Source(e.Node), k, e.Path())
+		}
+		keys = append(keys, ke)
+	}
+	return keys, nil
+}
+
+// IsKey reports whether e is a leaf named, with or without a prefix, by the
+// key statement of the list that is its parent.
+func (e *Entry) IsKey() bool {
+	if !e.IsLeaf() || e.Parent == nil || !e.Parent.IsList() {
+		return false
+	}
+	for _, k := range strings.Fields(e.Parent.Key) {
+		if _, name := getPrefix(k); name == e.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenChoices replaces each choice in the tree rooted at e with the data
+// nodes within its cases, as returned by Flatten, setting the Case of each of
+// them to the case it was defined in.  The data nodes take the position of
+// their choice in the order of e's children.  A data node with the same name
+// as another child of e is not moved, and an error is returned for it; YANG
+// requires the names of the nodes within the cases of a choice to be unique
+// amongst the siblings of the choice.
+func (e *Entry) flattenChoices() []error {
+	if e == nil {
+		return nil
+	}
+	var errs []error
+	keys := e.orderedKeys()
+	for _, k := range keys {
+		if e.Dir[k].Kind != ChoiceEntry {
+			continue
+		}
+		dir := e.Dir
+		e.Dir = make(map[string]*Entry, len(dir))
+		e.order = nil
+		for _, k := range keys {
+			c := dir[k]
+			if c.Kind != ChoiceEntry {
+				e.add(k, c)
+				continue
+			}
+			for _, ce := range c.Flatten() {
+				if se := e.Dir[ce.Name]; se != nil {
+					errs = append(errs, fmt.Errorf("%s: %s in choice %s conflicts with %s from %s", Source(ce.Node), ce.Name, c.Path(), se.Path(), Source(se.Node)))
+					continue
+				}
+				if ce.Parent.Kind == CaseEntry {
+					ce.Case = ce.Parent
+				}
+				e.add(ce.Name, ce)
+			}
+		}
+		break
+	}
+	for _, k := range e.orderedKeys() {
+		errs = append(errs, e.Dir[k].flattenChoices()...)
+	}
+	return errs
+}
+
+// checkAugmented returns an error for each node of the augment a, which was
+// merged into e, that was not added to e because e already had a node of the
+// same name.  The modules of both nodes are named, as such collisions are
+// typically between augments from different modules.
+func (e *Entry) checkAugmented(a *Entry) []error {
+	var errs []error
+	for _, k := range a.orderedKeys() {
+		an, se := a.Dir[k], e.Dir[k]
+		if se == nil || se.Node == an.Node {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: augment from module %s adds %q to %s, which is already defined at %s by module %s",
+			Source(an.Node), moduleName(an.Node), k, e.Path(), Source(se.Node), moduleName(se.Node)))
+	}
+	return errs
+}
+
+// DefaultCase returns the case named by the default statement of the choice
+// e, or nil if e is not a choice or has no default case.
+func (e *Entry) DefaultCase() *Entry {
+	if e.Kind != ChoiceEntry || e.Default == "" {
+		return nil
+	}
+	if c := e.Dir[e.Default]; c != nil && c.Kind == CaseEntry {
+		return c
+	}
+	return nil
+}
+
+// ChoiceCases returns the data nodes within each case of the choice e, keyed
+// by the name of the case.  The data nodes of a case are returned as by
+// Flatten, in the order they were defined.  A child of e that is not a case
+// is a shorthand case, which is named by the child and whose data nodes are
+// the child itself, or the flattened children of the child if it is also a
+// choice.  An error is returned if e is not a choice.
+func (e *Entry) ChoiceCases() (map[string][]*Entry, error) {
+	if e.Kind != ChoiceEntry {
+		return nil, fmt.Errorf("%s is not a choice", e.Path())
+	}
+	cases := make(map[string][]*Entry, len(e.Dir))
+	for _, c := range e.OrderedChildren() {
+		switch c.Kind {
+		case CaseEntry, ChoiceEntry:
+			cases[c.Name] = c.Flatten()
+		default:
+			cases[c.Name] = []*Entry{c}
+		}
+	}
+	return cases, nil
+}
+
+// CaseDefaultValue returns the default value of e, as returned by
+// DefaultValue, taking into account the cases of the choices e is within.
+// The default of a node within a case only applies when that case is the
+// active case of its choice.  active maps the path of a choice, as returned
+// by Path, to the name of its active case.  The default case of a choice is
+// active when the choice is not in active.  The choices above the closest
+// list containing e are not considered, as an instance of the list implies
+// that its case is active.  An empty string is returned if any of the cases
+// e is within is not active.
+func (e *Entry) CaseDefaultValue(active map[string]string) string {
+	for p := e; p.Parent != nil && !p.Parent.IsList(); p = p.Parent {
+		if p.Case != nil {
+			// p was moved out of its case by the FlattenChoices option.
+			p = p.Case
+		}
+		if p.Kind != CaseEntry || p.Parent.Kind != ChoiceEntry {
+			continue
+		}
+		name, ok := active[p.Parent.Path()]
+		if !ok {
+			name = p.Parent.Default
+		}
+		if name != p.Name {
+			return ""
+		}
+	}
+	return e.DefaultValue()
+}
+
+// UniqueConstraints returns the descendant schema node identifiers named by
+// each unique statement of the list e, one slice per unique statement, in the
+// order they are given.  An error is returned if e is not a list.
+func (e *Entry) UniqueConstraints() ([][]string, error) {
+	if !e.IsList() {
+		return nil, fmt.Errorf("%s: %s is not a list", Source(e.Node), e.Path())
+	}
+	var uniques [][]string
+	for _, paths := range e.ListAttr.Unique {
+		uniques = append(uniques, append([]string(nil), paths...))
+	}
+	return uniques, nil
+}
+
+// UniqueEntries returns the leaves named by each unique statement of the list
+// e, in the order they are given in the unique statement.  An error is
+// returned if a descendant schema node identifier in a unique statement does
+// not name a leaf within e.
+func (e *Entry) UniqueEntries() ([][]*Entry, error) {
+	if e.ListAttr == nil {
+		return nil, nil
+	}
+	var uniques [][]*Entry
+	for _, paths := range e.ListAttr.Unique {
+		var leaves []*Entry
+		for _, p := range paths {
+			le := e
+			for _, elem := range strings.Split(p, "/") {
+				_, name := getPrefix(elem)
+				if le = le.Dir[name]; le == nil {
+					break
+				}
+			}
+			switch {
+			case le == nil:
+				return nil, fmt.Errorf("%s: unique %q of list %s is not a descendant of the list", Source(e.Node), p, e.Path())
+			case !le.IsLeaf():
+				return nil, fmt.Errorf("%s: unique %q of list %s is not a leaf", Source(e.Node), p, e.Path())
+			}
+			leaves = append(leaves, le)
+		}
+		uniques = append(uniques, leaves)
+	}
+	return uniques, nil
+}
+
+// checkSchema returns the errors found in the lists and choices of the tree
+// rooted at e.  Every key must name a leaf that is a direct child of its
+// list and have the config of the list, a key leaf must not be mandatory
+// false, a list that is config true must have a key if
+// ParseOptions.RequireListKeys is set, and the unique statements of a list
+// must name leaves within the list.  The default of a choice must name one of its cases and a
+// mandatory choice must not have a default.  config is false within an rpc,
+// action or notification, where lists are not configuration and need not
+// have a key.
+func (e *Entry) checkSchema(config bool) []error {
+	if e == nil {
+		return nil
+	}
+	var errs []error
+	if e.IsList() {
+		keys, err := e.KeyEntries()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, ke := range keys {
+			if ke.Mandatory == TSFalse {
+				errs = append(errs, fmt.Errorf("%s: key %q of list %s cannot be mandatory false", Source(ke.Node), ke.Name, e.Path()))
+			}
+		}
+		if e.Key == "" && config && !e.ReadOnly() && ParseOptions.RequireListKeys {
+			errs = append(errs, fmt.Errorf("%s: config list %s has no key", Source(e.Node), e.Path()))
+		}
+		if _, err := e.UniqueEntries(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, a := range e.Augmented {
+		errs = append(errs, e.checkAugmented(a)...)
+	}
+	if e.Kind == ChoiceEntry && e.Default != "" {
+		switch {
+		case e.Mandatory == TSTrue:
+			errs = append(errs, fmt.Errorf("%s: mandatory choice %s cannot have a default", Source(e.Node), e.Path()))
+		case e.DefaultCase() == nil:
+			errs = append(errs, fmt.Errorf("%s: default %q of choice %s is not a case of the choice", Source(e.Node), e.Default, e.Path()))
+		}
+	}
+	if err := e.checkDefault(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, k := range e.orderedKeys() {
+		c := e.Dir[k]
+		errs = append(errs, c.checkSchema(config && c.RPC == nil && c.Kind != NotificationEntry)...)
+	}
+	return errs
+}
+
+// checkDefault returns an error if e is a leaf or leaf-list whose defaults
+// are not valid values of its type, or a leaf-list with defaults that must
+// have at least one value.  The prefixes of identities in a default are
+// resolved in the module of the leaf if the leaf has its own default, or of
+// the typedef that provides the default otherwise.
+func (e *Entry) checkDefault() error {
+	if e.Type == nil || e.Kind != LeafEntry || e.Node == nil {
+		return nil
+	}
+	values := []string{e.DefaultValue()}
+	if e.ListAttr != nil {
+		var fromType bool
+		values, fromType = e.DefaultValues()
+		// A leaf-list with min-elements of at least one always has
+		// values, so it cannot have defaults (section 7.7.4 of RFC 7950).
+		if min := e.ListAttr.MinElements; len(values) > 0 && !fromType && min != nil && min.Name != "0" {
+			return fmt.Errorf("%s: leaf-list %s has a default and min-elements %s", Source(e.Node), e.Path(), min.Name)
+		}
+	}
+	m := RootNode(e.Node)
+	if e.Default == "" && e.Type.Base != nil {
+		m = RootNode(e.Type.Base)
+	}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if err := e.Type.validateDefault(value, m); err != nil {
+			return fmt.Errorf("%s: invalid default %q of %s: %v", Source(e.Node), value, e.Path(), err)
+		}
+	}
+	return nil
+}
+
+// orderedKeys returns the keys of e.Dir in the order they were defined.  Any
+// keys whose order is not known are returned last, sorted by name.
+func (e *Entry) orderedKeys() []string {
+	if len(e.Dir) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(e.Dir))
+	seen := map[string]bool{}
+	for _, k := range e.order {
+		if e.Dir[k] != nil && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == len(e.Dir) {
+		return keys
+	}
+	var rest []string
+	for k := range e.Dir {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// sortOrder reorders the recorded order of e's children to match the order
+// of the statements of n that defined them.  merged maps each uses and
+// include statement of n to the names of the children it merged into e.
+func (e *Entry) sortOrder(n Node, merged map[*Statement][]string) {
+	s := n.Statement()
+	if s == nil {
+		return
+	}
+	order := make([]string, 0, len(e.order))
+	seen := map[string]bool{}
+	appendName := func(k string) {
+		if !seen[k] {
+			seen[k] = true
+			order = append(order, k)
+		}
+	}
+	for _, ss := range s.SubStatements() {
+		if names, ok := merged[ss]; ok {
+			for _, k := range names {
+				appendName(k)
+			}
+			continue
+		}
+		name := ss.Argument
+		switch ss.Keyword {
+		case "input", "output":
+			name = ss.Keyword
+		}
+		if c := e.Dir[name]; c != nil && c.Node != nil && c.Node.Statement() == ss {
+			appendName(name)
+		}
+	}
+	// Keep any remaining children in the order they were added.
+	for _, k := range e.order {
+		appendName(k)
+	}
+	e.order = order
+	e.syncOrderedDir()
+}
+
+// SyncOrderedDir rebuilds the OrderedDir of e and of each of its descendants
+// from their Dir.  The entries whose order of definition is not known, such
+// as those added to the Dir of an Entry constructed programmatically, follow
+// the entries whose order is known, in sorted order.
+func SyncOrderedDir(e *Entry) {
+	if e == nil {
+		return
+	}
+	e.syncOrderedDir()
+	for _, ne := range e.OrderedDir {
+		SyncOrderedDir(ne.Entry)
+	}
+}
+
+// syncOrderedDir rebuilds the OrderedDir of e from its Dir.
+func (e *Entry) syncOrderedDir() {
+	keys := e.orderedKeys()
+	if len(keys) == 0 {
+		e.OrderedDir = nil
+		return
+	}
+	e.OrderedDir = make([]NamedEntry, len(keys))
+	for i, k := range keys {
+		e.OrderedDir[i] = NamedEntry{Name: k, Entry: e.Dir[k]}
+	}
+}
+
+// minElements returns the min-elements of e, or 0 if e is not a list or
+// leaf-list or has no valid min-elements statement.
+func (e *Entry) minElements() uint64 {
+	if e.ListAttr == nil || e.ListAttr.MinElements == nil {
+		return 0
+	}
+	n, err := ParseInt(e.ListAttr.MinElements.Name)
+	if err != nil || n.Kind != Positive {
+		return 0
+	}
+	return n.Value
+}
+
 // Print prints e to w in human readable form.
 func (e *Entry) Print(w io.Writer) {
 	if e.Description != "" {
@@ -302,19 +1201,34 @@ func (e *Entry) addError(err error) {
 }
 
 // importErrors imports all the errors from c and its children into e.
+// Errors already on e are not imported again, as the errors of c usually
+// include those imported from its own children.
 func (e *Entry) importErrors(c *Entry) {
+	seen := map[error]bool{}
+	for _, err := range e.Errors {
+		seen[err] = true
+	}
+	e.importErrorsFrom(c, seen)
+}
+
+// importErrorsFrom imports the errors from c and its children into e that
+// are not in seen, adding them to seen.
+func (e *Entry) importErrorsFrom(c *Entry, seen map[error]bool) {
 	if c == nil {
 		return
 	}
 	for _, err := range c.Errors {
-		e.addError(err)
+		if err != nil && !seen[err] {
+			seen[err] = true
+			e.addError(err)
+		}
 	}
 	// TODO(borman): need to determine if the extensions have errors
 	// for _, ce := range e.Exts {
 	// 	e.importErrors(ce)
 	// }
 	for _, ce := range c.Dir {
-		e.importErrors(ce)
+		e.importErrorsFrom(ce, seen)
 	}
 }
 
@@ -355,11 +1269,13 @@ func (e *Entry) GetErrors() []error {
 // add adds the directory entry key assigned to the provided value.
 func (e *Entry) add(key string, value *Entry) *Entry {
 	value.Parent = e
-	if e.Dir[key] != nil {
-		e.errorf("%s: duplicate key from %s: %s", Source(e.Node), Source(value.Node), key)
+	if se := e.Dir[key]; se != nil {
+		e.errorf("%s: duplicate key from %s: %s (first defined at %s)", Source(e.Node), Source(value.Node), key, Source(se.Node))
 		return e
 	}
 	e.Dir[key] = value
+	e.order = append(e.order, key)
+	e.clearStats()
 	return e
 }
 
@@ -369,6 +1285,38 @@ func (e *Entry) delete(key string) {
 		e.errorf("%s: unknown child key %s", Source(e.Node), key)
 	}
 	delete(e.Dir, key)
+	if e.RPC != nil {
+		switch key {
+		case "input":
+			e.RPC.Input = nil
+		case "output":
+			e.RPC.Output = nil
+		}
+	}
+	e.clearStats()
+}
+
+// GetExtension returns the first extension statement of e whose keyword is
+// prefix:keyword, or nil if e has no such extension.  The prefix is the one
+// used in the YANG source where the extension statement appears.
+func (e *Entry) GetExtension(prefix, keyword string) *Statement {
+	if exts := e.GetExtensions(prefix, keyword); len(exts) > 0 {
+		return exts[0]
+	}
+	return nil
+}
+
+// GetExtensions returns all the extension statements of e whose keyword is
+// prefix:keyword, in the order they were found.
+func (e *Entry) GetExtensions(prefix, keyword string) []*Statement {
+	var exts []*Statement
+	kw := prefix + ":" + keyword
+	for _, ext := range e.Exts {
+		if ext.Keyword == kw {
+			exts = append(exts, ext)
+		}
+	}
+	return exts
 }
 
 // GetWhenXPath returns the when XPath statement of e if able.
@@ -418,6 +1366,20 @@ func (e *Entry) GetWhenXPath() (string, bool) {
 // converted nodes.
 var entryCache = map[Node]*Entry{}
 
+// An entryState holds the state of a single call to ToEntry.
+type entryState struct {
+	// calls is the number of calls to toEntry in progress.
+	calls int
+	// failFast, if set, stops the expansion at the first entry with an
+	// error.
+	failFast bool
+	// failed is set once the expansion has stopped, at a node nested too
+	// deeply or, if failFast is set, at the first entry with an error.
+	// The nodes that are left are not expanded, and the entries that were
+	// being built when it stopped are not cached, as they are incomplete.
+	failed bool
+}
+
 // mergedSubmodule is used to prevent re-parsing a submodule that has already
 // been merged into a particular entity when circular dependencies are being
 // ignored. The keys of the map are a string that is formed by concatenating
@@ -471,6 +1433,10 @@ type DeviatedEntry struct {
 	// Entry is the embedded Entry storing the deviations that are made. Fields
 	// are set to the value in the schema after the deviation has been applied.
 	*Entry
+
+	// invalid holds the ways, found by ApplyDeviate, in which the
+	// deviation is not permitted; see CheckDeviationValidity.
+	invalid []DeviationError
 }
 
 // ToEntry expands node n into a directory Entry.  Expansion is based on the
@@ -479,7 +1445,12 @@ type DeviatedEntry struct {
 // ToEntry never returns nil.  Any errors encountered are found in the Errors
 // fields of the returned Entry and its children.  Use GetErrors to determine
 // if there were any errors.
-func ToEntry(n Node) (e *Entry) {
+func ToEntry(n Node) *Entry {
+	return toEntry(n, &entryState{})
+}
+
+// toEntry implements ToEntry, with state tracking the recursion.
+func toEntry(n Node, state *entryState) (e *Entry) {
 	if n == nil {
 		err := errors.New("ToEntry called with nil")
 		return &Entry{
@@ -490,8 +1461,32 @@ func ToEntry(n Node) (e *Entry) {
 	if e := entryCache[n]; e != nil {
 		return e
 	}
+
+	state.calls++
+	defer func() { state.calls-- }()
+	max := ParseOptions.MaxEntryDepth
+	if max <= 0 {
+		max = DefaultMaxEntryDepth
+	}
+	// The stubs are not cached, so later calls to ToEntry expand n.
+	if state.failed {
+		return &Entry{Node: n, Name: n.NName()}
+	}
+	if state.calls > max {
+		// Stop here, as a recursive grouping would otherwise be
+		// expanded exponentially many times.
+		state.failed = true
+		e := newError(n, "%s %s is nested more than %d levels deep", n.Kind(), n.NName(), max)
+		e.Name = n.NName()
+		return e
+	}
 	defer func() {
-		entryCache[n] = e
+		if state.failFast && len(e.Errors) > 0 {
+			state.failed = true
+		}
+		if !state.failed {
+			entryCache[n] = e
+		}
 	}()
 
 	// Copy in the extensions from our Node, if any.
@@ -535,10 +1530,18 @@ func ToEntry(n Node) (e *Entry) {
 		if s.Default != nil {
 			e.Default = s.Default.Name
 		}
+		if s.Units != nil {
+			e.Units = s.Units.Name
+		}
 		e.Type = s.Type.YangType
 		entryCache[n] = e
 		e.Config, err = tristateValue(s.Config)
 		e.addError(err)
+		e.Mandatory, err = tristateValue(s.Mandatory)
+		e.addError(err)
+		e.Status, err = statusValue(s.Status)
+		e.addError(err)
+		e.raiseStatus(typeStatus(e.Type))
 		e.Prefix = getRootPrefix(e)
 		return e
 	case *LeafList:
@@ -560,12 +1563,18 @@ func ToEntry(n Node) (e *Entry) {
 			When:        s.When,
 		}
 
-		e := ToEntry(leaf)
+		e := toEntry(leaf, state)
 		e.ListAttr = &ListAttr{
 			MinElements: s.MinElements,
 			MaxElements: s.MaxElements,
 			OrderedBy:   s.OrderedBy,
 		}
+		for _, d := range s.Default {
+			e.defaults = append(e.defaults, d.Name)
+		}
+		if len(e.defaults) > 0 {
+			e.Default = e.defaults[0]
+		}
 		e.Prefix = getRootPrefix(e)
 		return e
 	case *Uses:
@@ -576,7 +1585,7 @@ func ToEntry(n Node) (e *Entry) {
 		// We need to return a duplicate so we resolve properly
 		// when the group is used in multiple locations and the
 		// grouping has a leafref that references outside the group.
-		return ToEntry(g).dup()
+		return toEntry(g, state).dup()
 	}
 
 	e = newDirectory(n)
@@ -592,6 +1601,9 @@ func ToEntry(n Node) (e *Entry) {
 			MaxElements: s.MaxElements,
 			OrderedBy:   s.OrderedBy,
 		}
+		for _, u := range s.Unique {
+			e.ListAttr.Unique = append(e.ListAttr.Unique, strings.Fields(u.Name))
+		}
 	case *Choice:
 		e.Kind = ChoiceEntry
 		if s.Default != nil {
@@ -618,8 +1630,14 @@ func ToEntry(n Node) (e *Entry) {
 	v := reflect.ValueOf(n).Elem()
 	t := v.Type()
 	found := false
+	// merged maps each uses and include statement of n to the names of
+	// the children it merged into e.
+	merged := map[*Statement][]string{}
 
 	for i := t.NumField() - 1; i > 0; i-- {
+		if state.failed || state.failFast && len(e.Errors) > 0 {
+			break
+		}
 		f := t.Field(i)
 		yang := f.Tag.Get("yang")
 		if yang == "" {
@@ -643,39 +1661,45 @@ func ToEntry(n Node) (e *Entry) {
 			}
 		case "action":
 			for _, r := range fv.Interface().([]*Action) {
-				e.add(r.Name, ToEntry(r))
+				action := toEntry(r, state)
+				if action.RPC == nil {
+					// When "action" has no "input" or "output"
+					// children
+					action.RPC = &RPCEntry{}
+				}
+				e.add(r.Name, action)
 			}
 		case "augment":
 			for _, a := range fv.Interface().([]*Augment) {
-				ne := ToEntry(a)
+				ne := toEntry(a, state)
 				ne.Parent = e
 				e.Augments = append(e.Augments, ne)
 			}
 		case "anydata":
 			for _, a := range fv.Interface().([]*AnyData) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "anyxml":
 			for _, a := range fv.Interface().([]*AnyXML) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "case":
 			for _, a := range fv.Interface().([]*Case) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "choice":
 			for _, a := range fv.Interface().([]*Choice) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "container":
 			for _, a := range fv.Interface().([]*Container) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "grouping":
 			for _, a := range fv.Interface().([]*Grouping) {
 				// We just want to parse the grouping to
 				// collect errors.
-				e.importErrors(ToEntry(a))
+				e.importErrors(toEntry(a, state))
 			}
 		case "import":
 			// Apparently import only makes types and such
@@ -716,7 +1740,7 @@ func ToEntry(n Node) (e *Entry) {
 					}
 					mergedSubmodule[srcToIncluded] = true
 					mergedSubmodule[includedToParent] = true
-					e.merge(a.Module.Prefix, nil, ToEntry(a.Module))
+					merged[a.Statement()] = e.merge(a.Module.Prefix, nil, toEntry(a.Module, state))
 				case ParseOptions.IgnoreSubmoduleCircularDependencies:
 					continue
 				default:
@@ -725,15 +1749,15 @@ func ToEntry(n Node) (e *Entry) {
 			}
 		case "leaf":
 			for _, a := range fv.Interface().([]*Leaf) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "leaf-list":
 			for _, a := range fv.Interface().([]*LeafList) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "list":
 			for _, a := range fv.Interface().([]*List) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "key":
 			if v := fv.Interface().(*Value); v != nil {
@@ -741,14 +1765,14 @@ func ToEntry(n Node) (e *Entry) {
 			}
 		case "notification":
 			for _, a := range fv.Interface().([]*Notification) {
-				e.add(a.Name, ToEntry(a))
+				e.add(a.Name, toEntry(a, state))
 			}
 		case "rpc":
 			// TODO(borman): what do we do with these?
 			// seems fine to ignore them for now, we are
 			// just interested in the tree structure.
 			for _, r := range fv.Interface().([]*RPC) {
-				switch rpc := ToEntry(r); {
+				switch rpc := toEntry(r, state); {
 				case rpc.RPC == nil:
 					// When "rpc" has no "input" or "output" children
 					rpc.RPC = &RPCEntry{}
@@ -763,22 +1787,22 @@ func ToEntry(n Node) (e *Entry) {
 				if e.RPC == nil {
 					e.RPC = &RPCEntry{}
 				}
-				in := ToEntry(i)
-				in.Parent = e
+				in := toEntry(i, state)
+				in.Name = "input"
+				in.Kind = InputEntry
 				e.RPC.Input = in
-				e.RPC.Input.Name = "input"
-				e.RPC.Input.Kind = InputEntry
+				e.add("input", in)
 			}
 		case "output":
 			if o := fv.Interface().(*Output); o != nil {
 				if e.RPC == nil {
 					e.RPC = &RPCEntry{}
 				}
-				out := ToEntry(o)
-				out.Parent = e
+				out := toEntry(o, state)
+				out.Name = "output"
+				out.Kind = OutputEntry
 				e.RPC.Output = out
-				e.RPC.Output.Name = "output"
-				e.RPC.Output.Kind = OutputEntry
+				e.add("output", out)
 			}
 		case "identity":
 			if i := fv.Interface().([]*Identity); i != nil {
@@ -786,8 +1810,19 @@ func ToEntry(n Node) (e *Entry) {
 			}
 		case "uses":
 			for _, a := range fv.Interface().([]*Uses) {
-				grouping := ToEntry(a)
-				e.merge(nil, nil, grouping)
+				grouping := toEntry(a, state)
+				grouping.expandedFrom(a)
+				names := e.merge(nil, nil, grouping)
+				merged[a.Statement()] = names
+				st, err := statusValue(a.Status)
+				e.addError(err)
+				if grouping.Status > st {
+					st = grouping.Status
+				}
+				for _, k := range names {
+					e.Dir[k].raiseStatus(st)
+					e.Dir[k].inheritWhen(a)
+				}
 				if ParseOptions.StoreUses {
 					e.Uses = append(e.Uses, &UsesStmt{a, grouping.shallowDup()})
 				}
@@ -803,7 +1838,7 @@ func ToEntry(n Node) (e *Entry) {
 
 			if n.Type != nil {
 				if errs := n.Type.resolve(); errs != nil {
-					e.addError(fmt.Errorf("deviation has unresolvable type, %v", errs))
+					e.addError(fmt.Errorf("%s: deviation has unresolvable type, %v", Source(n), errs))
 					continue
 				}
 				e.Type = n.Type.YangType
@@ -828,7 +1863,7 @@ func ToEntry(n Node) (e *Entry) {
 			if a := fv.Interface().([]*Deviation); a != nil {
 				for _, d := range a {
 					e.Deviations = append(e.Deviations, &DeviatedEntry{
-						Entry:        ToEntry(d),
+						Entry:        toEntry(d, state),
 						DeviatedPath: d.Statement().Argument,
 					})
 
@@ -842,7 +1877,7 @@ func ToEntry(n Node) (e *Entry) {
 		case "deviate":
 			if a := fv.Interface().([]*Deviate); a != nil {
 				for _, d := range a {
-					de := ToEntry(d)
+					de := toEntry(d, state)
 
 					dt, ok := toDeviation[d.Statement().Argument]
 					if !ok {
@@ -885,6 +1920,25 @@ func ToEntry(n Node) (e *Entry) {
 			} else {
 				e.ListAttr.MinElements = v
 			}
+		case "presence":
+			v, ok := fv.Interface().(*Value)
+			if !ok {
+				e.addError(fmt.Errorf("%s: presence had wrong type, %s:%s", Source(n), n.Kind(), n.NName()))
+				continue
+			}
+			e.Presence = v
+			e.Extra[name] = append(e.Extra[name], v)
+			continue
+		case "status":
+			v, ok := fv.Interface().(*Value)
+			if !ok {
+				e.addError(fmt.Errorf("%s: status had wrong type, %s:%s", Source(n), n.Kind(), n.NName()))
+				continue
+			}
+			e.Status, err = statusValue(v)
+			e.addError(err)
+			e.Extra[name] = append(e.Extra[name], v)
+			continue
 		case "units":
 			v, ok := fv.Interface().(*Value)
 			if !ok {
@@ -903,10 +1957,8 @@ func ToEntry(n Node) (e *Entry) {
 			"namespace",
 			"ordered-by",
 			"organization",
-			"presence",
 			"reference",
 			"revision",
-			"status",
 			"unique",
 			"when",
 			"yang-version":
@@ -927,6 +1979,7 @@ func ToEntry(n Node) (e *Entry) {
 	if !found {
 		return newError(n, "%T: cannot be converted to a *Entry", n)
 	}
+	e.sortOrder(n, merged)
 	// If prefix isn't set, provide it based on our root node (module)
 	if e.Prefix == nil {
 		e.Prefix = getRootPrefix(e)
@@ -947,6 +2000,14 @@ func getRootPrefix(e *Entry) *Value {
 // and the augments skipped.  If addErrors is true then missing augments will
 // generate errors.
 func (e *Entry) Augment(addErrors bool) (processed, skipped int) {
+	processed, skipped, _ = e.augment(addErrors, false)
+	return processed, skipped
+}
+
+// augment implements Augment.  If failFast is set, augment stops at the
+// first error, which it returns.  The augments that are left are not
+// processed.
+func (e *Entry) augment(addErrors, failFast bool) (processed, skipped int, err error) {
 	// Now process the augments we found
 	// NOTE(borman): is it possible this will fail if the augment refers
 	// to some removed sibling that has not been processed?  Perhaps this
@@ -956,42 +2017,73 @@ func (e *Entry) Augment(addErrors bool) (processed, skipped int) {
 	// order the augments (or just keep trying until we can make no further
 	// progress)
 	var sa []*Entry
-	for _, a := range e.Augments {
+	for i, a := range e.Augments {
 		ae := a.Find(a.Name)
 		if ae == nil {
 			if addErrors {
 				e.errorf("%s: augment %s not found", Source(a.Node), a.Name)
+				if failFast {
+					err = e.Errors[len(e.Errors)-1]
+				}
 			}
 			skipped++
 			sa = append(sa, a)
-			continue
+		} else {
+			// Augments do not have a prefix we merge in, just a node.
+			// We retain the namespace from the original context of the
+			// augment since the nodes have this namespace even though they
+			// are merged into another entry.
+			processed++
+			n := len(ae.Errors)
+			for _, k := range ae.merge(nil, a.Namespace(), a) {
+				ae.Dir[k].raiseStatus(a.Status)
+				ae.Dir[k].inheritWhen(a.Node)
+			}
+			ae.Augmented = append(ae.Augmented, a.shallowDup())
+			if failFast && len(ae.Errors) > n {
+				err = ae.Errors[n]
+			}
+		}
+		if err != nil {
+			sa = append(sa, e.Augments[i+1:]...)
+			break
 		}
-		// Augments do not have a prefix we merge in, just a node.
-		// We retain the namespace from the original context of the
-		// augment since the nodes have this namespace even though they
-		// are merged into another entry.
-		processed++
-		ae.merge(nil, a.Namespace(), a)
-		ae.Augmented = append(ae.Augmented, a.shallowDup())
 	}
 	e.Augments = sa
-	return processed, skipped
+	return processed, skipped, err
 }
 
 // ApplyDeviate walks the deviations within the supplied entry, and applies them to the
 // schema.
 func (e *Entry) ApplyDeviate() []error {
+	return e.applyDeviate(false)
+}
+
+// applyDeviate implements ApplyDeviate.  If failFast is set, applyDeviate
+// does not apply the deviations that follow the first one with an error.
+func (e *Entry) applyDeviate(failFast bool) []error {
 	var errs []error
 	appendErr := func(err error) { errs = append(errs, err) }
 	for _, d := range e.Deviations {
+		if failFast && len(errs) > 0 {
+			break
+		}
 		deviatedNode := e.Find(d.DeviatedPath)
+		d.invalid = d.check(deviatedNode)
 		if deviatedNode == nil {
 			appendErr(fmt.Errorf("cannot find target node to deviate, %s", d.DeviatedPath))
 			continue
 		}
 
+		retyped := false
 		for dt, dv := range d.Deviate {
 			for _, devSpec := range dv {
+				if len(devSpec.Errors) > 0 {
+					// For example, the replacement type could not be
+					// resolved in the context of the deviating module.
+					errs = append(errs, devSpec.Errors...)
+					continue
+				}
 				switch dt {
 				case DeviationAdd, DeviationReplace:
 					if devSpec.Config != TSUnset {
@@ -1028,6 +2120,7 @@ func (e *Entry) ApplyDeviate() []error {
 
 					if devSpec.Type != nil {
 						deviatedNode.Type = devSpec.Type
+						retyped = true
 					}
 
 				case DeviationNotSupported:
@@ -1047,19 +2140,47 @@ func (e *Entry) ApplyDeviate() []error {
 					}
 
 					if devSpec.Mandatory != TSUnset {
-						devSpec.Mandatory = TSUnset
+						deviatedNode.Mandatory = TSUnset
+					}
+
+					if devSpec.Units != "" {
+						deviatedNode.Units = ""
 					}
 				default:
 					appendErr(fmt.Errorf("invalid deviation type %s", dt))
 				}
 			}
 		}
+		if retyped {
+			if err := deviatedNode.checkRetyped(d); err != nil {
+				appendErr(err)
+			}
+		}
 	}
 
 	return errs
 
 }
 
+// checkRetyped returns an error if a default of e, whose type has been
+// replaced by the deviation d, is not a valid value of its new type.
+func (e *Entry) checkRetyped(d *DeviatedEntry) error {
+	if err := e.checkDefault(); err != nil {
+		return err
+	}
+	if hasIdentityref(e.Type) {
+		// checkDefault has resolved the prefixes of the identities.
+		return nil
+	}
+	values, _ := e.DefaultValues()
+	for _, v := range values {
+		if _, err := e.Type.ParseValue(v); err != nil {
+			return fmt.Errorf("%s: default %q of %s is not valid for the type %s it is deviated to: %v", Source(d.Node), v, e.Path(), e.Type.Name, err)
+		}
+	}
+	return nil
+}
+
 // FixChoice inserts missing Case entries in a choice
 func (e *Entry) FixChoice() {
 	if e.Kind == ChoiceEntry && len(e.Errors) == 0 {
@@ -1082,6 +2203,7 @@ func (e *Entry) FixChoice() {
 				}
 				ce.Parent = ne
 				e.Dir[k] = ne
+				e.clearStats()
 			}
 		}
 	}
@@ -1107,6 +2229,30 @@ func (e *Entry) ReadOnly() bool {
 	}
 }
 
+// IsConfig reports whether e is configuration data, as described in section
+// 7.21.1 of RFC 7950.  The nodes of rpcs, actions and notifications, and all
+// of their descendants, including the input and output of an rpc or action,
+// are not configuration data whatever their config statements.  Otherwise
+// e is configuration data unless it, or its closest ancestor with a config
+// statement, is config false.  Unlike ReadOnly, IsConfig is false for the
+// input of an rpc or action.
+func (e *Entry) IsConfig() bool {
+	for p := e; p != nil; p = p.Parent {
+		if p.RPC != nil {
+			return false
+		}
+		switch p.Kind {
+		case InputEntry, OutputEntry, NotificationEntry:
+			return false
+		}
+		switch p.Node.(type) {
+		case *RPC, *Action, *Notification:
+			return false
+		}
+	}
+	return !e.ReadOnly()
+}
+
 // Find finds the Entry named by name relative to e.
 func (e *Entry) Find(name string) *Entry {
 	if e == nil || name == "" {
@@ -1122,43 +2268,34 @@ func (e *Entry) Find(name string) *Entry {
 		}
 		parts = parts[1:]
 
-		// Since this module might use a different prefix that isn't
-		// the prefix that the module itself uses then we need to resolve
-		// the module into its local prefix to find it.
-		pfxMap := map[string]string{
-			// Seed the map with the local module - we use GetPrefix just
-			// in case the module is a submodule.
-			e.Node.(*Module).GetPrefix(): e.Prefix.Name,
-		}
-
-		// Add a map between the prefix used in the import statement, and
-		// the prefix that is used in the module itself.
-		for _, i := range e.Node.(*Module).Import {
-			// Resolve the module using the current module set, since we may
-			// not have populated the Module for the entry yet.
-			m, ok := e.Node.(*Module).modules.Modules[i.Name]
-			if !ok {
-				e.addError(fmt.Errorf("cannot find a module with name %s when looking at imports in %s", i.Name, e.Path()))
-				return nil
-			}
-
-			pfxMap[i.Prefix.Name] = m.Prefix.Name
-		}
-
+		// The prefix is one defined by this module, which might not be
+		// the prefix that the module it refers to uses itself.  Prefixes
+		// are not unique across modules, so the prefix of the module
+		// itself cannot be used to find it.
+		mod := e.Node.(*Module)
 		if prefix, _ := getPrefix(parts[0]); prefix != "" {
-			pfx, ok := pfxMap[prefix]
+			pfxMap := mod.PrefixMap()
+			m, ok := pfxMap[prefix]
 			if !ok {
+				// PrefixMap leaves out the prefixes of the imports
+				// of modules that have not been read.
+				for _, i := range mod.Import {
+					if i.Prefix != nil && i.Prefix.Name == prefix {
+						e.addError(fmt.Errorf("cannot find a module with name %s when looking at imports in %s", i.Name, e.Path()))
+						return nil
+					}
+				}
 				// This is an undefined prefix within our context, so
 				// we can't do anything about resolving it.
-				e.addError(fmt.Errorf("invalid module prefix %s within module %s, defined prefix map: %v", prefix, e.Name, pfxMap))
-				return nil
-			}
-			m, err := e.Modules().FindModuleByPrefix(pfx)
-			if err != nil {
-				e.addError(err)
+				var prefixes []string
+				for p := range pfxMap {
+					prefixes = append(prefixes, p)
+				}
+				sort.Strings(prefixes)
+				e.addError(fmt.Errorf("invalid module prefix %s within module %s, defined prefixes: %v", prefix, e.Name, prefixes))
 				return nil
 			}
-			if e.Node.(*Module) != m {
+			if m != mod {
 				e = ToEntry(m)
 			}
 		}
@@ -1171,13 +2308,6 @@ func (e *Entry) Find(name string) *Entry {
 		case part == ".":
 		case part == "..":
 			e = e.Parent
-		case e.RPC != nil:
-			switch part {
-			case "input":
-				e = e.RPC.Input
-			case "output":
-				e = e.RPC.Output
-			}
 		default:
 			_, part = getPrefix(part)
 			switch part {
@@ -1200,6 +2330,98 @@ func (e *Entry) Path() string {
 	return e.Parent.Path() + "/" + e.Name
 }
 
+// DataPath returns the path of e in the data tree, which is the path returned
+// by Path without the choice and case entries, as these do not appear in
+// instance data.
+func (e *Entry) DataPath() string {
+	if e == nil {
+		return ""
+	}
+	return e.DataParent().DataPath() + "/" + e.Name
+}
+
+// DataParent returns the parent of e in the data tree, skipping any choice
+// and case entries, or nil if e is the root of the tree.
+func (e *Entry) DataParent() *Entry {
+	p := e.Parent
+	for p != nil && (p.IsChoice() || p.IsCase()) {
+		p = p.Parent
+	}
+	return p
+}
+
+// FindDataNode returns the descendant of e named by the data path path, such
+// as /a/b/c, or an error if there is no such node.  A path starting with "/"
+// is relative to the root of the tree containing e, and is otherwise relative
+// to e.  Each step of path names a data node by its local name: choice and
+// case entries are looked through, the keys of lists, given either as
+// predicates or in the RESTCONF form list=key1,key2, are ignored, and so is
+// the module prefix of the step unless more than one data node has its name,
+// as happens when modules augment the same node with nodes of the same name.
+// The prefix is then the name or prefix of the module that defines the node
+// to select.
+func (e *Entry) FindDataNode(path string) (*Entry, error) {
+	c := e
+	path = StripPredicates(path)
+	if strings.HasPrefix(path, "/") {
+		for c.Parent != nil {
+			c = c.Parent
+		}
+		path = path[1:]
+	}
+	if path == "" {
+		return c, nil
+	}
+	for _, step := range strings.Split(path, "/") {
+		if i := strings.Index(step, "="); i >= 0 {
+			step = step[:i]
+		}
+		if !c.IsDir() && c.RPC == nil {
+			return nil, fmt.Errorf("%s is not a container or list, looking for %s", c.DataPath(), step)
+		}
+		prefix, name := getPrefix(step)
+		matches := c.dataChildren(name)
+		if len(matches) > 1 && prefix != "" {
+			var selected []*Entry
+			for _, m := range matches {
+				if mod := RootNode(m.Node); mod != nil && (moduleName(mod) == prefix || mod.GetPrefix() == prefix) {
+					selected = append(selected, m)
+				}
+			}
+			matches = selected
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("%s has no data node %s", c.DataPath(), step)
+		case 1:
+			c = matches[0]
+		default:
+			var names []string
+			for _, m := range matches {
+				names = append(names, m.Path())
+			}
+			return nil, fmt.Errorf("data node %s of %s is ambiguous: %s", step, c.DataPath(), strings.Join(names, ", "))
+		}
+	}
+	return c, nil
+}
+
+// dataChildren returns the data node children of e named name, looking
+// within the cases of the choices of e, in the order they are defined.
+func (e *Entry) dataChildren(name string) []*Entry {
+	var matches []*Entry
+	for _, k := range e.orderedKeys() {
+		ce := e.Dir[k]
+		switch {
+		case ce.IsChoice() || ce.IsCase():
+			matches = append(matches, ce.dataChildren(name)...)
+		case k == name:
+			matches = append(matches, ce)
+		}
+	}
+	return matches
+}
+
 // Namespace returns the YANG/XML namespace Value for e as mounted in the Entry
 // tree (e.g., as placed by grouping statements).
 //
@@ -1227,6 +2449,30 @@ func (e *Entry) Namespace() *Value {
 	return new(Value)
 }
 
+// NamespaceURI returns the XML namespace of e, which is the argument of the
+// namespace statement returned by Namespace: that of the module that
+// augmented e into its tree, if e was added by an augment statement, or of
+// the module of the root of the tree of e otherwise.  The empty string is
+// returned if the module has no namespace statement.
+func (e *Entry) NamespaceURI() string {
+	return e.Namespace().Name
+}
+
+// ModuleName returns the name of the module whose namespace e is in, as
+// returned by Namespace.  Unlike InstantiatingModule it does not need the
+// module to be found by its namespace, and so it does not fail.
+func (e *Entry) ModuleName() string {
+	if m := RootNode(e.Namespace()); m != nil {
+		return moduleName(m)
+	}
+	for ; e.Parent != nil; e = e.Parent {
+	}
+	if m, ok := e.Node.(*Module); ok {
+		return moduleName(m)
+	}
+	return ""
+}
+
 // InstantiatingModule returns the YANG module which instanitated the Entry
 // within the schema tree - using the same rules described in the documentation
 // of the Namespace function. The namespace is resolved in the module name. This
@@ -1251,6 +2497,8 @@ func (e *Entry) shallowDup() *Entry {
 	// Warning: if we add any elements to Entry that should not be
 	// copied we will have to explicitly uncopy them.
 	ne := *e
+	ne.order = append([]string(nil), e.order...)
+	ne.stats = nil
 
 	// Now only copy direct children, clear their Dir, and fix up
 	// Parent pointers.
@@ -1259,10 +2507,13 @@ func (e *Entry) shallowDup() *Entry {
 		for k, v := range e.Dir {
 			de := *v
 			de.Dir = nil
+			de.OrderedDir = nil
+			de.stats = nil
 			de.Parent = &ne
 			ne.Dir[k] = &de
 		}
 	}
+	ne.syncOrderedDir()
 	return &ne
 }
 
@@ -1274,6 +2525,8 @@ func (e *Entry) dup() *Entry {
 	// such as Exts, Choice and Case, but it is not clear that we need
 	// to do that.
 	ne := *e
+	ne.order = append([]string(nil), e.order...)
+	ne.refinements = append([]*Statement(nil), e.refinements...)
 
 	// Now recurse down to all of our children, fixing up Parent
 	// pointers as we go.
@@ -1285,16 +2538,25 @@ func (e *Entry) dup() *Entry {
 			ne.Dir[k] = de
 		}
 	}
+	if e.RPC != nil {
+		ne.RPC = &RPCEntry{
+			Input:  ne.Dir["input"],
+			Output: ne.Dir["output"],
+		}
+	}
+	ne.syncOrderedDir()
 	return &ne
 }
 
 // merge merges a duplicate of oe.Dir into e.Dir, setting the prefix of each
 // element to prefix, if not nil.  It is an error if e and oe contain common
-// elements.
-func (e *Entry) merge(prefix *Value, namespace *Value, oe *Entry) {
+// elements.  The names of the merged elements are returned in the order they
+// are defined in oe.
+func (e *Entry) merge(prefix *Value, namespace *Value, oe *Entry) []string {
 	e.importErrors(oe)
-	for k, v := range oe.Dir {
-		v := v.dup()
+	var names []string
+	for _, k := range oe.orderedKeys() {
+		v := oe.Dir[k].dup()
 		if prefix != nil {
 			v.Prefix = prefix
 		}
@@ -1310,38 +2572,46 @@ func (e *Entry) merge(prefix *Value, namespace *Value, oe *Entry) {
 			v.Parent = e
 			v.Exts = append(v.Exts, oe.Exts...)
 			e.Dir[k] = v
+			e.order = append(e.order, k)
+			names = append(names, k)
 		}
 	}
-}
-
-// nless returns -1 if a is less than b, 0 if a == b, and 1 if a > b.
-// If a and b are both numeric, then nless compares them as numbers,
-// otherwise they are compared lexicographically.
-func nless(a, b string) int {
-	an, ae := strconv.Atoi(a)
-	bn, be := strconv.Atoi(b)
-	switch {
-	case ae == nil && be == nil:
-		switch {
-		case an < bn:
-			return -1
-		case an > bn:
-			return 1
-		default:
-			return 0
-		}
-	case a < b:
-		return -1
-	case a > b:
-		return 1
-	default:
-		return 0
+	if len(names) > 0 {
+		e.clearStats()
 	}
+	return names
 }
 
 type sError struct {
-	s   string
-	err error
+	file      string
+	line, col int
+	msg       string
+	err       error
+}
+
+// newSError returns err as an sError with the file, line and column of its
+// file:line:col prefix.  The line and column of an error without them are
+// -1, and the file of an error without a position is the text before its
+// first colon, if any.
+func newSError(err error) sError {
+	s := err.Error()
+	parts := strings.SplitN(s, ":", 4)
+	se := sError{file: parts[0], line: -1, col: -1, err: err}
+	rest := parts[1:]
+	if len(rest) > 0 {
+		if n, err := strconv.Atoi(rest[0]); err == nil {
+			se.line = n
+			rest = rest[1:]
+			if len(rest) > 0 {
+				if n, err := strconv.Atoi(rest[0]); err == nil {
+					se.col = n
+					rest = rest[1:]
+				}
+			}
+		}
+	}
+	se.msg = strings.Join(rest, ":")
+	return se
 }
 
 type sortedErrors []sError
@@ -1349,41 +2619,21 @@ type sortedErrors []sError
 func (s sortedErrors) Len() int      { return len(s) }
 func (s sortedErrors) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 func (s sortedErrors) Less(i, j int) bool {
-	fi := strings.SplitN(s[i].s, ":", 4)
-	fj := strings.SplitN(s[j].s, ":", 4)
-	if fi[0] < fj[0] {
-		return true
-	}
-	if fi[0] > fj[0] {
-		return false
-	}
-
-	// compare compares field x to see which is less.
-	// numbers are compared as numbers.
-	compare := func(x int) int {
-		switch {
-		case len(fi) == x && len(fj) > x:
-			return -1
-		case len(fj) == x && len(fi) > x:
-			return 1
-		case len(fj) < x && len(fi) < x:
-			return 0
-		}
-		return nless(fi[x], fj[x])
-	}
-	for x := 1; x < 4; x++ {
-		switch compare(1) {
-		case -1:
-			return true
-		case 1:
-			return false
-		}
+	switch {
+	case s[i].file != s[j].file:
+		return s[i].file < s[j].file
+	case s[i].line != s[j].line:
+		return s[i].line < s[j].line
+	case s[i].col != s[j].col:
+		return s[i].col < s[j].col
 	}
-	return false
+	return s[i].msg < s[j].msg
 }
 
-// errorSort sorts the strings in the errors slice assuming each line starts
-// with file:line:col.  Line and column number are sorted numerically.
+// errorSort sorts the errors slice by file, line, column and message,
+// assuming each error starts with file:line:col.  Line and column number are
+// sorted numerically.  The sort is stable, so errors that compare equal, such
+// as errors with the same message and no position, keep their order.
 // Duplicate errors are stripped.
 func errorSort(errors []error) []error {
 	switch len(errors) {
@@ -1394,9 +2644,9 @@ func errorSort(errors []error) []error {
 	}
 	elist := make(sortedErrors, len(errors))
 	for x, err := range errors {
-		elist[x] = sError{err.Error(), err}
+		elist[x] = newSError(err)
 	}
-	sort.Sort(elist)
+	sort.Stable(elist)
 	errors = make([]error, len(errors))
 	i := 0
 	for _, err := range elist {
@@ -1409,6 +2659,33 @@ func errorSort(errors []error) []error {
 	return errors[:i]
 }
 
+// DefaultValues returns the default values of e, a leaf or leaf-list, and
+// whether they are the default of its type, as described in sections 7.6.1
+// and 7.7.2 of RFC 7950.  The defaults of e itself take precedence.  If e has
+// none, the default of its type, if any, applies to a leaf that is not
+// mandatory and to a leaf-list whose min-elements is 0.
+func (e *Entry) DefaultValues() (values []string, fromType bool) {
+	switch {
+	case e.Kind != LeafEntry:
+		return nil, false
+	case len(e.defaults) > 0 && e.Default == e.defaults[0]:
+		return append([]string(nil), e.defaults...), false
+	case e.Default != "":
+		// The default has been changed by a refine or deviate statement.
+		return []string{e.Default}, false
+	case e.Type == nil || e.Type.Default == "":
+		return nil, false
+	}
+	if e.ListAttr != nil {
+		if min := e.ListAttr.MinElements; min != nil && min.Name != "0" {
+			return nil, false
+		}
+	} else if e.Mandatory == TSTrue {
+		return nil, false
+	}
+	return []string{e.Type.Default}, true
+}
+
 // DefaultValue returns the schema default value for e, if any. If the leaf
 // has no explicit default, its type default (if any) will be used.
 func (e *Entry) DefaultValue() string {
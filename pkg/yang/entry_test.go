@@ -91,11 +91,11 @@ module base {
 `,
 		errors: []string{
 			`bad.yang:9:3: invalid config value: bad`,
-			`bad.yang:13:3: duplicate key from bad.yang:20:5: bob`,
+			`bad.yang:13:3: duplicate key from bad.yang:20:5: bob (first defined at bad.yang:14:5)`,
 			`bad.yang:14:5: invalid config value: incorrect`,
 			`bad.yang:17:7: unknown type: base:unknown`,
 			`bad.yang:22:5: unknown group: the-beatles`,
-			`bad.yang:24:3: duplicate key from bad.yang:27:5: one`,
+			`bad.yang:24:3: duplicate key from bad.yang:27:5: one (first defined at bad.yang:25:5)`,
 		},
 	},
 	{
@@ -700,6 +700,84 @@ func TestAugmentedEntry(t *testing.T) {
 	}
 }
 
+func TestAugmentUsesNode(t *testing.T) {
+	// The grouping and target modules have the same prefix, so the target
+	// of the augment can only be found through the prefix the augmenting
+	// module imports the target module with.
+	groupings := `module groupings {
+  prefix "x";
+  namespace "urn:groupings";
+  grouping inner {
+    container inner {
+      leaf x { type string; }
+    }
+  }
+}`
+	target := `module target {
+  prefix "x";
+  namespace "urn:target";
+  import groupings { prefix g; }
+  container top {
+    uses g:inner;
+  }
+  container other {
+    uses g:inner;
+  }
+}`
+
+	tests := []struct {
+		desc    string
+		path    string
+		wantErr string
+	}{{
+		desc: "node from grouping",
+		path: "/t:top/t:inner",
+	}, {
+		desc:    "node not from grouping",
+		path:    "/t:top/t:outer",
+		wantErr: "augment /t:top/t:outer not found",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for name, src := range map[string]string{
+				"groupings.yang": groupings,
+				"target.yang":    target,
+				"augmenting.yang": `module augmenting {
+  prefix "a";
+  namespace "urn:augmenting";
+  import target { prefix t; }
+  augment "` + tt.path + `" {
+    leaf y { type string; }
+  }
+}`,
+			} {
+				if err := ms.Parse(src, name); err != nil {
+					t.Fatalf("could not parse module %s: %v", name, err)
+				}
+			}
+			errs := ms.Process()
+			var err error
+			if len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("Process: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			top := ToEntry(ms.Modules["target"])
+			if top.Find("/top/inner/y") == nil {
+				t.Error("y was not augmented into /top/inner")
+			}
+			if top.Find("/other/inner/y") != nil {
+				t.Error("y was also augmented into /other/inner")
+			}
+		})
+	}
+}
+
 func TestUsesEntry(t *testing.T) {
 	ParseOptions.StoreUses = true
 	defer func() { ParseOptions.StoreUses = false }()
@@ -1079,6 +1157,119 @@ module defaults {
 	}
 }
 
+func TestEntryDefaultValidation(t *testing.T) {
+	header := `module m {
+  prefix "m";
+  namespace "urn:m";
+  import other { prefix o; }
+  identity base-id;
+  identity child { base base-id; }
+  identity grandchild { base child; }
+  identity unrelated;
+  typedef color { type enumeration { enum red; enum blue; } default "green"; }
+`
+	other := `module other {
+  prefix "o";
+  namespace "urn:o";
+  identity remote-base;
+  identity remote { base remote-base; }
+}`
+	tests := []struct {
+		desc       string
+		leaf       string
+		wantErrSub string
+	}{{
+		desc: "valid identityref",
+		leaf: `leaf l { type identityref { base base-id; } default "m:grandchild"; }`,
+	}, {
+		desc: "valid unprefixed identityref",
+		leaf: `leaf l { type identityref { base base-id; } default "child"; }`,
+	}, {
+		desc: "valid identityref from another module",
+		leaf: `leaf l { type identityref { base o:remote-base; } default "o:remote"; }`,
+	}, {
+		desc:       "identity of another base from another module",
+		leaf:       `leaf l { type identityref { base base-id; } default "o:remote"; }`,
+		wantErrSub: "identity o:remote is not derived from base-id",
+	}, {
+		desc:       "unknown identity",
+		leaf:       `leaf l { type identityref { base base-id; } default "m:missing"; }`,
+		wantErrSub: `invalid default "m:missing" of /m/l: "m:missing" is not a known identity`,
+	}, {
+		desc:       "identity not derived from base",
+		leaf:       `leaf l { type identityref { base base-id; } default "unrelated"; }`,
+		wantErrSub: "identity unrelated is not derived from base-id",
+	}, {
+		desc:       "base identity itself",
+		leaf:       `leaf l { type identityref { base base-id; } default "base-id"; }`,
+		wantErrSub: "identity base-id is not derived from base-id",
+	}, {
+		desc: "valid enum",
+		leaf: `leaf l { type enumeration { enum up; enum down; } default "down"; }`,
+	}, {
+		desc:       "invalid enum",
+		leaf:       `leaf l { type enumeration { enum up; enum down; } default "sideways"; }`,
+		wantErrSub: `"sideways" is not an enum of type enumeration`,
+	}, {
+		desc:       "invalid typedef default",
+		leaf:       `leaf l { type color; }`,
+		wantErrSub: `invalid default "green" of /m/l`,
+	}, {
+		desc: "leaf default overrides typedef default",
+		leaf: `leaf l { type color; default "red"; }`,
+	}, {
+		desc: "valid bits",
+		leaf: `leaf l { type bits { bit a; bit b; } default "b a"; }`,
+	}, {
+		desc:       "invalid bits",
+		leaf:       `leaf l { type bits { bit a; bit b; } default "a c"; }`,
+		wantErrSub: `"c" is not a valid bit`,
+	}, {
+		desc: "union with unchecked member",
+		leaf: `leaf l { type union { type enumeration { enum up; } type string; } default "anything"; }`,
+	}, {
+		desc:       "union of enums",
+		leaf:       `leaf l { type union { type enumeration { enum up; } type enumeration { enum down; } } default "left"; }`,
+		wantErrSub: `"left" is not a valid value of any member of union union`,
+	}, {
+		desc:       "empty",
+		leaf:       `leaf l { type empty; default "x"; }`,
+		wantErrSub: `invalid default "x" of /m/l: type empty cannot have a default`,
+	}, {
+		desc: "valid leaf-list defaults",
+		leaf: `leaf-list l { type enumeration { enum up; enum down; } default "up"; default "down"; }`,
+	}, {
+		desc:       "invalid second leaf-list default",
+		leaf:       `leaf-list l { type enumeration { enum up; enum down; } default "up"; default "left"; }`,
+		wantErrSub: `invalid default "left" of /m/l`,
+	}, {
+		desc:       "leaf-list default with min-elements",
+		leaf:       `leaf-list l { type string; default "x"; min-elements 1; }`,
+		wantErrSub: "leaf-list /m/l has a default and min-elements 1",
+	}, {
+		desc: "leaf-list typedef default with min-elements",
+		leaf: `typedef s { type string; default "x"; } leaf-list l { type s; min-elements 1; }`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(other, "other.yang"); err != nil {
+				t.Fatalf("Parse other: %v", err)
+			}
+			if err := ms.Parse(header+tt.leaf+"\n}", "m.yang"); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var err error
+			if errs := ms.Process(); len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("Process: %s", diff)
+			}
+		})
+	}
+}
+
 func TestFullModuleProcess(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1968,6 +2159,30 @@ func TestEntryFind(t *testing.T) {
 	}
 }
 
+func TestEntryFindUnreadImport(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module test { prefix "t"; namespace "urn:t";
+  import missing { prefix m; }
+  leaf a { type string; }
+}`, "test.yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	e := ToEntry(ms.Modules["test"])
+
+	// Only a path with the prefix of the import that has not been read
+	// cannot be found.
+	if got := e.Find("/t:a"); got == nil || got.Path() != "/test/a" {
+		t.Errorf("Find(/t:a): got %v, want /test/a; errors: %v", got, e.Errors)
+	}
+	if got := e.Find("/m:b"); got != nil {
+		t.Errorf("Find(/m:b): got %s, want nil", got.Path())
+	}
+	wantErr := "cannot find a module with name missing"
+	if len(e.Errors) != 1 || !strings.Contains(e.Errors[0].Error(), wantErr) {
+		t.Errorf("got errors %v, want one containing %q", e.Errors, wantErr)
+	}
+}
+
 func TestEntryTypes(t *testing.T) {
 	leafSchema := &Entry{Name: "leaf-schema", Kind: LeafEntry, Type: &YangType{Kind: Ystring}}
 
@@ -2644,6 +2859,115 @@ func TestDeviation(t *testing.T) {
 				},
 			}},
 		},
+	}, {
+		desc: "deviation to a typedef of a module imported by the deviating module",
+		inFiles: map[string]string{
+			"deviate": `
+				module deviate {
+					prefix "d";
+					namespace "urn:d";
+
+					import source { prefix s; }
+					import types { prefix t; }
+
+					deviation /s:a {
+						deviate replace {
+							type t:port;
+						}
+					}
+				}
+			`,
+			"source": `
+				module source {
+					prefix "s";
+					namespace "urn:s";
+
+					typedef port { type string; }
+
+					leaf a {
+						type port;
+						default "80";
+					}
+				}
+			`,
+			"types": `
+				module types {
+					prefix "t";
+					namespace "urn:t";
+
+					typedef port { type uint16; }
+				}
+			`,
+		},
+		wants: map[string][]deviationTest{
+			"source": {{
+				path: "/a",
+				entry: &Entry{
+					Default: "80",
+					Type: &YangType{
+						Name: "port",
+						Kind: Yuint16,
+					},
+				},
+			}},
+		},
+	}, {
+		desc: "deviation to an unresolvable type",
+		inFiles: map[string]string{
+			"deviate": `
+				module deviate {
+					prefix "d";
+					namespace "urn:d";
+
+					import source { prefix s; }
+
+					deviation /s:a {
+						deviate replace {
+							type s:port;
+						}
+					}
+				}
+			`,
+			"source": `
+				module source {
+					prefix "s";
+					namespace "urn:s";
+
+					leaf a { type string; }
+				}
+			`,
+		},
+		wantProcessErrSubstring: "deviation has unresolvable type",
+	}, {
+		desc: "deviation to a type of which the default is not a value",
+		inFiles: map[string]string{
+			"deviate": `
+				module deviate {
+					prefix "d";
+					namespace "urn:d";
+
+					import source { prefix s; }
+
+					deviation /s:a {
+						deviate replace {
+							type uint16;
+						}
+					}
+				}
+			`,
+			"source": `
+				module source {
+					prefix "s";
+					namespace "urn:s";
+
+					leaf a {
+						type string;
+						default "fish";
+					}
+				}
+			`,
+		},
+		wantProcessErrSubstring: `default "fish" of /source/a is not valid for the type uint16 it is deviated to`,
 	}}
 
 	for _, tt := range tests {
@@ -2734,3 +3058,2050 @@ func TestDeviation(t *testing.T) {
 		})
 	}
 }
+
+func TestMandatoryChildren(t *testing.T) {
+	modtext := `
+module mandatory {
+  namespace "urn:mandatory";
+  prefix "m";
+
+  container c {
+    leaf mandatory-leaf { type string; mandatory true; }
+    leaf optional-leaf { type string; mandatory false; }
+    leaf plain-leaf { type string; }
+    choice mandatory-choice {
+      mandatory true;
+      leaf a { type string; }
+      leaf b { type string; }
+    }
+    choice optional-choice {
+      leaf x { type string; }
+    }
+    list required-list {
+      key "k";
+      min-elements 1;
+      leaf k { type string; }
+    }
+    list optional-list {
+      key "k";
+      min-elements 0;
+      leaf k { type string; }
+    }
+    leaf-list required-leaflist { type string; min-elements 2; }
+    leaf-list optional-leaflist { type string; }
+    anyxml mandatory-anyxml { mandatory true; }
+    container child { leaf inner { type string; mandatory true; } }
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "mandatory.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("mandatory")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	var got []string
+	for _, ce := range e.Dir["c"].MandatoryChildren() {
+		got = append(got, ce.Name)
+	}
+	want := []string{"mandatory-anyxml", "mandatory-choice", "mandatory-leaf", "required-leaflist", "required-list"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MandatoryChildren (-want, +got):\n%s", diff)
+	}
+	if got := e.Dir["c"].Dir["plain-leaf"].MandatoryChildren(); len(got) != 0 {
+		t.Errorf("MandatoryChildren of a leaf: got %v, want none", got)
+	}
+}
+
+func TestOrderedChildren(t *testing.T) {
+	modtext := `
+module ordered {
+  namespace "urn:ordered";
+  prefix "o";
+
+  grouping g {
+    leaf z { type string; }
+    container y { leaf inner { type string; } }
+  }
+
+  container c {
+    leaf m { type string; }
+    container b { uses g; }
+    uses g;
+    leaf-list a { type string; }
+    list k {
+      key "name";
+      leaf name { type string; }
+    }
+    choice ch {
+      leaf x { type string; }
+    }
+    leaf d { type string; }
+  }
+
+  augment "/c" {
+    leaf aug2 { type string; }
+    leaf aug1 { type string; }
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "ordered.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("ordered")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	names := func(es []*Entry) []string {
+		var names []string
+		for _, e := range es {
+			names = append(names, e.Name)
+		}
+		return names
+	}
+	c := e.Dir["c"]
+	want := []string{"m", "b", "z", "y", "a", "k", "ch", "d", "aug2", "aug1"}
+	if diff := cmp.Diff(want, names(c.OrderedChildren())); diff != "" {
+		t.Errorf("OrderedChildren of c (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"z", "y"}, names(c.Dir["b"].OrderedChildren())); diff != "" {
+		t.Errorf("OrderedChildren of b (-want, +got):\n%s", diff)
+	}
+	if got := c.Dir["m"].OrderedChildren(); len(got) != 0 {
+		t.Errorf("OrderedChildren of a leaf: got %v, want none", names(got))
+	}
+}
+
+func TestOrderedDir(t *testing.T) {
+	modtext := `
+module ordered-dir {
+  namespace "urn:ordered-dir";
+  prefix "o";
+
+  grouping g {
+    leaf z { type string; }
+    leaf y { type string; }
+  }
+
+  container c {
+    leaf m { type string; }
+    uses g;
+    leaf removed { type string; }
+    choice ch {
+      leaf x { type string; }
+    }
+  }
+
+  augment "/c" {
+    leaf aug { type string; }
+  }
+
+  deviation "/c/removed" {
+    deviate not-supported;
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "ordered-dir.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("ordered-dir")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	var check func(e *Entry)
+	check = func(e *Entry) {
+		if len(e.OrderedDir) != len(e.Dir) {
+			t.Errorf("%s: OrderedDir has %d entries, Dir has %d", e.Path(), len(e.OrderedDir), len(e.Dir))
+		}
+		for i, ne := range e.OrderedDir {
+			if e.Dir[ne.Name] != ne.Entry {
+				t.Errorf("%s: OrderedDir[%d] %s is not Dir[%q]", e.Path(), i, ne.Entry.Path(), ne.Name)
+			}
+			check(ne.Entry)
+		}
+	}
+	check(e)
+
+	var got []string
+	for _, ne := range e.Dir["c"].OrderedDir {
+		got = append(got, ne.Name)
+	}
+	if diff := cmp.Diff([]string{"m", "z", "y", "ch", "aug"}, got); diff != "" {
+		t.Errorf("OrderedDir of c (-want, +got):\n%s", diff)
+	}
+
+	// An Entry constructed programmatically.
+	p := &Entry{
+		Name: "p",
+		Kind: DirectoryEntry,
+		Dir: map[string]*Entry{
+			"b": {Name: "b", Kind: DirectoryEntry, Dir: map[string]*Entry{"inner": {Name: "inner"}}},
+			"a": {Name: "a"},
+		},
+	}
+	SyncOrderedDir(p)
+	want := []NamedEntry{{Name: "a", Entry: p.Dir["a"]}, {Name: "b", Entry: p.Dir["b"]}}
+	if diff := cmp.Diff(want, p.OrderedDir, cmp.Comparer(func(x, y *Entry) bool { return x == y })); diff != "" {
+		t.Errorf("SyncOrderedDir (-want, +got):\n%s", diff)
+	}
+	if got := p.Dir["b"].OrderedDir; len(got) != 1 || got[0].Entry != p.Dir["b"].Dir["inner"] {
+		t.Errorf("SyncOrderedDir did not sync the descendants of p: got %v", got)
+	}
+}
+
+func TestKeyEntries(t *testing.T) {
+	modtext := `
+module keys {
+  namespace "urn:keys";
+  prefix "k";
+
+  list good {
+    key "second first";
+    leaf first { type string; }
+    leaf second { type string; }
+    leaf other { type string; }
+  }
+  list missing {
+    key "nope";
+    leaf name { type string; }
+  }
+  list not-leaf {
+    key "ll";
+    leaf-list ll { type string; }
+  }
+  list config-mismatch {
+    key "name";
+    leaf name { type string; config false; }
+  }
+  container state {
+    config false;
+    list readonly {
+      key "name";
+      leaf name { type string; }
+    }
+  }
+  list keyless {
+    config false;
+    leaf name { type string; }
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "keys.yang"); err != nil {
+		t.Fatal(err)
+	}
+	// Process reports the invalid keys, which KeyEntries is tested with.
+	ms.Process()
+	e := ToEntry(ms.Modules["keys"])
+
+	tests := []struct {
+		desc       string
+		list       *Entry
+		want       []string
+		wantErrSub string
+	}{
+		{desc: "declared order", list: e.Dir["good"], want: []string{"second", "first"}},
+		{desc: "read-only list", list: e.Dir["state"].Dir["readonly"], want: []string{"name"}},
+		{desc: "no key", list: e.Dir["keyless"]},
+		{desc: "missing key leaf", list: e.Dir["missing"], wantErrSub: `key "nope" is not a child`},
+		{desc: "key is not a leaf", list: e.Dir["not-leaf"], wantErrSub: `key "ll" of list /keys/not-leaf is not a leaf`},
+		{desc: "config mismatch", list: e.Dir["config-mismatch"], wantErrSub: "different config value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			keys, err := tt.list.KeyEntries()
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("KeyEntries: %s", diff)
+			}
+			var got []string
+			for _, k := range keys {
+				got = append(got, k.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("KeyEntries (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsKey(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module keys {
+  namespace "urn:keys";
+  prefix "k";
+
+  list l {
+    key "  k:second
+           first ";
+    leaf first { type string; }
+    leaf second { type string; }
+    leaf firstly { type string; }
+    leaf-list ll { type string; }
+    container c {
+      leaf first { type string; }
+    }
+  }
+  container top {
+    leaf first { type string; }
+  }
+}`, "keys.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	e := ToEntry(ms.Modules["keys"])
+	l := e.Dir["l"]
+
+	for _, tt := range []struct {
+		entry *Entry
+		want  bool
+	}{
+		{l.Dir["first"], true},
+		{l.Dir["second"], true},
+		{l.Dir["firstly"], false},
+		{l.Dir["ll"], false},
+		{l.Dir["c"].Dir["first"], false},
+		{e.Dir["top"].Dir["first"], false},
+		{l, false},
+	} {
+		if got := tt.entry.IsKey(); got != tt.want {
+			t.Errorf("IsKey of %s: got %v, want %v", tt.entry.Path(), got, tt.want)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	modtext := `
+module flatten {
+  namespace "urn:flatten";
+  prefix "f";
+
+  container c {
+    leaf a { type string; }
+    choice ch {
+      case one {
+        leaf b { type string; }
+        choice nested {
+          leaf c { type string; }
+        }
+      }
+      container d {
+        leaf e { type string; }
+      }
+    }
+    list l {
+      key "k";
+      leaf k { type string; }
+      choice lch { leaf m { type string; } }
+    }
+    leaf-list z { type string; }
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "flatten.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("flatten")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	names := func(es []*Entry) []string {
+		var names []string
+		for _, e := range es {
+			names = append(names, e.Name)
+		}
+		return names
+	}
+	c := e.Dir["c"]
+	if diff := cmp.Diff([]string{"a", "b", "c", "d", "l", "z"}, names(c.Flatten())); diff != "" {
+		t.Errorf("Flatten (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c", "d", "e", "l", "k", "m", "z"}, names(c.FlattenAll())); diff != "" {
+		t.Errorf("FlattenAll (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"c", "a", "b", "c", "d", "e", "l", "k", "m", "z"}, names(e.FlattenAll())); diff != "" {
+		t.Errorf("FlattenAll of module (-want, +got):\n%s", diff)
+	}
+}
+
+func TestToFlatMap(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module flat {
+  prefix "f";
+  namespace "urn:flat";
+  container c {
+    leaf a { type string; }
+    choice ch {
+      leaf b { type string; }
+    }
+  }
+  rpc r {
+    input { leaf i { type string; } }
+  }
+}`, "flat.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	e := ToEntry(ms.Modules["flat"])
+	m := e.ToFlatMap()
+	var got []string
+	for path, pe := range m {
+		if pe.Path() != path {
+			t.Errorf("entry of %s has path %s", path, pe.Path())
+		}
+		got = append(got, path)
+	}
+	sort.Strings(got)
+	want := []string{
+		"/flat/c",
+		"/flat/c/a",
+		"/flat/c/ch",
+		"/flat/c/ch/b",
+		"/flat/c/ch/b/b",
+		"/flat/r",
+		"/flat/r/input",
+		"/flat/r/input/i",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ToFlatMap (-want, +got):\n%s", diff)
+	}
+	if m["/flat/c/a"] != e.Dir["c"].Dir["a"] {
+		t.Errorf("ToFlatMap()[/flat/c/a] is not /flat/c/a")
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module depth {
+  prefix "d";
+  namespace "urn:d";
+  container a {
+    container b {
+      list c {
+        key "k";
+        leaf k { type string; }
+      }
+    }
+    leaf x { type string; }
+  }
+  container empty {}
+  container choices {
+    choice ch {
+      case one { leaf y { type string; } }
+    }
+  }
+  rpc r {
+    input { container in { leaf z { type string; } } }
+  }
+}`, "depth.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("depth")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	for path, want := range map[string]int{
+		"":               4,
+		"a":              3,
+		"a/b/c/k":        0,
+		"a/x":            0,
+		"empty":          0,
+		"choices":        3,
+		"r":              3,
+		"a/b/c":          1,
+		"choices/ch/one": 1,
+	} {
+		n := e
+		if path != "" {
+			if n = e.Find(path); n == nil {
+				t.Errorf("%s not found", path)
+				continue
+			}
+		}
+		if got := n.MaxDepth(); got != want {
+			t.Errorf("%s: MaxDepth() = %d, want %d", path, got, want)
+		}
+	}
+
+	// An entry that is its own ancestor is not descended into again.
+	loop := &Entry{Name: "loop", Dir: map[string]*Entry{}}
+	child := &Entry{Name: "child", Parent: loop, Dir: map[string]*Entry{"loop": loop}}
+	loop.Dir["child"] = child
+	if got := loop.MaxDepth(); got != 1 {
+		t.Errorf("MaxDepth of a cycle = %d, want 1", got)
+	}
+}
+
+func TestUniqueEntries(t *testing.T) {
+	modtext := `
+module unique {
+  namespace "urn:unique";
+  prefix "u";
+
+  list server {
+    key "name";
+    unique "ip port";
+    unique "u:config/u:label";
+    leaf name { type string; }
+    leaf ip { type string; }
+    leaf port { type uint16; }
+    container config { leaf label { type string; } }
+  }
+  list plain {
+    key "name";
+    leaf name { type string; }
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "unique.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("unique")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	server := e.Dir["server"]
+	if diff := cmp.Diff([][]string{{"ip", "port"}, {"u:config/u:label"}}, server.ListAttr.Unique); diff != "" {
+		t.Errorf("ListAttr.Unique (-want, +got):\n%s", diff)
+	}
+	constraints, err := server.UniqueConstraints()
+	if err != nil {
+		t.Fatalf("UniqueConstraints: %v", err)
+	}
+	if diff := cmp.Diff([][]string{{"ip", "port"}, {"u:config/u:label"}}, constraints); diff != "" {
+		t.Errorf("UniqueConstraints (-want, +got):\n%s", diff)
+	}
+	if _, err := server.Dir["config"].UniqueConstraints(); err == nil {
+		t.Errorf("UniqueConstraints of a container: got no error")
+	}
+	uniques, err := server.UniqueEntries()
+	if err != nil {
+		t.Fatalf("UniqueEntries: %v", err)
+	}
+	var got [][]string
+	for _, leaves := range uniques {
+		var paths []string
+		for _, le := range leaves {
+			paths = append(paths, le.Path())
+		}
+		got = append(got, paths)
+	}
+	want := [][]string{{"/unique/server/ip", "/unique/server/port"}, {"/unique/server/config/label"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("UniqueEntries (-want, +got):\n%s", diff)
+	}
+	if uniques, err := e.Dir["plain"].UniqueEntries(); err != nil || len(uniques) != 0 {
+		t.Errorf("UniqueEntries of list without unique: got %v, %v, want none", uniques, err)
+	}
+}
+
+func TestGetExtension(t *testing.T) {
+	ms := NewModules()
+	for name, text := range map[string]string{
+		"ext-defs": `module ext-defs {
+  prefix "d";
+  namespace "urn:d";
+  extension version { argument "v"; }
+  extension tag { argument "t"; }
+}`,
+		"ext-user": `module ext-user {
+  prefix "u";
+  namespace "urn:u";
+  import ext-defs { prefix "oc-ext"; }
+
+  container c {
+    oc-ext:tag "first";
+    oc-ext:tag "second";
+    oc-ext:version "1.0.0";
+    leaf l { type string; }
+  }
+}`,
+	} {
+		if err := ms.Parse(text, name+".yang"); err != nil {
+			t.Fatalf("Parse(%s): %v", name, err)
+		}
+	}
+	e, errs := ms.GetModule("ext-user")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	c := e.Dir["c"]
+
+	if got := c.GetExtension("oc-ext", "version"); got == nil || got.Argument != "1.0.0" {
+		t.Errorf("GetExtension(oc-ext, version): got %v, want argument 1.0.0", got)
+	}
+	if got := c.GetExtension("oc-ext", "tag"); got == nil || got.Argument != "first" {
+		t.Errorf("GetExtension(oc-ext, tag): got %v, want argument first", got)
+	}
+	var tags []string
+	for _, ext := range c.GetExtensions("oc-ext", "tag") {
+		tags = append(tags, ext.Argument)
+	}
+	if diff := cmp.Diff([]string{"first", "second"}, tags); diff != "" {
+		t.Errorf("GetExtensions(oc-ext, tag) (-want, +got):\n%s", diff)
+	}
+	if got := c.GetExtension("d", "version"); got != nil {
+		t.Errorf("GetExtension(d, version): got %v, want nil", got)
+	}
+	if got := c.Dir["l"].GetExtensions("oc-ext", "tag"); len(got) != 0 {
+		t.Errorf("GetExtensions on leaf without extensions: got %v, want none", got)
+	}
+}
+
+func TestIsMandatory(t *testing.T) {
+	modtext := `
+module is-mandatory {
+  namespace "urn:is-mandatory";
+  prefix "m";
+
+  container c {
+    leaf mandatory-leaf { type string; mandatory true; }
+    leaf optional-leaf { type string; mandatory false; }
+    leaf plain-leaf { type string; }
+    choice mandatory-choice {
+      mandatory true;
+      leaf a { type string; }
+    }
+    choice defaulted-choice {
+      default x;
+      leaf x { type string; }
+    }
+    choice optional-choice {
+      leaf y { type string; mandatory true; }
+    }
+    list required-list {
+      key "k";
+      min-elements 1;
+      leaf k { type string; }
+    }
+    leaf-list required-leaflist { type string; min-elements 2; }
+    leaf-list optional-leaflist { type string; min-elements 0; }
+    anydata mandatory-anydata { mandatory true; }
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "is-mandatory.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("is-mandatory")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	c := e.Dir["c"]
+
+	for name, want := range map[string]bool{
+		"mandatory-leaf":    true,
+		"optional-leaf":     false,
+		"plain-leaf":        false,
+		"mandatory-choice":  true,
+		"defaulted-choice":  false,
+		"optional-choice":   false,
+		"required-list":     true,
+		"required-leaflist": true,
+		"optional-leaflist": false,
+		"mandatory-anydata": true,
+	} {
+		if got := c.Dir[name].IsMandatory(); got != want {
+			t.Errorf("%s: IsMandatory() = %v, want %v", name, got, want)
+		}
+	}
+	if got := c.Dir["optional-leaf"].Mandatory; got != TSFalse {
+		t.Errorf("optional-leaf: Mandatory = %v, want %v", got, TSFalse)
+	}
+	if got := c.IsMandatory(); got {
+		t.Errorf("container: IsMandatory() = %v, want false", got)
+	}
+}
+
+func TestChoiceDefaults(t *testing.T) {
+	modtext := `
+module choice-defaults {
+  namespace "urn:choice-defaults";
+  prefix "c";
+
+  container c {
+    choice transport {
+      default tcp;
+      case tcp {
+        leaf tcp-port { type uint16; default 80; }
+        choice tcp-mode {
+          default passive;
+          leaf active { type string; default "a"; }
+          leaf passive { type string; default "p"; }
+        }
+      }
+      case udp {
+        leaf udp-port { type uint16; default 53; }
+      }
+    }
+    choice no-default {
+      leaf x { type string; default "x"; }
+      leaf y { type string; default "y"; }
+    }
+    list l {
+      key "name";
+      leaf name { type string; }
+      choice in-list {
+        leaf z { type string; default "z"; }
+      }
+    }
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "choice-defaults.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("choice-defaults")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	c := e.Dir["c"]
+	transport := c.Dir["transport"]
+
+	if got := transport.Default; got != "tcp" {
+		t.Errorf("choice transport: Default = %q, want tcp", got)
+	}
+	if got := transport.DefaultCase(); got != transport.Dir["tcp"] {
+		t.Errorf("choice transport: DefaultCase() = %v, want case tcp", got)
+	}
+	if got := c.Dir["no-default"].DefaultCase(); got != nil {
+		t.Errorf("choice no-default: DefaultCase() = %v, want nil", got)
+	}
+	if got := c.DefaultCase(); got != nil {
+		t.Errorf("container: DefaultCase() = %v, want nil", got)
+	}
+
+	tcp := transport.Dir["tcp"]
+	mode := tcp.Dir["tcp-mode"]
+	tests := []struct {
+		desc   string
+		leaf   *Entry
+		active map[string]string
+		want   string
+	}{
+		{desc: "default case", leaf: tcp.Dir["tcp-port"], want: "80"},
+		{desc: "other case when default case active", leaf: transport.Dir["udp"].Dir["udp-port"], want: ""},
+		{desc: "other case active", leaf: transport.Dir["udp"].Dir["udp-port"], active: map[string]string{"/choice-defaults/c/transport": "udp"}, want: "53"},
+		{desc: "default case not active", leaf: tcp.Dir["tcp-port"], active: map[string]string{"/choice-defaults/c/transport": "udp"}, want: ""},
+		{desc: "nested default case", leaf: mode.Dir["passive"].Dir["passive"], want: "p"},
+		{desc: "nested non-default case", leaf: mode.Dir["active"].Dir["active"], want: ""},
+		{desc: "nested case of inactive case", leaf: mode.Dir["passive"].Dir["passive"], active: map[string]string{"/choice-defaults/c/transport": "udp"}, want: ""},
+		{desc: "choice without default", leaf: c.Dir["no-default"].Dir["x"].Dir["x"], want: ""},
+		{desc: "choice without default, case active", leaf: c.Dir["no-default"].Dir["x"].Dir["x"], active: map[string]string{"/choice-defaults/c/no-default": "x"}, want: "x"},
+		{desc: "choice within list", leaf: c.Dir["l"].Dir["in-list"].Dir["z"].Dir["z"], want: ""},
+		{desc: "list within inactive case", leaf: c.Dir["l"].Dir["in-list"].Dir["z"].Dir["z"], active: map[string]string{"/choice-defaults/c/l/in-list": "z"}, want: "z"},
+		{desc: "not within a case", leaf: c.Dir["l"].Dir["name"], want: ""},
+	}
+	for _, tt := range tests {
+		if got := tt.leaf.CaseDefaultValue(tt.active); got != tt.want {
+			t.Errorf("%s: CaseDefaultValue(%v) = %q, want %q", tt.desc, tt.active, got, tt.want)
+		}
+	}
+}
+
+func TestFlattenChoicesOption(t *testing.T) {
+	modtext := `
+module flatten-choices {
+  namespace "urn:flatten-choices";
+  prefix "f";
+
+  container c {
+    leaf first { type string; }
+    choice transport {
+      default tcp;
+      case tcp {
+        leaf tcp-port { type uint16; default 80; }
+        choice mode {
+          leaf active { type string; }
+        }
+      }
+      case udp {
+        leaf udp-port { type uint16; }
+      }
+    }
+    leaf last { type string; }
+  }
+  rpc r {
+    input {
+      choice in { leaf a { type string; } }
+    }
+  }
+}
+`
+	for _, flatten := range []bool{false, true} {
+		t.Run(fmt.Sprintf("FlattenChoices=%v", flatten), func(t *testing.T) {
+			ParseOptions.FlattenChoices = flatten
+			defer func() { ParseOptions.FlattenChoices = false }()
+
+			ms := NewModules()
+			if err := ms.Parse(modtext, "flatten-choices.yang"); err != nil {
+				t.Fatal(err)
+			}
+			e, errs := ms.GetModule("flatten-choices")
+			if errs != nil {
+				t.Fatalf("GetModule: %v", errs)
+			}
+			c := e.Dir["c"]
+
+			var got []string
+			for _, ce := range c.OrderedChildren() {
+				got = append(got, ce.Name)
+			}
+			want := []string{"first", "transport", "last"}
+			if flatten {
+				want = []string{"first", "tcp-port", "active", "udp-port", "last"}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("children of c (-want, +got):\n%s", diff)
+			}
+			if !flatten {
+				return
+			}
+
+			for name, wantCase := range map[string]string{
+				"tcp-port": "/flatten-choices/c/transport/tcp",
+				"active":   "/flatten-choices/c/transport/tcp/mode/active",
+				"udp-port": "/flatten-choices/c/transport/udp",
+			} {
+				ce := c.Dir[name]
+				if ce.Parent != c {
+					t.Errorf("%s: Parent is %s, want %s", name, ce.Parent.Path(), c.Path())
+				}
+				if ce.Case == nil || ce.Case.Path() != wantCase {
+					t.Errorf("%s: Case is %v, want %s", name, ce.Case, wantCase)
+				}
+			}
+			if got := c.Dir["first"].Case; got != nil {
+				t.Errorf("first: Case is %s, want nil", got.Path())
+			}
+			if got, want := c.Dir["tcp-port"].Path(), "/flatten-choices/c/tcp-port"; got != want {
+				t.Errorf("tcp-port: Path() = %s, want %s", got, want)
+			}
+			if got := c.Dir["tcp-port"].CaseDefaultValue(nil); got != "80" {
+				t.Errorf("tcp-port: CaseDefaultValue(nil) = %q, want 80", got)
+			}
+			if got := c.Dir["tcp-port"].CaseDefaultValue(map[string]string{"/flatten-choices/c/transport": "udp"}); got != "" {
+				t.Errorf("tcp-port: CaseDefaultValue with udp active = %q, want empty", got)
+			}
+			if in := e.Dir["r"].RPC.Input; in.Dir["a"] == nil {
+				t.Errorf("rpc input: choice was not flattened, children are %v", in.Dir)
+			}
+		})
+	}
+}
+
+func TestFlattenChoicesConflict(t *testing.T) {
+	ParseOptions.FlattenChoices = true
+	defer func() { ParseOptions.FlattenChoices = false }()
+
+	ms := NewModules()
+	if err := ms.Parse(`module conflict {
+  namespace "urn:conflict";
+  prefix "c";
+  leaf a { type string; }
+  choice ch { leaf a { type int8; } leaf b { type string; } }
+}`, "conflict.yang"); err != nil {
+		t.Fatal(err)
+	}
+	errs := ms.Process()
+	if len(errs) != 1 {
+		t.Fatalf("Process: got errors %v, want one conflict error", errs)
+	}
+	if diff := errdiff.Substring(errs[0], "conflict.yang:5:15: a in choice /conflict/ch conflicts with /conflict/a from conflict.yang:4:3"); diff != "" {
+		t.Error(diff)
+	}
+	e := ToEntry(ms.Modules["conflict"])
+	if got := e.Dir["a"].Type.Kind; got != Ystring {
+		t.Errorf("leaf a: got type %v, want the original string leaf", got)
+	}
+	if e.Dir["b"] == nil {
+		t.Errorf("leaf b was not moved out of choice ch")
+	}
+}
+
+func TestEntryStatus(t *testing.T) {
+	modtext := `
+module status {
+  namespace "urn:status";
+  prefix "s";
+
+  typedef old-type { type string; status deprecated; }
+  typedef derived-type { type old-type; }
+
+  grouping dep-group {
+    status deprecated;
+    leaf from-group { type string; }
+  }
+  grouping plain-group {
+    leaf from-uses { type string; }
+  }
+
+  container c {
+    leaf current-leaf { type string; }
+    leaf explicit-current { type string; status current; }
+    leaf deprecated-leaf { type string; status deprecated; }
+    leaf obsolete-leaf { type string; status obsolete; }
+    leaf typed-leaf { type derived-type; }
+    leaf-list deprecated-leaflist { type string; status deprecated; }
+    uses dep-group;
+    uses plain-group { status obsolete; }
+  }
+  container dep {
+    status deprecated;
+    leaf inner { type string; }
+    leaf inner-obsolete { type string; status obsolete; }
+    container nested { leaf deep { type string; } }
+  }
+  augment "/s:c" {
+    status deprecated;
+    leaf augmented { type string; }
+  }
+}
+`
+	ms := NewModules()
+	if err := ms.Parse(modtext, "status.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("status")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	tests := []struct {
+		path          []string
+		wantStatus    Status
+		wantEffective Status
+	}{
+		{[]string{"c"}, StatusCurrent, StatusCurrent},
+		{[]string{"c", "current-leaf"}, StatusCurrent, StatusCurrent},
+		{[]string{"c", "explicit-current"}, StatusCurrent, StatusCurrent},
+		{[]string{"c", "deprecated-leaf"}, StatusDeprecated, StatusDeprecated},
+		{[]string{"c", "obsolete-leaf"}, StatusObsolete, StatusObsolete},
+		{[]string{"c", "typed-leaf"}, StatusDeprecated, StatusDeprecated},
+		{[]string{"c", "deprecated-leaflist"}, StatusDeprecated, StatusDeprecated},
+		{[]string{"c", "from-group"}, StatusDeprecated, StatusDeprecated},
+		{[]string{"c", "from-uses"}, StatusObsolete, StatusObsolete},
+		{[]string{"c", "augmented"}, StatusDeprecated, StatusDeprecated},
+		{[]string{"dep"}, StatusDeprecated, StatusDeprecated},
+		{[]string{"dep", "inner"}, StatusCurrent, StatusDeprecated},
+		{[]string{"dep", "inner-obsolete"}, StatusObsolete, StatusObsolete},
+		{[]string{"dep", "nested", "deep"}, StatusCurrent, StatusDeprecated},
+	}
+	for _, tt := range tests {
+		ce := e
+		for _, name := range tt.path {
+			if ce = ce.Dir[name]; ce == nil {
+				t.Fatalf("%v: node not found", tt.path)
+			}
+		}
+		if ce.Status != tt.wantStatus {
+			t.Errorf("%s: Status = %v, want %v", ce.Path(), ce.Status, tt.wantStatus)
+		}
+		if got := ce.EffectiveStatus(); got != tt.wantEffective {
+			t.Errorf("%s: EffectiveStatus() = %v, want %v", ce.Path(), got, tt.wantEffective)
+		}
+		if got, want := ce.IsCurrent(), tt.wantEffective == StatusCurrent; got != want {
+			t.Errorf("%s: IsCurrent() = %v, want %v", ce.Path(), got, want)
+		}
+		if got, want := ce.IsDeprecated(), tt.wantEffective == StatusDeprecated; got != want {
+			t.Errorf("%s: IsDeprecated() = %v, want %v", ce.Path(), got, want)
+		}
+		if got, want := ce.IsObsolete(), tt.wantEffective == StatusObsolete; got != want {
+			t.Errorf("%s: IsObsolete() = %v, want %v", ce.Path(), got, want)
+		}
+	}
+
+	ms = NewModules()
+	if err := ms.Parse(`module bad-status { prefix "b"; namespace "urn:b"; leaf l { type string; status old; } }`, "bad-status.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "invalid status value: old") {
+		t.Errorf("Process with invalid status: got %v, want invalid status error", errs)
+	}
+}
+
+func TestPresenceContainer(t *testing.T) {
+	ms := NewModules()
+	for name, text := range map[string]string{
+		"presence": `module presence {
+  namespace "urn:presence";
+  prefix "p";
+
+  grouping g {
+    container from-grouping { presence "enables the feature"; }
+  }
+  container with-presence {
+    presence "the container has meaning";
+    leaf l { type string; }
+  }
+  container without-presence {
+    leaf l { type string; }
+    uses g;
+  }
+  list l {
+    key "k";
+    leaf k { type string; }
+  }
+}`,
+		"presence-aug": `module presence-aug {
+  namespace "urn:presence-aug";
+  prefix "pa";
+  import presence { prefix "p"; }
+  augment "/p:without-presence" {
+    container augmented { presence "added by an augment"; }
+  }
+}`,
+	} {
+		if err := ms.Parse(text, name+".yang"); err != nil {
+			t.Fatalf("Parse(%s): %v", name, err)
+		}
+	}
+	e, errs := ms.GetModule("presence")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	tests := []struct {
+		desc         string
+		entry        *Entry
+		wantPresence string
+		wantIs       bool
+	}{
+		{desc: "presence container", entry: e.Dir["with-presence"], wantPresence: "the container has meaning", wantIs: true},
+		{desc: "non-presence container", entry: e.Dir["without-presence"]},
+		{desc: "from grouping", entry: e.Dir["without-presence"].Dir["from-grouping"], wantPresence: "enables the feature", wantIs: true},
+		{desc: "from augment", entry: e.Dir["without-presence"].Dir["augmented"], wantPresence: "added by an augment", wantIs: true},
+		{desc: "list", entry: e.Dir["l"]},
+		{desc: "leaf", entry: e.Dir["with-presence"].Dir["l"]},
+	}
+	for _, tt := range tests {
+		if tt.entry == nil {
+			t.Errorf("%s: entry not found", tt.desc)
+			continue
+		}
+		var got string
+		if tt.entry.Presence != nil {
+			got = tt.entry.Presence.Name
+		}
+		if got != tt.wantPresence {
+			t.Errorf("%s: Presence = %q, want %q", tt.desc, got, tt.wantPresence)
+		}
+		if is := tt.entry.IsPresenceContainer(); is != tt.wantIs {
+			t.Errorf("%s: IsPresenceContainer() = %v, want %v", tt.desc, is, tt.wantIs)
+		}
+	}
+}
+
+func TestEmptyLeaf(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef flag { type empty; }
+  leaf enabled { type empty; }
+  leaf derived { type flag; }
+  leaf name { type string; }
+  container c { presence "p"; }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+	for name, want := range map[string]bool{
+		"enabled": true,
+		"derived": true,
+		"name":    false,
+		"c":       false,
+	} {
+		if got := mod.Dir[name].IsEmptyLeaf(); got != want {
+			t.Errorf("%s: IsEmptyLeaf() = %v, want %v", name, got, want)
+		}
+	}
+
+	y := mod.Dir["enabled"].Type
+	if v, err := y.ParseValue(""); err != nil || v != nil {
+		t.Errorf(`ParseValue("") = %v, %v, want nil, nil`, v, err)
+	}
+	if _, err := y.ParseValue("true"); err == nil {
+		t.Errorf(`ParseValue("true") succeeded, want error`)
+	}
+}
+
+func TestChoiceCases(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    choice transport {
+      case tcp {
+        leaf port { type uint16; }
+        choice mode {
+          leaf active { type empty; }
+          leaf passive { type empty; }
+        }
+      }
+      leaf udp { type uint16; }
+      container local { leaf path { type string; } }
+    }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	c := ToEntry(ms.Modules["m"]).Dir["c"]
+
+	cases, err := c.Dir["transport"].ChoiceCases()
+	if err != nil {
+		t.Fatalf("ChoiceCases: %v", err)
+	}
+	got := map[string][]string{}
+	for name, entries := range cases {
+		for _, e := range entries {
+			got[name] = append(got[name], e.Name)
+		}
+	}
+	want := map[string][]string{
+		"tcp":   {"port", "active", "passive"},
+		"udp":   {"udp"},
+		"local": {"local"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ChoiceCases (-want, +got):\n%s", diff)
+	}
+
+	if _, err := c.ChoiceCases(); err == nil {
+		t.Errorf("ChoiceCases of a container succeeded, want error")
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container a {
+    list b {
+      key "k";
+      leaf k { type string; }
+    }
+  }
+  rpc r { input { leaf i { type string; } } }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+	paths := func(es []*Entry) []string {
+		var ps []string
+		for _, e := range es {
+			ps = append(ps, e.Path())
+		}
+		return ps
+	}
+	for _, tt := range []struct {
+		e    *Entry
+		want []string
+	}{
+		{e: mod},
+		{e: mod.Dir["a"], want: []string{"/m"}},
+		{e: mod.Find("a/b/k"), want: []string{"/m/a/b", "/m/a", "/m"}},
+		{e: mod.Dir["r"].RPC.Input.Dir["i"], want: []string{"/m/r/input", "/m/r", "/m"}},
+	} {
+		if diff := cmp.Diff(tt.want, paths(tt.e.Ancestors())); diff != "" {
+			t.Errorf("%s: Ancestors (-want, +got):\n%s", tt.e.Path(), diff)
+		}
+		if got := tt.e.Module(); got != mod {
+			t.Errorf("%s: Module() = %v, want the module entry", tt.e.Path(), got)
+		}
+	}
+	if got := (&Entry{Name: "orphan"}).Module(); got != nil {
+		t.Errorf("Module() of an entry without a module = %v, want nil", got)
+	}
+}
+
+func TestSiblings(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf a { type string; }
+    choice ch {
+      case one { leaf b { type string; } }
+      leaf d { type string; }
+    }
+    container e { leaf inner { type string; } }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	c := ToEntry(ms.Modules["m"]).Dir["c"]
+	b := c.Find("ch/one/b")
+
+	tests := []struct {
+		desc       string
+		e          *Entry
+		name       string
+		want       *Entry
+		wantErrSub string
+	}{{
+		desc: "leaf",
+		e:    c.Dir["a"],
+		name: "e",
+		want: c.Dir["e"],
+	}, {
+		desc: "into a case",
+		e:    c.Dir["a"],
+		name: "b",
+		want: b,
+	}, {
+		desc: "out of a case",
+		e:    b,
+		name: "a",
+		want: c.Dir["a"],
+	}, {
+		desc:       "choice is not a sibling",
+		e:          c.Dir["a"],
+		name:       "ch",
+		wantErrSub: "/m/c/a has no sibling ch",
+	}, {
+		desc:       "not its own sibling",
+		e:          c.Dir["a"],
+		name:       "a",
+		wantErrSub: "has no sibling a",
+	}, {
+		desc:       "no parent",
+		e:          c.Parent,
+		name:       "c",
+		wantErrSub: "/m has no parent",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.e.Sibling(tt.name)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("Sibling(%q): %s", tt.name, diff)
+			}
+			if got != tt.want {
+				t.Errorf("Sibling(%q) = %s, want %s", tt.name, got.Path(), tt.want.Path())
+			}
+		})
+	}
+
+	var got []string
+	for _, s := range b.Siblings() {
+		got = append(got, s.Name)
+	}
+	if diff := cmp.Diff([]string{"a", "d", "e"}, got); diff != "" {
+		t.Errorf("Siblings (-want, +got):\n%s", diff)
+	}
+	if got := c.Parent.Siblings(); got != nil {
+		t.Errorf("Siblings of the module = %v, want nil", got)
+	}
+}
+
+func TestSubmodule(t *testing.T) {
+	ms := NewModules()
+	for _, in := range []string{`module m {
+  prefix "m";
+  namespace "urn:m";
+  include sub;
+  container main { uses g; }
+}`, `submodule sub {
+  belongs-to m { prefix "m"; }
+  grouping g { leaf grouped { type string; } }
+  container defined { leaf l { type string; } }
+}`} {
+		if err := ms.Parse(in, "m.yang"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+	for path, want := range map[string]string{
+		"main":         "",
+		"main/grouped": "sub",
+		"defined":      "sub",
+		"defined/l":    "sub",
+	} {
+		e := mod.Find(path)
+		if e == nil {
+			t.Errorf("%s not found", path)
+			continue
+		}
+		if got := e.Submodule(); got != want {
+			t.Errorf("%s: Submodule() = %q, want %q", path, got, want)
+		}
+		if got, err := e.InstantiatingModule(); err != nil || got != "m" {
+			t.Errorf("%s: InstantiatingModule() = %q, %v, want m", path, got, err)
+		}
+	}
+	if got := mod.Submodule(); got != "" {
+		t.Errorf("module: Submodule() = %q, want empty", got)
+	}
+}
+
+// taggedError is an error whose tag distinguishes it from other errors with
+// the same message.
+type taggedError struct {
+	msg string
+	tag int
+}
+
+func (e taggedError) Error() string { return e.msg }
+
+func TestErrorSort(t *testing.T) {
+	var in []error
+	for _, s := range []string{
+		"b.yang:2:1: second",
+		"a.yang:10:3: late",
+		"no position",
+		"a.yang:2:7: b",
+		"a.yang:2:7: a",
+		"a.yang:2:10: wide column",
+		"b.yang:2:1: second",
+		"a.yang:3: no column",
+		"another: without position",
+	} {
+		in = append(in, fmt.Errorf("%s", s))
+	}
+	// Distinct errors with the same text keep their order.
+	first, second := taggedError{"same", 2}, taggedError{"same", 1}
+	in = append(in, first, second)
+
+	var got []string
+	out := errorSort(in)
+	for _, err := range out {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"a.yang:2:7: a",
+		"a.yang:2:7: b",
+		"a.yang:2:10: wide column",
+		"a.yang:3: no column",
+		"a.yang:10:3: late",
+		"another: without position",
+		"b.yang:2:1: second",
+		"no position",
+		"same",
+		"same",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("errorSort (-want, +got):\n%s", diff)
+	}
+	if n := len(out); n < 2 || out[n-2] != first || out[n-1] != second {
+		t.Errorf("errorSort did not keep the order of equal errors")
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef color {
+    type enumeration {
+      enum red { value 5; description "the color red"; reference "RFC 0000"; }
+      enum green;
+      enum blue { value 1; }
+    }
+  }
+  typedef warm {
+    type color {
+      enum red;
+      enum green { description "restricted"; }
+    }
+  }
+  leaf all { type color; }
+  leaf some { type warm; }
+  leaf-list own { type enumeration { enum b; enum a; } }
+  leaf s { type string; }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+
+	tests := []struct {
+		leaf       string
+		want       []EnumValue
+		wantErrSub string
+	}{{
+		leaf: "all",
+		want: []EnumValue{
+			{Name: "red", Value: 5, Description: "the color red", Reference: "RFC 0000"},
+			{Name: "green", Value: 6},
+			{Name: "blue", Value: 1},
+		},
+	}, {
+		leaf: "some",
+		want: []EnumValue{
+			{Name: "red", Value: 5, Description: "the color red", Reference: "RFC 0000"},
+			{Name: "green", Value: 6, Description: "restricted"},
+		},
+	}, {
+		leaf: "own",
+		want: []EnumValue{{Name: "b", Value: 0}, {Name: "a", Value: 1}},
+	}, {
+		leaf:       "s",
+		wantErrSub: "/m/s is not of an enumeration type",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.leaf, func(t *testing.T) {
+			got, err := mod.Dir[tt.leaf].EnumValues()
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("EnumValues: %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("EnumValues (-want, +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		leaf, in   string
+		wantErrSub string
+	}{
+		{leaf: "all", in: "blue"},
+		{leaf: "some", in: "blue", wantErrSub: `"blue" is not a valid enum`},
+		{leaf: "own", in: "a"},
+		{leaf: "s", in: "a", wantErrSub: "is not of an enumeration type"},
+	} {
+		if diff := errdiff.Substring(mod.Dir[tt.leaf].ValidateEnum(tt.in), tt.wantErrSub); diff != "" {
+			t.Errorf("%s: ValidateEnum(%q): %s", tt.leaf, tt.in, diff)
+		}
+	}
+}
+
+func TestMaxEntryDepth(t *testing.T) {
+	// chain returns a module whose container top expands a chain of n
+	// groupings, each of which uses the next.
+	chain := func(n int) string {
+		var b strings.Builder
+		b.WriteString("module m {\n  prefix \"m\";\n  namespace \"urn:m\";\n")
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&b, "  grouping g%d { container c%d { uses g%d; } }\n", i, i, i+1)
+		}
+		fmt.Fprintf(&b, "  grouping g%d { leaf l { type string; } }\n", n)
+		b.WriteString("  container top { uses g0; }\n}\n")
+		return b.String()
+	}
+	tests := []struct {
+		desc       string
+		in         string
+		max        int
+		wantErrSub string
+	}{{
+		desc: "within default",
+		in:   chain(50),
+	}, {
+		desc:       "too deep",
+		in:         chain(50),
+		max:        40,
+		wantErrSub: "is nested more than 40 levels deep",
+	}, {
+		desc:       "recursive grouping",
+		in:         `module m { prefix "m"; namespace "urn:m"; grouping g { container c { uses g; uses g; } } container top { uses g; } }`,
+		max:        100,
+		wantErrSub: "is nested more than 100 levels deep",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			defer func(max int) { ParseOptions.MaxEntryDepth = max }(ParseOptions.MaxEntryDepth)
+			ParseOptions.MaxEntryDepth = tt.max
+			ms := NewModules()
+			if err := ms.Parse(tt.in, "m.yang"); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var err error
+			if errs := ms.Process(); len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("Process: %s", diff)
+			}
+		})
+	}
+
+	// The nodes that are not expanded because they are nested too deeply
+	// are expanded by a later call to ToEntry.
+	defer func(max int) { ParseOptions.MaxEntryDepth = max }(ParseOptions.MaxEntryDepth)
+	ParseOptions.MaxEntryDepth = 40
+	ms := NewModules()
+	if err := ms.Parse(chain(50), "m.yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := ms.Process(); len(errs) == 0 {
+		t.Fatalf("Process: got no errors, want too deep")
+	}
+	if errs := ToEntry(ms.Modules["m"].Grouping[45]).GetErrors(); len(errs) > 0 {
+		t.Errorf("ToEntry of grouping g45: %v", errs)
+	}
+
+	// The expansion stops at the first node that is nested too deeply,
+	// and the entries that were being built then are not cached.
+	ms = NewModules()
+	if err := ms.Parse(`module m { prefix "m"; namespace "urn:m"; grouping g { container c { uses g; uses g; } } container top { uses g; } }`, "m.yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := ms.Process(); len(errs) != 1 {
+		t.Errorf("Process: got %v, want one error", errs)
+	}
+	for _, n := range []Node{ms.Modules["m"], ms.Modules["m"].Container[0]} {
+		if entryCache[n] != nil {
+			t.Errorf("%s %s is cached", n.Kind(), n.NName())
+		}
+	}
+}
+
+func TestEntryAs(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    presence "p";
+    leaf l { type string; }
+    leaf-list ll { type string; max-elements 3; }
+    list li { key "k"; leaf k { type string; } }
+    choice ch {
+      case one { leaf x { type string; } }
+      leaf short { type string; }
+    }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+
+	// as returns the kind of the statements returned by the As methods of
+	// e that succeed.
+	as := func(e *Entry) []string {
+		var got []string
+		add := func(n Node, ok bool) {
+			if ok {
+				got = append(got, n.Kind()+" "+n.NName())
+			}
+		}
+		add(e.AsModule())
+		add(e.AsContainer())
+		add(e.AsList())
+		add(e.AsLeaf())
+		add(e.AsLeafList())
+		add(e.AsChoice())
+		add(e.AsCase())
+		return got
+	}
+	for path, want := range map[string][]string{
+		"":                 {"module m"},
+		"c":                {"container c"},
+		"c/l":              {"leaf l"},
+		"c/ll":             {"leaf-list ll"},
+		"c/li":             {"list li"},
+		"c/ch":             {"choice ch"},
+		"c/ch/one":         {"case one"},
+		"c/ch/short":       {"case short"},
+		"c/ch/one/x":       {"leaf x"},
+		"c/ch/short/short": {"leaf short"},
+	} {
+		e := mod
+		if path != "" {
+			e = mod.Find(path)
+		}
+		if e == nil {
+			t.Errorf("%s not found", path)
+			continue
+		}
+		if diff := cmp.Diff(want, as(e)); diff != "" {
+			t.Errorf("%s (-want, +got):\n%s", path, diff)
+		}
+	}
+	if c, _ := mod.Dir["c"].AsContainer(); c.Presence.asString() != "p" {
+		t.Errorf("AsContainer().Presence = %q, want p", c.Presence.asString())
+	}
+	if ll, _ := mod.Find("c/ll").AsLeafList(); ll.MaxElements.asString() != "3" {
+		t.Errorf("AsLeafList().MaxElements = %q, want 3", ll.MaxElements.asString())
+	}
+}
+
+func TestDefaultValues(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef s { type string; default "from-type"; }
+  leaf own { type s; default "x"; }
+  leaf typed { type s; }
+  leaf mandatory { type s; mandatory true; }
+  leaf none { type string; }
+  leaf-list explicit { type s; default "a"; default "b"; }
+  leaf-list type-default { type s; }
+  leaf-list required { type s; min-elements 1; }
+  leaf-list zero { type s; min-elements 0; }
+  container c { leaf l { type string; } }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+	type defaults struct {
+		Values   []string
+		FromType bool
+	}
+	for name, want := range map[string]defaults{
+		"own":          {Values: []string{"x"}},
+		"typed":        {Values: []string{"from-type"}, FromType: true},
+		"mandatory":    {},
+		"none":         {},
+		"explicit":     {Values: []string{"a", "b"}},
+		"type-default": {Values: []string{"from-type"}, FromType: true},
+		"required":     {},
+		"zero":         {Values: []string{"from-type"}, FromType: true},
+		"c":            {},
+	} {
+		var got defaults
+		got.Values, got.FromType = mod.Dir[name].DefaultValues()
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: DefaultValues (-want, +got):\n%s", name, diff)
+		}
+	}
+	if got := mod.Dir["explicit"].Default; got != "a" {
+		t.Errorf("explicit: Default = %q, want a", got)
+	}
+}
+
+func TestDataPath(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    choice ch {
+      case one {
+        container inner {
+          choice nested { leaf deep { type string; } }
+        }
+      }
+      leaf short { type string; }
+    }
+    leaf plain { type string; }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+	for path, want := range map[string]struct{ dataPath, dataParent string }{
+		"c/plain":                         {"/m/c/plain", "/m/c"},
+		"c/ch/one/inner":                  {"/m/c/inner", "/m/c"},
+		"c/ch/one/inner/nested/deep/deep": {"/m/c/inner/deep", "/m/c/ch/one/inner"},
+		"c/ch/short/short":                {"/m/c/short", "/m/c"},
+		"c":                               {"/m/c", "/m"},
+	} {
+		e := mod.Find(path)
+		if e == nil {
+			t.Errorf("%s not found", path)
+			continue
+		}
+		if got := e.DataPath(); got != want.dataPath {
+			t.Errorf("%s: DataPath() = %s, want %s", path, got, want.dataPath)
+		}
+		if got := e.DataParent().Path(); got != want.dataParent {
+			t.Errorf("%s: DataParent().Path() = %s, want %s", path, got, want.dataParent)
+		}
+	}
+	if p := mod.DataParent(); p != nil {
+		t.Errorf("DataParent() of module = %s, want nil", p.Path())
+	}
+}
+
+func TestFindDataNode(t *testing.T) {
+	ms := NewModules()
+	for name, src := range map[string]string{
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  container c {
+    choice ch {
+      case one { leaf x { type string; } }
+    }
+    list l {
+      key "k";
+      leaf k { type string; }
+      container inner { leaf y { type string; } }
+    }
+    leaf z { type string; }
+  }
+  rpc r {
+    input { leaf in { type string; } }
+  }
+}`,
+		"b.yang": `module b {
+  prefix "bp";
+  namespace "urn:b";
+  import a { prefix a; }
+  augment "/a:c/a:ch" {
+    case two { leaf x { type string; } }
+  }
+}`,
+	} {
+		if err := ms.Parse(src, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	a := ToEntry(ms.Modules["a"])
+	c := a.Dir["c"]
+
+	for _, tt := range []struct {
+		desc    string
+		from    *Entry
+		path    string
+		want    string
+		wantErr string
+	}{{
+		desc: "absolute path",
+		from: c,
+		path: "/c/l/inner/y",
+		want: "/a/c/l/inner/y",
+	}, {
+		desc: "relative path",
+		from: c,
+		path: "l/inner",
+		want: "/a/c/l/inner",
+	}, {
+		desc: "keys in predicates",
+		from: a,
+		path: "/c/l[k='foo']/inner/y",
+		want: "/a/c/l/inner/y",
+	}, {
+		desc: "restconf keys",
+		from: a,
+		path: "/a:c/l=foo/inner",
+		want: "/a/c/l/inner",
+	}, {
+		desc: "prefix ignored when unambiguous",
+		from: a,
+		path: "/bp:c/z",
+		want: "/a/c/z",
+	}, {
+		desc: "module name disambiguates",
+		from: a,
+		path: "/c/b:x",
+		want: "/a/c/ch/two/x",
+	}, {
+		desc: "prefix disambiguates",
+		from: a,
+		path: "/c/a:x",
+		want: "/a/c/ch/one/x",
+	}, {
+		desc: "rpc input",
+		from: a,
+		path: "/r/input/in",
+		want: "/a/r/input/in",
+	}, {
+		desc: "root",
+		from: c,
+		path: "/",
+		want: "/a",
+	}, {
+		desc:    "ambiguous",
+		from:    a,
+		path:    "/c/x",
+		wantErr: "data node x of /a/c is ambiguous: /a/c/ch/one/x, /a/c/ch/two/x",
+	}, {
+		desc:    "not found",
+		from:    a,
+		path:    "/c/nope",
+		wantErr: "/a/c has no data node nope",
+	}, {
+		desc:    "below a leaf",
+		from:    a,
+		path:    "/c/z/w",
+		wantErr: "/a/c/z is not a container or list, looking for w",
+	}} {
+		got, err := tt.from.FindDataNode(tt.path)
+		if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+			t.Errorf("%s: FindDataNode(%q): %s", tt.desc, tt.path, diff)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if p := got.Path(); p != tt.want {
+			t.Errorf("%s: FindDataNode(%q) = %s, want %s", tt.desc, tt.path, p, tt.want)
+		}
+	}
+}
+
+func TestIsConfig(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf rw { type string; }
+    container state {
+      config false;
+      leaf ro { type string; }
+    }
+    action reset {
+      input { leaf delay { type uint8; } }
+    }
+  }
+  rpc r {
+    input { leaf in { type string; } }
+    output { leaf out { type string; } }
+  }
+  notification n {
+    leaf event { type string; }
+    container details { leaf info { type string; } }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+
+	for _, tt := range []struct {
+		path string
+		want bool
+	}{
+		{"/c", true},
+		{"/c/rw", true},
+		{"/c/state", false},
+		{"/c/state/ro", false},
+		{"/c/reset", false},
+		{"/c/reset/input/delay", false},
+		{"/r", false},
+		{"/r/input", false},
+		{"/r/input/in", false},
+		{"/r/output/out", false},
+		{"/n", false},
+		{"/n/event", false},
+		{"/n/details/info", false},
+	} {
+		e, err := mod.FindDataNode(tt.path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if got := e.IsConfig(); got != tt.want {
+			t.Errorf("%s: IsConfig() = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNamespaceURI(t *testing.T) {
+	ms := NewModules()
+	for name, src := range map[string]string{
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  import g { prefix g; }
+  container c {
+    leaf l { type string; }
+    uses g:group;
+  }
+}`,
+		"g.yang": `module g {
+  prefix "g";
+  namespace "urn:g";
+  grouping group { leaf from-group { type string; } }
+}`,
+		"b.yang": `module b {
+  prefix "b";
+  namespace "urn:b";
+  import a { prefix a; }
+  augment "/a:c" {
+    container added { leaf inner { type string; } }
+  }
+}`,
+	} {
+		if err := ms.Parse(src, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["a"])
+
+	for _, tt := range []struct {
+		path               string
+		wantNS, wantModule string
+	}{
+		{"/", "urn:a", "a"},
+		{"/c", "urn:a", "a"},
+		{"/c/l", "urn:a", "a"},
+		// Nodes from a grouping are in the namespace of the user.
+		{"/c/from-group", "urn:a", "a"},
+		// Augmented nodes are in the namespace of the augmenting module.
+		{"/c/added", "urn:b", "b"},
+		{"/c/added/inner", "urn:b", "b"},
+	} {
+		e, err := mod.FindDataNode(tt.path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if got := e.NamespaceURI(); got != tt.wantNS {
+			t.Errorf("%s: NamespaceURI() = %q, want %q", tt.path, got, tt.wantNS)
+		}
+		if got := e.ModuleName(); got != tt.wantModule {
+			t.Errorf("%s: ModuleName() = %q, want %q", tt.path, got, tt.wantModule)
+		}
+	}
+}
+
+func TestRPCInputOutput(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  grouping ops {
+    action reset {
+      input { leaf delay { type uint8; } }
+      output { leaf done { type boolean; } }
+    }
+  }
+  container a { uses ops; }
+  container b { uses ops; }
+  container c { action ping; }
+  rpc both {
+    input { leaf in { type string; } }
+    output { leaf out { type string; } }
+  }
+  rpc in-only {
+    input { leaf in { type string; } }
+  }
+  rpc neither;
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+
+	for _, tt := range []struct {
+		path          string
+		wantInput     string
+		wantOutput    string
+		wantInputErr  string
+		wantOutputErr string
+	}{{
+		path:       "both",
+		wantInput:  "/m/both/input/in",
+		wantOutput: "/m/both/output/out",
+	}, {
+		path:          "in-only",
+		wantInput:     "/m/in-only/input/in",
+		wantOutputErr: "/m/in-only has no output",
+	}, {
+		path:          "neither",
+		wantInputErr:  "/m/neither has no input",
+		wantOutputErr: "/m/neither has no output",
+	}, {
+		// Each use of a grouping has its own input and output.
+		path:       "a/reset",
+		wantInput:  "/m/a/reset/input/delay",
+		wantOutput: "/m/a/reset/output/done",
+	}, {
+		path:       "b/reset",
+		wantInput:  "/m/b/reset/input/delay",
+		wantOutput: "/m/b/reset/output/done",
+	}, {
+		path:          "c/ping",
+		wantInputErr:  "/m/c/ping has no input",
+		wantOutputErr: "/m/c/ping has no output",
+	}, {
+		path:          "c",
+		wantInputErr:  "/m/c is not an rpc or action",
+		wantOutputErr: "/m/c is not an rpc or action",
+	}} {
+		e := mod.Find(tt.path)
+		if e == nil {
+			t.Errorf("%s not found", tt.path)
+			continue
+		}
+		// leafPath returns the path of the only leaf of io.
+		leafPath := func(io *Entry) string {
+			var paths []string
+			for _, c := range io.OrderedChildren() {
+				paths = append(paths, c.Path())
+			}
+			return strings.Join(paths, ", ")
+		}
+		in, err := e.RPCInput()
+		if diff := errdiff.Substring(err, tt.wantInputErr); diff != "" {
+			t.Errorf("%s: RPCInput: %s", tt.path, diff)
+		} else if err == nil {
+			if got := leafPath(in); got != tt.wantInput {
+				t.Errorf("%s: RPCInput children = %s, want %s", tt.path, got, tt.wantInput)
+			}
+			if in.Parent != e {
+				t.Errorf("%s: RPCInput parent is %s", tt.path, in.Parent.Path())
+			}
+		}
+		out, err := e.RPCOutput()
+		if diff := errdiff.Substring(err, tt.wantOutputErr); diff != "" {
+			t.Errorf("%s: RPCOutput: %s", tt.path, diff)
+		} else if err == nil {
+			if got := leafPath(out); got != tt.wantOutput {
+				t.Errorf("%s: RPCOutput children = %s, want %s", tt.path, got, tt.wantOutput)
+			}
+			if out.Parent != e {
+				t.Errorf("%s: RPCOutput parent is %s", tt.path, out.Parent.Path())
+			}
+		}
+		// The input and output are also the children of e named input
+		// and output.
+		if e.Dir["input"] != in {
+			t.Errorf("%s: Dir[input] is not the RPCInput", tt.path)
+		}
+		if e.Dir["output"] != out {
+			t.Errorf("%s: Dir[output] is not the RPCOutput", tt.path)
+		}
+	}
+}
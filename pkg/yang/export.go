@@ -0,0 +1,771 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements exporting a module, as compiled by Process, as the
+// text of a single YANG module in which groupings, typedefs and augments
+// have been expanded.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ExportYANG returns the YANG 1.1 text of the module name as it is after
+// Process has applied its augments, uses and deviations.  The groupings used
+// by the module are inlined, its types are written as their resolved built-in
+// types, and the nodes that other modules augment into it are shown in place.
+// The identities and features of the modules that augment it are defined by
+// the exported module, as the nodes that refer to them are now part of it.
+// Parsing and processing the result, with the modules it imports, yields an
+// equivalent tree of entries.
+//
+// Extension statements, typedefs, groupings and the if-feature statements of
+// uses and augment statements are not exported, and augmented nodes have the
+// namespace of the exported module.  Process must be called before
+// ExportYANG.
+func (ms *Modules) ExportYANG(name string) (string, error) {
+	m := ms.Modules[name]
+	if m == nil {
+		return "", fmt.Errorf("no such module: %s", name)
+	}
+	e := ToEntry(m)
+	if errs := e.GetErrors(); len(errs) > 0 {
+		return "", errs[0]
+	}
+	x := &exporter{
+		ms:           ms,
+		target:       m,
+		contributors: map[*Module]bool{},
+		merged:       map[*Module]bool{},
+		prefixes:     map[*Module]string{},
+		used:         map[string]bool{m.Prefix.Name: true},
+	}
+	x.findContributors(e)
+	for c := range x.contributors {
+		if c == m {
+			continue
+		}
+		imports, err := ms.AllImports(c)
+		if err != nil {
+			return "", err
+		}
+		for _, i := range imports {
+			if i.Name == m.Name {
+				x.merged[c] = true
+			}
+		}
+	}
+
+	// The body is built first, as it determines the modules that must be
+	// imported.
+	var body []*Statement
+	features, err := x.features()
+	if err != nil {
+		return "", err
+	}
+	body = append(body, features...)
+	identities, err := x.identities()
+	if err != nil {
+		return "", err
+	}
+	body = append(body, identities...)
+	for _, c := range e.OrderedChildren() {
+		body = append(body, x.entry(c, nil))
+	}
+	for _, sm := range append([]*Module{m}, m.Submodules()...) {
+		for _, a := range sm.Augment {
+			if s := x.augment(a); s != nil {
+				body = append(body, s)
+			}
+		}
+	}
+
+	stmt := newExportStatement("module", m.Name)
+	addExportStatement(stmt, "yang-version", "1.1")
+	addExportStatement(stmt, "namespace", m.Namespace.asString())
+	addExportStatement(stmt, "prefix", m.Prefix.Name)
+	var imported []*Module
+	for i := range x.prefixes {
+		imported = append(imported, i)
+	}
+	sort.Slice(imported, func(i, j int) bool { return imported[i].Name < imported[j].Name })
+	for _, i := range imported {
+		is := addExportStatement(stmt, "import", i.Name)
+		addExportStatement(is, "prefix", x.prefixes[i])
+	}
+	for _, f := range []struct {
+		keyword string
+		v       *Value
+	}{
+		{"organization", m.Organization},
+		{"contact", m.Contact},
+		{"description", m.Description},
+		{"reference", m.Reference},
+	} {
+		if f.v != nil {
+			addExportStatement(stmt, f.keyword, f.v.Name)
+		}
+	}
+	for _, r := range m.Revision {
+		rs := addExportStatement(stmt, "revision", r.Name)
+		if r.Description != nil {
+			addExportStatement(rs, "description", r.Description.Name)
+		}
+		if r.Reference != nil {
+			addExportStatement(rs, "reference", r.Reference.Name)
+		}
+	}
+	stmt.statements = append(stmt.statements, body...)
+
+	var b strings.Builder
+	if err := stmt.Write(&b, ""); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// An exporter holds the state of ExportYANG.
+type exporter struct {
+	ms     *Modules
+	target *Module
+	// contributors are the modules that define nodes of the exported tree.
+	contributors map[*Module]bool
+	// merged are the contributors, other than target, that import target,
+	// that is, the modules that augment it.  Their identities and features
+	// are exported as part of target.
+	merged map[*Module]bool
+	// prefixes are the prefixes of the modules that must be imported, and
+	// used the prefixes that have been allocated.
+	prefixes map[*Module]string
+	used     map[string]bool
+}
+
+// findContributors records the modules that define e and its descendants.
+func (x *exporter) findContributors(e *Entry) {
+	if e == nil {
+		return
+	}
+	if m := x.moduleOf(e.Node); m != nil {
+		x.contributors[m] = true
+	}
+	for _, c := range e.OrderedChildren() {
+		x.findContributors(c)
+	}
+}
+
+// moduleOf returns the module that defines n, or the module that includes
+// it if n is defined by a submodule.
+func (x *exporter) moduleOf(n Node) *Module {
+	if n == nil {
+		return nil
+	}
+	r := RootNode(n)
+	if r == nil {
+		return nil
+	}
+	if m := x.ms.Modules[moduleName(r)]; m != nil {
+		return m
+	}
+	return r
+}
+
+// resolve returns the module that prefix refers to in the context of n.
+func (x *exporter) resolve(n Node, prefix string) *Module {
+	if n == nil || RootNode(n) == nil {
+		return nil
+	}
+	m := FindModuleByPrefix(n, prefix)
+	if m == nil {
+		return nil
+	}
+	return x.moduleOf(m)
+}
+
+// importPrefix returns the prefix m is imported with, allocating one that
+// does not collide with any other if m has not been imported yet.
+func (x *exporter) importPrefix(m *Module) string {
+	if p, ok := x.prefixes[m]; ok {
+		return p
+	}
+	p := m.Prefix.Name
+	for i := 2; x.used[p]; i++ {
+		p = fmt.Sprintf("%s%d", m.Prefix.Name, i)
+	}
+	x.used[p] = true
+	x.prefixes[m] = p
+	return p
+}
+
+// qname rewrites the prefix of the qualified name qn, in the context of n,
+// to the prefix used by the exported module.  Data nodes of all contributors
+// are part of the exported module, while definitions, such as identities and
+// features, only are if they are defined by target or a merged module.  qn
+// is returned unchanged if it has no prefix or its prefix is unknown.
+func (x *exporter) qname(n Node, qn string, definition bool) string {
+	prefix, name := getPrefix(qn)
+	if prefix == "" {
+		return qn
+	}
+	m := x.resolve(n, prefix)
+	switch {
+	case m == nil:
+		return qn
+	case m == x.target, x.merged[m], !definition && x.contributors[m]:
+		return x.target.Prefix.Name + ":" + name
+	}
+	return x.importPrefix(m) + ":" + name
+}
+
+// path rewrites the prefixes of the steps of the schema node identifier p.
+func (x *exporter) path(n Node, p string) string {
+	steps := strings.Split(p, "/")
+	for i, step := range steps {
+		steps[i] = x.qname(n, step, false)
+	}
+	return strings.Join(steps, "/")
+}
+
+// features returns the feature statements of target and of the merged
+// modules.
+func (x *exporter) features() ([]*Statement, error) {
+	var stmts []*Statement
+	seen := map[string]*Feature{}
+	for _, m := range x.definingModules() {
+		for _, f := range m.Features() {
+			if o := seen[f.Name]; o != nil {
+				return nil, fmt.Errorf("%s: feature %s is also defined at %s", Source(f.Feature), f.Name, Source(o))
+			}
+			seen[f.Name] = f.Feature
+			s := newExportStatement("feature", f.Name)
+			for _, v := range f.IfFeature {
+				addExportStatement(s, "if-feature", x.ifFeature(f.Feature, v))
+			}
+			if f.Status != StatusCurrent {
+				addExportStatement(s, "status", f.Status.String())
+			}
+			if f.Description != "" {
+				addExportStatement(s, "description", f.Description)
+			}
+			stmts = append(stmts, s)
+		}
+	}
+	return stmts, nil
+}
+
+// identities returns the identity statements of target and of the merged
+// modules.
+func (x *exporter) identities() ([]*Statement, error) {
+	var stmts []*Statement
+	seen := map[string]*Identity{}
+	for _, m := range x.definingModules() {
+		for _, sm := range append([]*Module{m}, m.Submodules()...) {
+			for _, i := range sm.Identity {
+				if o := seen[i.Name]; o != nil {
+					return nil, fmt.Errorf("%s: identity %s is also defined at %s", Source(i), i.Name, Source(o))
+				}
+				seen[i.Name] = i
+				s := newExportStatement("identity", i.Name)
+				if i.Base != nil {
+					addExportStatement(s, "base", x.qname(i, i.Base.Name, true))
+				}
+				if i.Status != nil && i.Status.Name != "current" {
+					addExportStatement(s, "status", i.Status.Name)
+				}
+				if i.Description != nil {
+					addExportStatement(s, "description", i.Description.Name)
+				}
+				if i.Reference != nil {
+					addExportStatement(s, "reference", i.Reference.Name)
+				}
+				stmts = append(stmts, s)
+			}
+		}
+	}
+	return stmts, nil
+}
+
+// definingModules returns target followed by the merged modules, sorted by
+// name.
+func (x *exporter) definingModules() []*Module {
+	var merged []*Module
+	for m := range x.merged {
+		merged = append(merged, m)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+	return append([]*Module{x.target}, merged...)
+}
+
+// ifFeature rewrites the prefixes of the if-feature expression expr.
+func (x *exporter) ifFeature(n Node, expr string) string {
+	tokens := whenTokens(expr)
+	for i, t := range tokens {
+		switch t {
+		case "(", ")", "and", "or", "not":
+		default:
+			tokens[i] = x.qname(n, t, true)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// augment returns the augment statement of a, if a augments a module other
+// than target.  Augments of target itself are already part of its tree.
+func (x *exporter) augment(a *Augment) *Statement {
+	p := strings.TrimPrefix(a.Name, "/")
+	if i := strings.Index(p, "/"); i >= 0 {
+		p = p[:i]
+	}
+	prefix, _ := getPrefix(p)
+	if m := x.resolve(a, prefix); m == nil || m == x.target {
+		return nil
+	}
+	ae := ToEntry(a)
+	te := ae.Find(a.Name)
+	if te == nil {
+		return nil
+	}
+	s := newExportStatement("augment", x.path(a, a.Name))
+	if w, ok := whenValueOf(a); ok {
+		addExportStatement(s, "when", x.xpath(a, w.Name, false))
+	}
+	for _, v := range ifFeatures(a) {
+		addExportStatement(s, "if-feature", x.ifFeature(a, v.Name))
+	}
+	if a.Status != nil && a.Status.Name != "current" {
+		addExportStatement(s, "status", a.Status.Name)
+	}
+	if a.Description != nil {
+		addExportStatement(s, "description", a.Description.Name)
+	}
+	for _, k := range ae.orderedKeys() {
+		if c := te.Dir[k]; c != nil {
+			s.statements = append(s.statements, x.entry(c, a))
+		}
+	}
+	return s
+}
+
+// entry returns the statement of e.  The when statement of augment, if not
+// nil, is written by the augment statement rather than by e.
+func (x *exporter) entry(e *Entry, augment *Augment) *Statement {
+	var s *Statement
+	switch {
+	case e.RPC != nil && e.Parent != nil && e.Parent.Parent == nil:
+		s = newExportStatement("rpc", e.Name)
+	case e.RPC != nil:
+		s = newExportStatement("action", e.Name)
+	case e.Kind == InputEntry:
+		s = &Statement{Keyword: "input"}
+	case e.Kind == OutputEntry:
+		s = &Statement{Keyword: "output"}
+	case e.Kind == NotificationEntry:
+		s = newExportStatement("notification", e.Name)
+	case e.Kind == ChoiceEntry:
+		s = newExportStatement("choice", e.Name)
+	case e.Kind == CaseEntry:
+		s = newExportStatement("case", e.Name)
+	case e.Kind == AnyDataEntry:
+		s = newExportStatement("anydata", e.Name)
+	case e.Kind == AnyXMLEntry:
+		s = newExportStatement("anyxml", e.Name)
+	case e.IsList():
+		s = newExportStatement("list", e.Name)
+	case e.IsLeafList():
+		s = newExportStatement("leaf-list", e.Name)
+	case e.IsLeaf():
+		s = newExportStatement("leaf", e.Name)
+	default:
+		s = newExportStatement("container", e.Name)
+	}
+
+	if when := x.when(e, augment); when != "" {
+		addExportStatement(s, "when", when)
+	}
+	for _, v := range ifFeatures(e.Node) {
+		addExportStatement(s, "if-feature", x.ifFeature(e.Node, v.Name))
+	}
+	for _, mc := range e.MustConditions() {
+		n := e.Node
+		if mc.Must != nil {
+			n = mc.Must
+		}
+		m := addExportStatement(s, "must", x.xpath(n, mc.XPath, false))
+		addConstraintError(m, mc.Error)
+	}
+
+	switch s.Keyword {
+	case "leaf", "leaf-list":
+		if e.Type != nil {
+			s.statements = append(s.statements, x.yangType(e.Node, e.Type))
+			units := e.Units
+			if units == "" {
+				units = e.Type.Units
+			}
+			if units != "" {
+				addExportStatement(s, "units", units)
+			}
+			values, fromType := e.DefaultValues()
+			n := e.Node
+			if fromType && e.Type.Base != nil && RootNode(e.Type.Base) != nil {
+				n = e.Type.Base
+			}
+			for _, v := range values {
+				addExportStatement(s, "default", x.defaultValue(n, e.Type, v))
+			}
+		}
+	case "list":
+		if e.Key != "" {
+			addExportStatement(s, "key", e.Key)
+		}
+		for _, u := range e.ListAttr.Unique {
+			var paths []string
+			for _, p := range u {
+				paths = append(paths, x.path(e.Node, p))
+			}
+			addExportStatement(s, "unique", strings.Join(paths, " "))
+		}
+	case "container":
+		if e.Presence != nil {
+			addExportStatement(s, "presence", e.Presence.Name)
+		}
+	case "choice":
+		if e.Default != "" {
+			addExportStatement(s, "default", e.Default)
+		}
+	}
+	switch s.Keyword {
+	case "leaf", "leaf-list", "list", "container", "choice", "anydata", "anyxml":
+		if e.Config != TSUnset && !e.inOperation() {
+			addExportStatement(s, "config", fmt.Sprint(e.Config.Value()))
+		}
+	}
+	switch s.Keyword {
+	case "leaf", "choice", "anydata", "anyxml":
+		if e.Mandatory != TSUnset {
+			addExportStatement(s, "mandatory", fmt.Sprint(e.Mandatory.Value()))
+		}
+	}
+	if la := e.ListAttr; la != nil {
+		if la.MinElements != nil {
+			addExportStatement(s, "min-elements", la.MinElements.Name)
+		}
+		if la.MaxElements != nil {
+			addExportStatement(s, "max-elements", la.MaxElements.Name)
+		}
+		if la.OrderedBy != nil {
+			addExportStatement(s, "ordered-by", la.OrderedBy.Name)
+		}
+	}
+	if e.Status != StatusCurrent {
+		addExportStatement(s, "status", e.Status.String())
+	}
+	if e.Description != "" {
+		addExportStatement(s, "description", e.Description)
+	}
+	if r := e.reference(); r != "" {
+		addExportStatement(s, "reference", r)
+	}
+
+	for _, c := range e.OrderedChildren() {
+		s.statements = append(s.statements, x.entry(c, augment))
+	}
+	return s
+}
+
+// inOperation reports whether e is within an rpc, action or notification,
+// where config statements are not allowed.
+func (e *Entry) inOperation() bool {
+	for p := e; p != nil; p = p.Parent {
+		if p.RPC != nil || p.Kind == InputEntry || p.Kind == OutputEntry || p.Kind == NotificationEntry {
+			return true
+		}
+	}
+	return false
+}
+
+// when returns the when expression of e, combining those it inherits from
+// the uses and augment statements that added it to the tree with its own.
+// The inherited expressions are evaluated in the context of the parent of
+// e, so they are rewritten to be relative to e itself.  The when statement
+// of augment is omitted.
+func (x *exporter) when(e *Entry, augment *Augment) string {
+	var exprs []string
+	for _, w := range e.WhenConditions() {
+		if augment != nil && w.Node == Node(augment) {
+			continue
+		}
+		inherited := w.Node != e.Node && e.Kind != ChoiceEntry && e.Kind != CaseEntry
+		exprs = append(exprs, x.xpath(w.Node, w.XPath, inherited))
+	}
+	switch len(exprs) {
+	case 0:
+		return ""
+	case 1:
+		return exprs[0]
+	}
+	return "(" + strings.Join(exprs, ") and (") + ")"
+}
+
+// yangType returns the type statement of y, the type of a node defined by n.
+func (x *exporter) yangType(n Node, y *YangType) *Statement {
+	s := newExportStatement("type", y.Kind.String())
+	switch y.Kind {
+	case Yint8, Yint16, Yint32, Yint64, Yuint8, Yuint16, Yuint32, Yuint64, Ydecimal64:
+		if y.Kind == Ydecimal64 {
+			addExportStatement(s, "fraction-digits", fmt.Sprint(y.FractionDigits))
+		}
+		if len(y.Range) > 0 && !y.Range.Equal(y.fullRange()) && !(len(y.Range) == 1 && y.Range[0].Min.Kind == MinNumber && y.Range[0].Max.Kind == MaxNumber) {
+			rs := addExportStatement(s, "range", y.Range.String())
+			addConstraintError(rs, y.RangeError())
+		}
+	case Ystring, Ybinary:
+		if len(y.Length) > 0 {
+			ls := addExportStatement(s, "length", y.Length.String())
+			addConstraintError(ls, y.LengthError())
+		}
+		for _, p := range y.Pattern {
+			ps := addExportStatement(s, "pattern", p)
+			addConstraintError(ps, y.PatternError(p))
+		}
+	case Yenum:
+		if len(y.enumValues) > 0 {
+			for _, ev := range y.enumValues {
+				es := addExportStatement(s, "enum", ev.Name)
+				addExportStatement(es, "value", fmt.Sprint(ev.Value))
+				if ev.Description != "" {
+					addExportStatement(es, "description", ev.Description)
+				}
+				if ev.Reference != "" {
+					addExportStatement(es, "reference", ev.Reference)
+				}
+			}
+		} else if y.Enum != nil {
+			for _, name := range y.Enum.Names() {
+				es := addExportStatement(s, "enum", name)
+				addExportStatement(es, "value", fmt.Sprint(y.Enum.Value(name)))
+			}
+		}
+	case Ybits:
+		bits, _ := y.Bits()
+		for _, b := range bits {
+			bs := addExportStatement(s, "bit", b.Name)
+			addExportStatement(bs, "position", fmt.Sprint(b.Position))
+			if b.Description != "" {
+				addExportStatement(bs, "description", b.Description)
+			}
+		}
+	case Yidentityref:
+		if i := y.IdentityBase; i != nil {
+			name := i.Name
+			if m := x.moduleOf(i); m != nil {
+				if m == x.target || x.merged[m] {
+					name = x.target.Prefix.Name + ":" + name
+				} else {
+					name = x.importPrefix(m) + ":" + name
+				}
+			}
+			addExportStatement(s, "base", name)
+		}
+	case Yleafref:
+		if y.Base != nil && y.Base.Path != nil && RootNode(y.Base) != nil {
+			n = y.Base
+		}
+		addExportStatement(s, "path", x.xpath(n, y.Path, false))
+		if y.OptionalInstance {
+			addExportStatement(s, "require-instance", "false")
+		}
+	case YinstanceIdentifier:
+		if y.OptionalInstance {
+			addExportStatement(s, "require-instance", "false")
+		}
+	case Yunion:
+		for _, t := range y.Type {
+			s.statements = append(s.statements, x.yangType(n, t))
+		}
+	}
+	return s
+}
+
+// defaultValue rewrites the prefix of the default value v of a node of type
+// y, in the context of n, if it is an identity.
+func (x *exporter) defaultValue(n Node, y *YangType, v string) string {
+	if !hasIdentityref(y) {
+		return v
+	}
+	if prefix, _ := getPrefix(v); prefix == "" || x.resolve(n, prefix) == nil {
+		return v
+	}
+	return x.qname(n, v, true)
+}
+
+// hasIdentityref reports whether y is, or is a union with a member that is,
+// an identityref.
+func hasIdentityref(y *YangType) bool {
+	if y.Kind == Yidentityref {
+		return true
+	}
+	for _, t := range y.Type {
+		if hasIdentityref(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// xpath rewrites the prefixes of the names, and of the quoted identities, in
+// the XPath expression expr, which is evaluated in the context of n.  If
+// inherited is true, expr is rewritten to be evaluated in the context of a
+// child of its original context node, as is needed for the when statements
+// that are inherited from uses and augment statements.  An expression that
+// is not valid XPath is returned unchanged.
+func (x *exporter) xpath(n Node, expr string, inherited bool) string {
+	tokens, err := tokenizeXPath(expr)
+	if err != nil {
+		return expr
+	}
+	var b strings.Builder
+	depth := 0       // the depth of predicates
+	operand := false // the previous token ends an operand
+	step := false    // the next name continues a location path
+	// start writes ../ if a location path relative to the context node
+	// starts at the current token.
+	start := func() {
+		if inherited && depth == 0 && !operand && !step {
+			b.WriteString("../")
+		}
+	}
+	end := 0 // the end of the previous token in expr
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		// Keep the white space between the tokens.
+		b.WriteString(expr[end:t.pos])
+		end = t.pos + len(t.text)
+		switch t.kind {
+		case xpLiteral:
+			lit := t.text
+			if v := lit[1 : len(lit)-1]; isQName(v) {
+				if prefix, _ := getPrefix(v); prefix != "" && x.resolve(n, prefix) != nil {
+					lit = lit[:1] + x.qname(n, v, true) + lit[:1]
+				}
+			}
+			b.WriteString(lit)
+			operand, step = true, false
+		case xpNumber:
+			b.WriteString(t.text)
+			operand, step = true, false
+		case xpFunction:
+			if t.text == "current" && inherited && i+2 < len(tokens) && tokens[i+2].text == ")" {
+				b.WriteString("current()/..")
+				end = tokens[i+2].pos + 1
+				i += 2
+				operand, step = true, false
+				break
+			}
+			b.WriteString(t.text)
+			operand, step = false, false
+		case xpNodeType:
+			b.WriteString(t.text)
+			operand, step = false, false
+		case xpAxis:
+			start()
+			b.WriteString(t.text)
+			operand, step = false, true
+		case xpName:
+			start()
+			if t.text == "*" {
+				b.WriteString(t.text)
+			} else {
+				b.WriteString(x.qname(n, t.text, false))
+			}
+			operand, step = true, false
+		case xpOperator:
+			b.WriteString(t.text)
+			switch t.text {
+			case "/", "//":
+				operand, step = false, true
+			default:
+				operand, step = false, false
+			}
+		case xpPunct:
+			switch t.text {
+			case ".", "..":
+				start()
+				operand, step = true, false
+			case "@":
+				start()
+				operand, step = false, true
+			case "::", "$":
+				operand, step = false, true
+			case "[":
+				depth++
+				operand, step = false, false
+			case "]":
+				depth--
+				operand, step = true, false
+			case ")":
+				operand, step = true, false
+			default:
+				operand, step = false, false
+			}
+			b.WriteString(t.text)
+		}
+	}
+	b.WriteString(expr[end:])
+	return b.String()
+}
+
+// ifFeatures returns the if-feature statements of n.
+func ifFeatures(n Node) []*Value {
+	if n == nil {
+		return nil
+	}
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if f := v.Elem().FieldByName("IfFeature"); f.IsValid() {
+		if vs, ok := f.Interface().([]*Value); ok {
+			return vs
+		}
+	}
+	return nil
+}
+
+// addConstraintError adds the error-message and error-app-tag statements of
+// ce to s.
+func addConstraintError(s *Statement, ce ConstraintError) {
+	if ce.Message != "" {
+		addExportStatement(s, "error-message", ce.Message)
+	}
+	if ce.AppTag != "" {
+		addExportStatement(s, "error-app-tag", ce.AppTag)
+	}
+}
+
+// newExportStatement returns a statement with keyword and argument arg.
+func newExportStatement(keyword, arg string) *Statement {
+	return &Statement{Keyword: keyword, HasArgument: true, Argument: arg}
+}
+
+// addExportStatement adds a substatement with keyword and argument arg to s
+// and returns it.
+func addExportStatement(s *Statement, keyword, arg string) *Statement {
+	ss := newExportStatement(keyword, arg)
+	s.statements = append(s.statements, ss)
+	return ss
+}
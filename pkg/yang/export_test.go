@@ -0,0 +1,197 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExportYANG(t *testing.T) {
+	sources := map[string]string{
+		"c.yang": `module c {
+  prefix "c";
+  namespace "urn:c";
+  identity base-id;
+  grouping addr {
+    leaf address {
+      type string { pattern "[0-9.]+"; }
+    }
+    leaf kind {
+      type identityref { base c:base-id; }
+    }
+  }
+}`,
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  import c { prefix cc; }
+  organization "example";
+  revision 2020-01-01 { description "initial"; }
+  typedef percent {
+    type uint8 { range "0..100"; }
+    default 50;
+  }
+  identity eth { base cc:base-id; }
+  container top {
+    leaf load { type percent; }
+    list server {
+      key "name";
+      leaf name { type string; }
+      uses cc:addr { when "../name != 'x'"; }
+      leaf-list tag {
+        type string;
+        ordered-by user;
+      }
+    }
+    choice mode {
+      leaf auto { type empty; }
+      case manual {
+        leaf value { type int32; mandatory true; }
+      }
+    }
+    leaf ref {
+      type leafref { path "../server/name"; }
+    }
+    leaf state {
+      config false;
+      type string;
+    }
+  }
+  rpc reset {
+    input {
+      leaf target { type string; }
+    }
+  }
+}`,
+		"b.yang": `module b {
+  prefix "b";
+  namespace "urn:b";
+  import a { prefix x; }
+  feature bf;
+  identity special { base x:eth; }
+  augment "/x:top" {
+    when "x:load > 10";
+    leaf extra {
+      if-feature bf;
+      type identityref { base x:eth; }
+      default b:special;
+    }
+  }
+}`,
+	}
+
+	ms := NewModules()
+	for _, name := range []string{"c.yang", "a.yang", "b.yang"} {
+		if err := ms.Parse(sources[name], name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	got, err := ms.ExportYANG("a")
+	if err != nil {
+		t.Fatalf("ExportYANG: %v", err)
+	}
+	for _, want := range []string{
+		"import \"c\" {\n\t\tprefix \"c\";\n\t}",
+		`feature "bf";`,
+		"identity \"special\" {\n\t\tbase \"a:eth\";\n\t}",
+		`when "../../name != 'x'";`,
+		`when "../a:load > 10";`,
+		`if-feature "bf";`,
+		`base "c:base-id";`,
+		`default "a:special";`,
+		`range "0..100";`,
+		`default "50";`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ExportYANG did not return %s, got:\n%s", want, got)
+		}
+	}
+
+	// Process resets the entries of all modules, so the summary of the
+	// original module must be taken first.
+	want := exportSummary(ToEntry(ms.Modules["a"]))
+	rs := NewModules()
+	if err := rs.Parse(sources["c.yang"], "c.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Parse(got, "a.yang"); err != nil {
+		t.Fatalf("cannot parse exported module: %v\n%s", err, got)
+	}
+	if errs := rs.Process(); errs != nil {
+		t.Fatalf("cannot process exported module: %v\n%s", errs, got)
+	}
+	if diff := cmp.Diff(want, exportSummary(ToEntry(rs.Modules["a"]))); diff != "" {
+		t.Errorf("exported module differs (-want, +got):\n%s", diff)
+	}
+
+	if _, err := rs.ExportYANG("nosuch"); err == nil {
+		t.Error("ExportYANG of unknown module did not fail")
+	}
+}
+
+// exportSummary returns the paths of e and its descendants along with their
+// kind, type, defaults and config.  The prefixes of the defaults are removed,
+// as the identities of the modules that augment e are exported as part of it.
+func exportSummary(e *Entry) []string {
+	var s []string
+	var walk func(e *Entry)
+	walk = func(e *Entry) {
+		if e == nil {
+			return
+		}
+		line := fmt.Sprintf("%s %s config=%v", e.Path(), e.Kind, e.IsConfig())
+		if e.Type != nil {
+			var values []string
+			defaults, _ := e.DefaultValues()
+			for _, v := range defaults {
+				_, name := getPrefix(v)
+				values = append(values, name)
+			}
+			line += fmt.Sprintf(" type=%s default=%v", e.Type.Kind, values)
+		}
+		s = append(s, line)
+		for _, c := range e.OrderedChildren() {
+			walk(c)
+		}
+	}
+	walk(e)
+	return s
+}
+
+func TestExportInheritedXPath(t *testing.T) {
+	x := &exporter{}
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"../name != 'x'", "../../name != 'x'"},
+		{". = 5", "../. = 5"},
+		{"current()/a = b", "current()/../a = ../b"},
+		{"count(x[y = 1]) > 0", "count(../x[y = 1]) > 0"},
+		{"/t:top/b and c", "/t:top/b and ../c"},
+		{"a * 2 div b", "../a * 2 div ../b"},
+		{"derived-from(t:type, 'x:eth')", "derived-from(../t:type, 'x:eth')"},
+	} {
+		if got := x.xpath(nil, tt.in, true); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
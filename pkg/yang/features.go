@@ -0,0 +1,267 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the introspection of the features of a module, and
+// the resolution of the features that are enabled given the if-feature
+// statements of the features.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A FeatureInfo describes a feature defined by a module.
+type FeatureInfo struct {
+	Name        string
+	Description string
+	Status      Status
+	// IfFeature are the arguments of the if-feature statements of the
+	// feature, which must all be true for the feature to be enabled.
+	IfFeature []string
+	Feature   *Feature
+}
+
+// Features returns the features defined by s and by the submodules it
+// includes, in the order they are defined.
+func (s *Module) Features() []FeatureInfo {
+	var features []FeatureInfo
+	for _, m := range append([]*Module{s}, s.Submodules()...) {
+		for _, f := range m.Feature {
+			st, _ := statusValue(f.Status)
+			fi := FeatureInfo{
+				Name:        f.Name,
+				Description: f.Description.asString(),
+				Status:      st,
+				Feature:     f,
+			}
+			for _, v := range f.IfFeature {
+				fi.IfFeature = append(fi.IfFeature, v.Name)
+			}
+			features = append(features, fi)
+		}
+	}
+	return features
+}
+
+// ResolveFeatures returns the features that are effectively enabled when the
+// features named by enabled are enabled, sorted.  Features are named as
+// module:feature, where module is the name of the module that defines the
+// feature.  A feature is effectively enabled if it is enabled and the
+// if-feature statements of the feature are all true, as described in section
+// 7.20.2 of RFC 7950.  The if-feature statements of every feature of the
+// modules of ms are checked, whether or not the feature is enabled, and an
+// error is returned if a feature named by enabled or by an if-feature
+// statement does not exist, if an if-feature statement is not a valid
+// expression, or if a feature depends on itself.  The modules of ms must have
+// been processed.
+func (ms *Modules) ResolveFeatures(enabled []string) ([]string, error) {
+	r := &featureResolver{
+		ms:      ms,
+		enabled: map[*Feature]bool{},
+		state:   map[*Feature]featureState{},
+	}
+	for _, name := range enabled {
+		module, feature := getPrefix(name)
+		f, err := r.find(module, feature)
+		if err != nil {
+			return nil, err
+		}
+		r.enabled[f] = true
+	}
+
+	for _, name := range ms.moduleNames() {
+		for _, fi := range ms.Modules[name].Features() {
+			if _, err := r.resolve(fi.Feature); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var names []string
+	for f := range r.enabled {
+		on, err := r.resolve(f)
+		if err != nil {
+			return nil, err
+		}
+		if on {
+			names = append(names, moduleName(f)+":"+f.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// A featureState is the state of the resolution of a feature.
+type featureState int
+
+const (
+	featureUnresolved = featureState(iota)
+	featureResolving
+	featureOff
+	featureOn
+)
+
+// A featureResolver resolves whether features are effectively enabled.
+type featureResolver struct {
+	ms      *Modules
+	enabled map[*Feature]bool
+	state   map[*Feature]featureState
+}
+
+// find returns the feature named feature defined by the named module.
+func (r *featureResolver) find(module, feature string) (*Feature, error) {
+	m := r.ms.Modules[module]
+	if m == nil {
+		return nil, fmt.Errorf("no such module: %s", module)
+	}
+	for _, fi := range m.Features() {
+		if fi.Name == feature {
+			return fi.Feature, nil
+		}
+	}
+	return nil, fmt.Errorf("module %s has no feature %s", module, feature)
+}
+
+// resolve returns whether f is effectively enabled.
+func (r *featureResolver) resolve(f *Feature) (bool, error) {
+	switch r.state[f] {
+	case featureResolving:
+		return false, fmt.Errorf("%s: feature %s depends on itself", Source(f), f.Name)
+	case featureOff:
+		return false, nil
+	case featureOn:
+		return true, nil
+	}
+	// The if-feature statements of a feature that is not enabled are
+	// still checked.
+	r.state[f] = featureResolving
+	on := r.enabled[f]
+	for _, v := range f.IfFeature {
+		p := &ifFeatureParser{r: r, n: v, tokens: whenTokens(v.Name)}
+		ok, err := p.expr()
+		if err == nil && len(p.tokens) > 0 {
+			err = p.errorf("unexpected %q", p.tokens[0])
+		}
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			on = false
+		}
+	}
+	if on {
+		r.state[f] = featureOn
+	} else {
+		r.state[f] = featureOff
+	}
+	return on, nil
+}
+
+// An ifFeatureParser evaluates the tokens of the argument of n, an
+// if-feature statement, as it parses them.  Errors in the if-feature
+// statement are returned from p.errorf, while the errors of the features it
+// names are returned as is.
+type ifFeatureParser struct {
+	r      *featureResolver
+	n      Node
+	tokens []string
+}
+
+// errorf returns an error, formatted from format and v, in the if-feature
+// statement of p.
+func (p *ifFeatureParser) errorf(format string, v ...interface{}) error {
+	return fmt.Errorf("%s: invalid if-feature %q: %s", Source(p.n), p.n.NName(), fmt.Sprintf(format, v...))
+}
+
+// next removes and returns the next token, or "" if there are none.
+func (p *ifFeatureParser) next() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	t := p.tokens[0]
+	p.tokens = p.tokens[1:]
+	return t
+}
+
+// peek returns the next token, or "" if there are none.
+func (p *ifFeatureParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+// expr parses term *("or" term).  All the terms are parsed, and so checked,
+// even once the value of the expression is known.
+func (p *ifFeatureParser) expr() (bool, error) {
+	v, err := p.term()
+	for err == nil && p.peek() == "or" {
+		p.next()
+		var t bool
+		t, err = p.term()
+		v = v || t
+	}
+	return v, err
+}
+
+// term parses factor *("and" factor).
+func (p *ifFeatureParser) term() (bool, error) {
+	v, err := p.factor()
+	for err == nil && p.peek() == "and" {
+		p.next()
+		var f bool
+		f, err = p.factor()
+		v = v && f
+	}
+	return v, err
+}
+
+// factor parses "not" factor, "(" expr ")" or the name of a feature.
+func (p *ifFeatureParser) factor() (bool, error) {
+	switch t := p.next(); t {
+	case "":
+		return false, p.errorf("missing feature name")
+	case "not":
+		v, err := p.factor()
+		return !v, err
+	case "(":
+		v, err := p.expr()
+		if err != nil {
+			return false, err
+		}
+		if t := p.next(); t != ")" {
+			return false, p.errorf("missing )")
+		}
+		return v, nil
+	case ")", "and", "or":
+		return false, p.errorf("unexpected %q", t)
+	default:
+		prefix, name := getPrefix(t)
+		if strings.ContainsAny(name, ":") || name == "" {
+			return false, p.errorf("bad feature name %q", t)
+		}
+		m := FindModuleByPrefix(p.n, prefix)
+		if m == nil {
+			return false, p.errorf("unknown prefix %s", prefix)
+		}
+		f, err := p.r.find(moduleName(m), name)
+		if err != nil {
+			return false, p.errorf("%v", err)
+		}
+		return p.r.resolve(f)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+// featureModules are the modules used to test features.
+var featureModules = map[string]string{
+	"base.yang": `module base {
+  prefix "b";
+  namespace "urn:b";
+  feature shared;
+}`,
+	"m.yang": `module m {
+  prefix "m";
+  namespace "urn:m";
+  import base { prefix bp; }
+  include s;
+  feature a { description "feature a"; }
+  feature b { if-feature a; status deprecated; }
+  feature c { if-feature "a and (not b or bp:shared)"; }
+  feature d { if-feature b; if-feature c; }
+}`,
+	"s.yang": `submodule s {
+  belongs-to m { prefix "m"; }
+  feature e { if-feature "m:a or d"; }
+}`,
+}
+
+func TestFeatures(t *testing.T) {
+	ms := NewModules()
+	for name, in := range featureModules {
+		if err := ms.Parse(in, name); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	want := []FeatureInfo{
+		{Name: "a", Description: "feature a"},
+		{Name: "b", Status: StatusDeprecated, IfFeature: []string{"a"}},
+		{Name: "c", IfFeature: []string{"a and (not b or bp:shared)"}},
+		{Name: "d", IfFeature: []string{"b", "c"}},
+		{Name: "e", IfFeature: []string{"m:a or d"}},
+	}
+	if diff := cmp.Diff(want, ms.Modules["m"].Features(), cmpopts.IgnoreFields(FeatureInfo{}, "Feature")); diff != "" {
+		t.Errorf("Features (-want, +got):\n%s", diff)
+	}
+}
+
+func TestResolveFeatures(t *testing.T) {
+	tests := []struct {
+		desc       string
+		extra      string // a feature added to module m
+		enabled    []string
+		want       []string
+		wantErrSub string
+	}{{
+		desc: "none",
+	}, {
+		desc:    "dependency not enabled",
+		enabled: []string{"m:b", "m:c", "m:e"},
+	}, {
+		desc:    "dependencies enabled",
+		enabled: []string{"m:a", "m:b", "m:d"},
+		want:    []string{"m:a", "m:b"},
+	}, {
+		desc:    "expression",
+		enabled: []string{"m:a", "m:b", "m:c", "base:shared"},
+		want:    []string{"base:shared", "m:a", "m:b", "m:c"},
+	}, {
+		desc:    "all",
+		enabled: []string{"m:a", "m:b", "m:c", "m:d", "m:e", "base:shared"},
+		want:    []string{"base:shared", "m:a", "m:b", "m:c", "m:d", "m:e"},
+	}, {
+		desc:    "submodule",
+		enabled: []string{"m:a", "m:e"},
+		want:    []string{"m:a", "m:e"},
+	}, {
+		desc:       "unknown feature",
+		enabled:    []string{"m:z"},
+		wantErrSub: "module m has no feature z",
+	}, {
+		desc:       "unknown module",
+		enabled:    []string{"x:a"},
+		wantErrSub: "no such module: x",
+	}, {
+		desc:       "self dependency",
+		extra:      `feature loop { if-feature "not loop"; }`,
+		enabled:    []string{"m:loop"},
+		wantErrSub: "feature loop depends on itself",
+	}, {
+		desc:       "bad expression",
+		extra:      `feature bad { if-feature "a and"; }`,
+		enabled:    []string{"m:bad"},
+		wantErrSub: `invalid if-feature "a and": missing feature name`,
+	}, {
+		desc:       "unknown prefix",
+		extra:      `feature bad { if-feature "x:a"; }`,
+		enabled:    []string{"m:bad"},
+		wantErrSub: "unknown prefix x",
+	}, {
+		desc:       "unknown feature of a feature not enabled",
+		extra:      `feature bad { if-feature "nosuch"; }`,
+		enabled:    []string{"m:a"},
+		wantErrSub: "module m has no feature nosuch",
+	}, {
+		desc:       "bad expression of a feature not enabled",
+		extra:      `feature bad { if-feature "a or"; }`,
+		wantErrSub: `invalid if-feature "a or": missing feature name`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for name, in := range featureModules {
+				if name == "m.yang" {
+					in = in[:len(in)-1] + tt.extra + "}"
+				}
+				if err := ms.Parse(in, name); err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+			}
+			if errs := ms.Process(); errs != nil {
+				t.Fatalf("Process: %v", errs)
+			}
+			got, err := ms.ResolveFeatures(tt.enabled)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("ResolveFeatures: %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ResolveFeatures (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -69,6 +69,72 @@ func PathsWithModules(root string) (paths []string, err error) {
 	return
 }
 
+// A ModuleReader reads the YANG source of modules and submodules.  A Modules
+// uses a ModuleReader, set by SetModuleReader, to read the modules and
+// submodules that are imported or included by the modules it contains.
+type ModuleReader interface {
+	// ReadModule returns the YANG source of the named module or
+	// submodule.  If revision is not empty, the source of that revision
+	// should be returned if it is available; otherwise the latest
+	// revision is returned.
+	ReadModule(name, revision string) ([]byte, error)
+}
+
+// PathReader is a ModuleReader that reads modules from the directories in
+// Path, in the same way as Modules.Read.  It is the default ModuleReader
+// of a Modules.
+type PathReader struct{}
+
+// ReadModule implements ModuleReader.  The file name@revision.yang is read
+// in preference to name.yang if revision is not empty.
+func (PathReader) ReadModule(name, revision string) ([]byte, error) {
+	if revision != "" {
+		if _, data, err := findFile(name + "@" + revision); err == nil {
+			return []byte(data), nil
+		}
+	}
+	_, data, err := findFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+// A MapReader is a ModuleReader that reads modules from the map, such as the
+// YANG sources of the modules of a test.  Each key is the file name of the
+// YANG source it maps to, name.yang or name@revision.yang, possibly within a
+// directory, or the name of the module or submodule.
+type MapReader map[string]string
+
+// ReadModule implements ModuleReader.  The source of name@revision is read in
+// preference to that of name if revision is not empty.  Otherwise, the source
+// of name is read if it is in r, and if it is not, the latest revision of name
+// in r is read.
+func (r MapReader) ReadModule(name, revision string) ([]byte, error) {
+	sources := map[string]string{}
+	var revisions []string
+	for k, v := range r {
+		k = strings.TrimSuffix(filepath.Base(k), ".yang")
+		sources[k] = v
+		if strings.HasPrefix(k, name+"@") {
+			revisions = append(revisions, k)
+		}
+	}
+	if revision != "" {
+		if data, ok := sources[name+"@"+revision]; ok {
+			return []byte(data), nil
+		}
+	}
+	if data, ok := sources[name]; ok {
+		return []byte(data), nil
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("no such module: %s", name)
+	}
+	sort.Strings(revisions)
+	return []byte(sources[revisions[len(revisions)-1]]), nil
+}
+
 // readFile makes testing of findFile easier.
 var readFile = ioutil.ReadFile
 
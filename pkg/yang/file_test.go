@@ -112,3 +112,46 @@ func TestScanForPathsAndAddModules(t *testing.T) {
 	}
 
 }
+
+func TestPathReader(t *testing.T) {
+	defer testPathReset()
+	// disable any readFile or scanDir mock setup by other tests
+	readFile = ioutil.ReadFile
+	scanDir = findInDir
+
+	dir, err := ioutil.TempDir("", "goyang-reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, text := range map[string]string{
+		"reader.yang":            "latest",
+		"reader@2019-01-01.yang": "older",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	AddPath(dir)
+
+	for _, tt := range []struct {
+		revision string
+		want     string
+	}{
+		{"", "latest"},
+		{"2019-01-01", "older"},
+		{"2018-01-01", "latest"},
+	} {
+		data, err := (PathReader{}).ReadModule("reader", tt.revision)
+		if err != nil {
+			t.Errorf("ReadModule(reader, %q): %v", tt.revision, err)
+			continue
+		}
+		if got := string(data); got != tt.want {
+			t.Errorf("ReadModule(reader, %q): got %q, want %q", tt.revision, got, tt.want)
+		}
+	}
+	if _, err := (PathReader{}).ReadModule("not-a-module", ""); err == nil {
+		t.Errorf("ReadModule(not-a-module): got no error")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the fingerprinting of Entry trees and modules.
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"sort"
+)
+
+// Hash returns the SHA-256 hash of the canonical form of the Entry tree
+// rooted at e.  Two trees have the same hash if they have the same schema,
+// regardless of the order the nodes were defined in or the files they were
+// read from, so the hash can be used to detect whether a model has changed.
+//
+// The canonical form of an entry consists of its name, its kind (the keyword
+// of the statement it was defined by, such as container, leaf-list or rpc),
+// its config and mandatory states, its default, units, list key, min and max
+// elements and ordered-by, its type, and the canonical forms of its children,
+// which include the input and output of an rpc or action, in order of their
+// names.  The canonical form of a type consists of its name, its kind, its
+// restrictions, its enums and bits with their values, its identity base and
+// the canonical forms of the members of a union.  Descriptions, references,
+// extensions and the locations of the statements are not part of the
+// canonical form.  The canonical form is encoded with explicit lengths and
+// fixed byte orders, so the hash does not depend on the Go version or the
+// platform.
+func Hash(e *Entry) [32]byte {
+	h := &entryHasher{h: sha256.New()}
+	h.entry(e)
+	var sum [32]byte
+	copy(sum[:], h.h.Sum(nil))
+	return sum
+}
+
+// HashModules returns the Hash of the Entry tree of each module of ms, keyed
+// by module name.  Only the latest revision of each module is hashed.  The
+// modules of ms must have been processed.
+func HashModules(ms *Modules) map[string][32]byte {
+	hashes := map[string][32]byte{}
+	for _, name := range ms.moduleNames() {
+		hashes[name] = Hash(ToEntry(ms.Modules[name]))
+	}
+	return hashes
+}
+
+// Fingerprint returns the SHA-256 hash, as a hexadecimal string, of the name,
+// namespace and latest revision of m followed by the keyword and argument of
+// each statement of m, in the order they were read.  Comments, whitespace and
+// the quoting of arguments are not kept by the parser, so modules whose text
+// only differs in these have the same fingerprint.  The order of the
+// statements is kept as in YANG it is significant, such as for the order of
+// data nodes.  Unlike Hash, Fingerprint does not depend on the modules that m
+// imports and m does not need to have been processed.
+func (m *Module) Fingerprint() string {
+	h := &entryHasher{h: sha256.New()}
+	h.string(m.Name)
+	h.value(m.Namespace)
+	h.string(m.Current())
+	h.statement(m.Source)
+	return hex.EncodeToString(h.h.Sum(nil))
+}
+
+// An entryHasher writes the canonical form of an Entry tree to a hash.
+type entryHasher struct {
+	h hash.Hash
+}
+
+// int writes n to the hash as 8 bytes in big-endian order.
+func (h *entryHasher) int(n int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	h.h.Write(b[:])
+}
+
+// string writes the length of s followed by s to the hash.
+func (h *entryHasher) string(s string) {
+	h.int(int64(len(s)))
+	h.h.Write([]byte(s))
+}
+
+// value writes the name of v to the hash, or an empty string if v is nil.
+func (h *entryHasher) value(v *Value) {
+	h.string(v.asString())
+}
+
+// entry writes the canonical form of e to the hash.  A nil entry is written
+// as an empty name.
+func (h *entryHasher) entry(e *Entry) {
+	if e == nil {
+		h.string("")
+		return
+	}
+	h.string(e.Name)
+	kind := e.Kind.String()
+	if e.Node != nil {
+		kind = e.Node.Kind()
+	}
+	h.string(kind)
+	h.int(int64(e.Config))
+	h.int(int64(e.Mandatory))
+	h.string(e.Default)
+	h.string(e.Units)
+	h.string(e.Key)
+	if la := e.ListAttr; la != nil {
+		h.string("list")
+		h.value(la.MinElements)
+		h.value(la.MaxElements)
+		h.value(la.OrderedBy)
+	} else {
+		h.string("")
+	}
+	h.yangType(e.Type)
+
+	names := make([]string, 0, len(e.Dir))
+	for name := range e.Dir {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h.int(int64(len(names)))
+	for _, name := range names {
+		h.entry(e.Dir[name])
+	}
+	if e.RPC != nil {
+		h.string("rpc")
+	} else {
+		h.string("")
+	}
+}
+
+// yangType writes the canonical form of y to the hash.  A nil type is written
+// as an empty name.
+func (h *entryHasher) yangType(y *YangType) {
+	if y == nil {
+		h.string("")
+		return
+	}
+	h.string(y.Name)
+	h.string(y.Kind.String())
+	h.string(y.Default)
+	h.string(y.Units)
+	h.int(int64(y.FractionDigits))
+	h.string(y.Length.String())
+	h.string(y.Range.String())
+	h.int(int64(len(y.Pattern)))
+	for _, p := range y.Pattern {
+		h.string(p)
+	}
+	h.string(y.Path)
+	if y.OptionalInstance {
+		h.int(1)
+	} else {
+		h.int(0)
+	}
+	h.enumType(y.Enum)
+	h.enumType(y.Bit)
+	if y.IdentityBase != nil {
+		h.string(RootNode(y.IdentityBase).Name + ":" + y.IdentityBase.Name)
+	} else {
+		h.string("")
+	}
+	h.int(int64(len(y.Type)))
+	for _, t := range y.Type {
+		h.yangType(t)
+	}
+}
+
+// enumType writes the names and values of e, in order of value, to the hash.
+func (h *entryHasher) enumType(e *EnumType) {
+	if e == nil {
+		h.int(0)
+		return
+	}
+	values := e.Values()
+	h.int(int64(len(values)))
+	for _, v := range values {
+		h.string(e.Name(v))
+		h.int(v)
+	}
+}
+
+// statement writes the keyword, argument and substatements of s to the hash.
+// A nil statement is written as an empty keyword.
+func (h *entryHasher) statement(s *Statement) {
+	if s == nil {
+		h.string("")
+		return
+	}
+	h.string(s.Keyword)
+	if s.HasArgument {
+		h.int(1)
+	} else {
+		h.int(0)
+	}
+	h.string(s.Argument)
+	h.int(int64(len(s.statements)))
+	for _, ss := range s.statements {
+		h.statement(ss)
+	}
+}
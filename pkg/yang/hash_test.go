@@ -0,0 +1,239 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"testing"
+)
+
+// hashModule returns the Hash of the module named m parsed from in.
+func hashModule(t *testing.T, in string) [32]byte {
+	t.Helper()
+	ms := NewModules()
+	if err := ms.Parse(in, "m.yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	return Hash(ToEntry(ms.Modules["m"]))
+}
+
+func TestHash(t *testing.T) {
+	base := `module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf a { type string; }
+    leaf b { type enumeration { enum x; enum y; } }
+  }
+}`
+	tests := []struct {
+		desc string
+		in   string
+		same bool
+	}{{
+		desc: "identical",
+		in:   base,
+		same: true,
+	}, {
+		desc: "reordered with documentation",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  description "documented";
+  container c {
+    leaf b { type enumeration { enum x; enum y; } description "b"; }
+    leaf a { type string; reference "RFC 0000"; }
+  }
+}`,
+		same: true,
+	}, {
+		desc: "different type",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf a { type int8; }
+    leaf b { type enumeration { enum x; enum y; } }
+  }
+}`,
+	}, {
+		desc: "different enum value",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf a { type string; }
+    leaf b { type enumeration { enum x; enum y { value 5; } } }
+  }
+}`,
+	}, {
+		desc: "container became list",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  list c {
+    key "a";
+    leaf a { type string; }
+    leaf b { type enumeration { enum x; enum y; } }
+  }
+}`,
+	}, {
+		desc: "added leaf",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf a { type string; }
+    leaf b { type enumeration { enum x; enum y; } }
+    leaf c { type string; }
+  }
+}`,
+	}, {
+		desc: "config false",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    config false;
+    leaf a { type string; }
+    leaf b { type enumeration { enum x; enum y; } }
+  }
+}`,
+	}}
+	want := hashModule(t, base)
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := hashModule(t, tt.in)
+			if same := got == want; same != tt.same {
+				t.Errorf("Hash equal to base = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestHashStable(t *testing.T) {
+	// The hash must not change between runs, platforms or Go versions.
+	got := fmt.Sprintf("%x", hashModule(t, `module m {
+  prefix "m";
+  namespace "urn:m";
+  leaf l { type string; }
+}`))
+	if want := "925b4039ca31af454e5405852db3ddc83b1925ab2a55d2d41b77f500727db8a1"; got != want {
+		t.Errorf("Hash = %s, want %s", got, want)
+	}
+}
+
+func TestHashModules(t *testing.T) {
+	ms := NewModules()
+	for _, in := range []string{
+		`module a { prefix "a"; namespace "urn:a"; leaf l { type string; } }`,
+		`module b { prefix "b"; namespace "urn:b"; revision 2020-01-01; leaf l { type string; } }`,
+	} {
+		if err := ms.Parse(in, "in.yang"); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	hashes := HashModules(ms)
+	if len(hashes) != 2 {
+		t.Fatalf("HashModules returned %d hashes, want 2: %v", len(hashes), hashes)
+	}
+	for _, name := range []string{"a", "b"} {
+		if got, want := hashes[name], Hash(ToEntry(ms.Modules[name])); got != want {
+			t.Errorf("HashModules()[%s] = %x, want %x", name, got, want)
+		}
+	}
+	if hashes["a"] == hashes["b"] {
+		t.Errorf("modules a and b have the same hash")
+	}
+}
+
+func TestModuleFingerprint(t *testing.T) {
+	fingerprint := func(in string) string {
+		t.Helper()
+		ms := NewModules()
+		if err := ms.Parse(in, "m.yang"); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		return ms.Modules["m"].Fingerprint()
+	}
+
+	base := fingerprint(`module m {
+  prefix "m";
+  namespace "urn:m";
+  revision 2020-01-01;
+  leaf a { type string; description "the a leaf"; }
+  leaf b { type int8; }
+}`)
+	if len(base) != 64 {
+		t.Errorf("Fingerprint = %q, want 64 hexadecimal digits", base)
+	}
+	tests := []struct {
+		desc string
+		in   string
+		same bool
+	}{{
+		desc: "whitespace, comments and quoting",
+		in: `// The m module.
+module m { prefix m; namespace 'urn:m';
+  revision "2020-01-01";
+  /* leaves */
+  leaf a {
+    type string;
+    description "the " + "a leaf";
+  }
+  leaf b { type "int8"; }
+}`,
+		same: true,
+	}, {
+		desc: "changed description",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  revision 2020-01-01;
+  leaf a { type string; description "the leaf"; }
+  leaf b { type int8; }
+}`,
+	}, {
+		desc: "reordered leaves",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  revision 2020-01-01;
+  leaf b { type int8; }
+  leaf a { type string; description "the a leaf"; }
+}`,
+	}, {
+		desc: "new revision",
+		in: `module m {
+  prefix "m";
+  namespace "urn:m";
+  revision 2020-01-01;
+  revision 2021-01-01;
+  leaf a { type string; description "the a leaf"; }
+  leaf b { type int8; }
+}`,
+	}}
+	for _, tt := range tests {
+		if got := fingerprint(tt.in); (got == base) != tt.same {
+			t.Errorf("%s: Fingerprint = %s, base %s, want same %v", tt.desc, got, base, tt.same)
+		}
+	}
+}
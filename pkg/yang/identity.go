@@ -16,6 +16,8 @@ package yang
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -175,7 +177,9 @@ func (ms *Modules) resolveIdentities() []error {
 			base, baseErr := root.findIdentityBase(i.Identity.Base.asString())
 
 			if baseErr != nil {
-				errs = append(errs, baseErr...)
+				if errs = append(errs, baseErr...); ms.failFast {
+					return errs
+				}
 				continue
 			}
 
@@ -195,3 +199,101 @@ func (ms *Modules) resolveIdentities() []error {
 
 	return errs
 }
+
+// ResolveIdentityRef returns the identity named by value, a value of the
+// identityref leaf or leaf-list e, or of a member of the union type of e.
+// The prefix of value may be either the name of the module defining the
+// identity, as in RFC 7951, or a prefix of that module in the module that
+// defines e.  An unprefixed value names an identity of the module that
+// defines e.  An error is returned if e is not of an identityref type or if
+// value does not name an identity derived from the base of the type.
+func (ms *Modules) ResolveIdentityRef(e *Entry, value string) (*Identity, error) {
+	if e == nil || e.Type == nil || e.Node == nil {
+		return nil, fmt.Errorf("cannot resolve identityref %q without a typed entry", value)
+	}
+	types := []*YangType{e.Type}
+	if e.Type.Kind == Yunion {
+		var err error
+		if types, err = e.Type.UnionTypes(); err != nil {
+			return nil, fmt.Errorf("%s: %v", e.Path(), err)
+		}
+	}
+
+	prefix, name := getPrefix(value)
+	var module string
+	switch mod := RootNode(e.Node); {
+	case prefix == "":
+		module = moduleName(mod)
+	case ms.Modules[prefix] != nil:
+		module = prefix
+	default:
+		im := FindModuleByPrefix(mod, prefix)
+		if im == nil {
+			return nil, fmt.Errorf("%s: identityref %q has unknown prefix %s", e.Path(), value, prefix)
+		}
+		module = moduleName(im)
+	}
+
+	var bases []string
+	for _, y := range types {
+		if y.Kind != Yidentityref || y.IdentityBase == nil {
+			continue
+		}
+		bases = append(bases, y.IdentityBase.PrefixedName())
+		for _, id := range y.IdentityBase.Values {
+			if id.Name == name && moduleName(id) == module {
+				return id, nil
+			}
+		}
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("%s is not of an identityref type", e.Path())
+	}
+	return nil, fmt.Errorf("%s: %q is not an identity derived from %s", e.Path(), value, strings.Join(bases, " or "))
+}
+
+// IdentityDerivatives returns the identities that are derived from base,
+// directly or through other derived identities, sorted by the name of their
+// module and then by their name.  base itself is not included.
+func (ms *Modules) IdentityDerivatives(base *Identity) []*Identity {
+	if base == nil {
+		return nil
+	}
+	ids := append([]*Identity(nil), base.Values...)
+	sort.Slice(ids, func(i, j int) bool {
+		if mi, mj := moduleName(ids[i]), moduleName(ids[j]); mi != mj {
+			return mi < mj
+		}
+		return ids[i].Name < ids[j].Name
+	})
+	return ids
+}
+
+// AllIdentities returns the identities defined by the modules and submodules
+// of ms, sorted by the name of the module that defines them, or that the
+// submodule defining them belongs to, and then by their name.  Each
+// identity is returned once, even though ms has an entry for both the name
+// and the name@revision of each module.
+func (ms *Modules) AllIdentities() []*Identity {
+	var ids []*Identity
+	seen := map[*Module]bool{}
+	for _, mm := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		for _, m := range mm {
+			if !seen[m] {
+				seen[m] = true
+				ids = append(ids, m.Identity...)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if mi, mj := moduleName(ids[i]), moduleName(ids[j]); mi != mj {
+			return mi < mj
+		}
+		if ids[i].Name != ids[j].Name {
+			return ids[i].Name < ids[j].Name
+		}
+		// The same identity of different revisions of a module.
+		return RootNode(ids[i]).Current() < RootNode(ids[j]).Current()
+	})
+	return ids
+}
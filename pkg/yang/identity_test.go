@@ -17,6 +17,9 @@ package yang
 import (
 	"reflect"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
 )
 
 // inputModule is a mock input YANG module.
@@ -522,3 +525,152 @@ func TestIdentityTree(t *testing.T) {
 		}
 	}
 }
+
+func TestResolveIdentityRef(t *testing.T) {
+	ms := NewModules()
+	for name, in := range map[string]string{
+		"base.yang": `module base {
+  prefix "b";
+  namespace "urn:b";
+  identity animal;
+  identity dog { base animal; }
+  identity color;
+}`,
+		"m.yang": `module m {
+  prefix "m";
+  namespace "urn:m";
+  import base { prefix bp; }
+  identity cat { base bp:animal; }
+  identity kitten { base cat; }
+  leaf pet { type identityref { base bp:animal; } }
+  leaf either {
+    type union {
+      type identityref { base bp:color; }
+      type identityref { base bp:animal; }
+    }
+  }
+  leaf s { type string; }
+}`,
+	} {
+		if err := ms.Parse(in, name); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+
+	tests := []struct {
+		desc       string
+		leaf       string
+		value      string
+		want       string
+		wantErrSub string
+	}{{
+		desc:  "unprefixed",
+		leaf:  "pet",
+		value: "kitten",
+		want:  "m:kitten",
+	}, {
+		desc:  "import prefix",
+		leaf:  "pet",
+		value: "bp:dog",
+		want:  "b:dog",
+	}, {
+		desc:  "module name",
+		leaf:  "pet",
+		value: "base:dog",
+		want:  "b:dog",
+	}, {
+		desc:  "union",
+		leaf:  "either",
+		value: "m:cat",
+		want:  "m:cat",
+	}, {
+		desc:       "base itself",
+		leaf:       "pet",
+		value:      "bp:animal",
+		wantErrSub: `"bp:animal" is not an identity derived from b:animal`,
+	}, {
+		desc:       "wrong module",
+		leaf:       "pet",
+		value:      "bp:cat",
+		wantErrSub: `"bp:cat" is not an identity derived from b:animal`,
+	}, {
+		desc:       "not derived",
+		leaf:       "either",
+		value:      "dog2",
+		wantErrSub: "is not an identity derived from b:color or b:animal",
+	}, {
+		desc:       "unknown prefix",
+		leaf:       "pet",
+		value:      "x:dog",
+		wantErrSub: "has unknown prefix x",
+	}, {
+		desc:       "not identityref",
+		leaf:       "s",
+		value:      "dog",
+		wantErrSub: "/m/s is not of an identityref type",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			id, err := ms.ResolveIdentityRef(mod.Dir[tt.leaf], tt.value)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("ResolveIdentityRef: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := id.PrefixedName(); got != tt.want {
+				t.Errorf("ResolveIdentityRef(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	var got []string
+	for _, id := range ms.IdentityDerivatives(mod.Dir["pet"].Type.IdentityBase) {
+		got = append(got, id.PrefixedName())
+	}
+	if want := []string{"b:dog", "m:cat", "m:kitten"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IdentityDerivatives = %v, want %v", got, want)
+	}
+	if got := ms.IdentityDerivatives(nil); got != nil {
+		t.Errorf("IdentityDerivatives(nil) = %v, want nil", got)
+	}
+}
+
+func TestAllIdentities(t *testing.T) {
+	ms := NewModules()
+	for name, in := range map[string]string{
+		"b.yang": `module b {
+  prefix "b";
+  namespace "urn:b";
+  identity zebra;
+  identity ant;
+}`,
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  revision 2020-01-01;
+  include s;
+  identity yak;
+}`,
+		"s.yang": `submodule s {
+  belongs-to a { prefix a; }
+  identity bee;
+}`,
+	} {
+		if err := ms.Parse(in, name); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+	}
+	var got []string
+	for _, id := range ms.AllIdentities() {
+		got = append(got, moduleName(id)+":"+id.Name)
+	}
+	want := []string{"a:bee", "a:yak", "b:ant", "b:zebra"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AllIdentities (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the traversal of the transitive imports of a module,
+// and the detection of import cycles.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AllImports returns the modules that m imports, directly or through the
+// modules it imports, including those imported by the submodules m
+// includes.  Each module is returned once, after all of the modules it
+// imports, so that the modules are in topological order.  An error is
+// returned if an imported module or included submodule cannot be found, or
+// if the imports form a cycle.  The result for each module is cached until
+// ms is Reset.
+func (ms *Modules) AllImports(m *Module) ([]*Module, error) {
+	imports, err := ms.allImportsOf(m, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append([]*Module(nil), imports...), nil
+}
+
+// An importCycleError reports an import cycle found by allImportsOf.
+type importCycleError struct {
+	error
+}
+
+// allImportsOf returns the modules that m imports, as returned by AllImports.
+// stack is the chain of modules whose imports led to m, which is used to
+// detect cycles.  An import cycle is returned as an importCycleError as soon
+// as it is found, but the imports of the other modules are still searched
+// for cycles when a module or submodule cannot be found.
+func (ms *Modules) allImportsOf(m *Module, stack []*Module) ([]*Module, error) {
+	if imports, ok := ms.allImports[m]; ok {
+		return imports, nil
+	}
+	for x, sm := range stack {
+		if sm == m {
+			var names []string
+			for _, sm := range append(stack[x:], m) {
+				names = append(names, sm.Name)
+			}
+			return nil, importCycleError{fmt.Errorf("%s: import cycle detected: %s", Source(m), strings.Join(names, " -> "))}
+		}
+	}
+	stack = append(stack, m)
+
+	// missing is the error for the first module or submodule that cannot
+	// be found.
+	var missing error
+	notFound := func(err error) {
+		if missing == nil {
+			missing = err
+		}
+	}
+
+	// The imports of m include those of its submodules, and of their
+	// submodules.
+	mods := []*Module{m}
+	included := map[*Module]bool{m: true}
+	for x := 0; x < len(mods); x++ {
+		for _, i := range mods[x].Include {
+			switch sm := ms.FindModule(i); {
+			case sm == nil:
+				notFound(fmt.Errorf("%s: no such submodule: %s", Source(i), i.Name))
+			case !included[sm]:
+				included[sm] = true
+				mods = append(mods, sm)
+			}
+		}
+	}
+
+	var imports []*Module
+	seen := map[*Module]bool{}
+	add := func(m *Module) {
+		if !seen[m] {
+			seen[m] = true
+			imports = append(imports, m)
+		}
+	}
+	for _, sm := range mods {
+		for _, i := range sm.Import {
+			im := ms.FindModule(i)
+			if im == nil {
+				notFound(fmt.Errorf("%s: no such module: %s", Source(i), i.Name))
+				continue
+			}
+			deps, err := ms.allImportsOf(im, stack)
+			if _, ok := err.(importCycleError); ok {
+				return nil, err
+			}
+			if err != nil {
+				notFound(err)
+			}
+			for _, d := range deps {
+				add(d)
+			}
+			add(im)
+		}
+	}
+	if missing != nil {
+		return nil, missing
+	}
+
+	if ms.allImports == nil {
+		ms.allImports = map[*Module][]*Module{}
+	}
+	ms.allImports[m] = imports
+	return imports, nil
+}
+
+// CyclicDependencyCheck returns an error naming the modules of the first
+// import cycle found among the modules of ms, such as
+//
+//	import cycle detected: a -> b -> a
+//
+// or nil if the imports of the modules do not form a cycle.  The imports of
+// a module include those of the submodules it includes.  Modules are
+// searched in order of their names, and modules that cannot be found are
+// ignored.  Process calls CyclicDependencyCheck once the modules imported by
+// the modules of ms have been loaded, and before the modules are processed
+// further, if ParseOptions.RejectImportCycles is set, and otherwise reports
+// the cycle as a warning.
+func (ms *Modules) CyclicDependencyCheck() error {
+	var mods []*Module
+	seen := map[*Module]bool{}
+	for _, m := range ms.Modules {
+		if !seen[m] {
+			seen[m] = true
+			mods = append(mods, m)
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].Name != mods[j].Name {
+			return mods[i].Name < mods[j].Name
+		}
+		return mods[i].Current() < mods[j].Current()
+	})
+
+	for _, m := range mods {
+		if _, err := ms.allImportsOf(m, nil); err != nil {
+			if _, ok := err.(importCycleError); ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestAllImports(t *testing.T) {
+	tests := []struct {
+		desc       string
+		in         []string
+		module     string
+		want       []string
+		wantErrSub string
+	}{{
+		desc:   "no imports",
+		in:     []string{`module a { prefix "a"; namespace "urn:a"; }`},
+		module: "a",
+	}, {
+		desc: "diamond",
+		in: []string{
+			`module a { prefix "a"; namespace "urn:a"; import b { prefix b; } import c { prefix c; } }`,
+			`module b { prefix "b"; namespace "urn:b"; import d { prefix d; } }`,
+			`module c { prefix "c"; namespace "urn:c"; import d { prefix d; } }`,
+			`module d { prefix "d"; namespace "urn:d"; }`,
+		},
+		module: "a",
+		want:   []string{"d", "b", "c"},
+	}, {
+		desc: "imports of submodules",
+		in: []string{
+			`module a { prefix "a"; namespace "urn:a"; include s; }`,
+			`submodule s { belongs-to a { prefix a; } include t; import b { prefix b; } }`,
+			`submodule t { belongs-to a { prefix a; } import c { prefix c; } }`,
+			`module b { prefix "b"; namespace "urn:b"; import c { prefix c; } }`,
+			`module c { prefix "c"; namespace "urn:c"; }`,
+		},
+		module: "a",
+		want:   []string{"c", "b"},
+	}, {
+		desc: "cycle",
+		in: []string{
+			`module a { prefix "a"; namespace "urn:a"; import b { prefix b; } }`,
+			`module b { prefix "b"; namespace "urn:b"; import c { prefix c; } }`,
+			`module c { prefix "c"; namespace "urn:c"; import b { prefix b; } }`,
+		},
+		module:     "a",
+		wantErrSub: "import cycle detected: b -> c -> b",
+	}, {
+		desc:       "missing module",
+		in:         []string{`module a { prefix "a"; namespace "urn:a"; import missing { prefix m; } }`},
+		module:     "a",
+		wantErrSub: "no such module: missing",
+	}, {
+		desc: "cycle past a missing module",
+		in: []string{
+			`module a { prefix "a"; namespace "urn:a"; import missing { prefix m; } import b { prefix b; } }`,
+			`module b { prefix "b"; namespace "urn:b"; import a { prefix a; } }`,
+		},
+		module:     "a",
+		wantErrSub: "import cycle detected: a -> b -> a",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for _, in := range tt.in {
+				if err := ms.Parse(in, "in.yang"); err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+			}
+			imports, err := ms.AllImports(ms.Modules[tt.module])
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("AllImports: %s", diff)
+			}
+			var got []string
+			for _, m := range imports {
+				got = append(got, m.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("AllImports (-want, +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			// The cached result must be the same, and not shared with
+			// the caller.
+			if len(imports) > 0 {
+				imports[0] = nil
+			}
+			again, err := ms.AllImports(ms.Modules[tt.module])
+			if err != nil {
+				t.Fatalf("second AllImports: %v", err)
+			}
+			got = nil
+			for _, m := range again {
+				got = append(got, m.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("second AllImports (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCyclicDependencyCheck(t *testing.T) {
+	tests := []struct {
+		desc       string
+		in         []string
+		wantErrSub string
+	}{{
+		desc: "no cycle",
+		in: []string{
+			`module a { prefix "a"; namespace "urn:a"; import b { prefix b; } import c { prefix c; } }`,
+			`module b { prefix "b"; namespace "urn:b"; import c { prefix c; } }`,
+			`module c { prefix "c"; namespace "urn:c"; }`,
+		},
+	}, {
+		desc: "two modules",
+		in: []string{
+			`module a { prefix "a"; namespace "urn:a"; import b { prefix b; } }`,
+			`module b { prefix "b"; namespace "urn:b"; import a { prefix a; } }`,
+		},
+		wantErrSub: "import cycle detected: a -> b -> a",
+	}, {
+		desc: "cycle below the first module",
+		in: []string{
+			`module a { prefix "a"; namespace "urn:a"; import b { prefix b; } }`,
+			`module b { prefix "b"; namespace "urn:b"; import c { prefix c; } }`,
+			`module c { prefix "c"; namespace "urn:c"; import d { prefix d; } }`,
+			`module d { prefix "d"; namespace "urn:d"; import b { prefix b; } }`,
+		},
+		wantErrSub: "import cycle detected: b -> c -> d -> b",
+	}, {
+		desc: "through a submodule",
+		in: []string{
+			`module a { prefix "a"; namespace "urn:a"; include s; }`,
+			`submodule s { belongs-to a { prefix a; } import b { prefix b; } }`,
+			`module b { prefix "b"; namespace "urn:b"; import a { prefix a; } }`,
+		},
+		wantErrSub: "import cycle detected: a -> b -> a",
+	}, {
+		desc: "missing module",
+		in:   []string{`module a { prefix "a"; namespace "urn:a"; import missing { prefix m; } }`},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for _, in := range tt.in {
+				if err := ms.Parse(in, "in.yang"); err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+			}
+			err := ms.CyclicDependencyCheck()
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("CyclicDependencyCheck: %s", diff)
+			}
+			if err == nil {
+				return
+			}
+
+			// By default, Process reports the cycle as a warning.
+			ms.Process()
+			var warned bool
+			for _, w := range ms.Warnings() {
+				if errdiff.Substring(w, tt.wantErrSub) == "" {
+					warned = true
+				}
+			}
+			if !warned {
+				t.Errorf("Process: got warnings %v, want the cycle", ms.Warnings())
+			}
+
+			ParseOptions.RejectImportCycles = true
+			defer func() { ParseOptions.RejectImportCycles = false }()
+			errs := ms.Process()
+			if len(errs) != 1 {
+				t.Fatalf("Process: got %v, want only the cycle", errs)
+			}
+			if diff := errdiff.Substring(errs[0], tt.wantErrSub); diff != "" {
+				t.Errorf("Process: %s", diff)
+			}
+		})
+	}
+}
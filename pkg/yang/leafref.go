@@ -0,0 +1,170 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the validation of the paths of leafrefs.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A LeafrefError reports a leafref whose path cannot be resolved.
+type LeafrefError struct {
+	Entry *Entry // the leaf or leaf-list of type leafref
+	Path  string // the path of the leafref
+	Err   error  // why the path cannot be resolved
+}
+
+// Error implements the error interface.
+func (e LeafrefError) Error() string {
+	return e.Err.Error()
+}
+
+// ValidateLeafrefs resolves the path of every leaf and leaf-list of type
+// leafref, including the leafref members of unions, in the modules of ms, and
+// returns an error for each path that does not refer to a leaf or leaf-list.
+// The errors are ordered by module name and then in the order the leaves
+// are defined.  The predicates of the paths are not validated.  Process must
+// be called before ValidateLeafrefs.
+func (ms *Modules) ValidateLeafrefs() []LeafrefError {
+	var errs []LeafrefError
+	validate := func(e *Entry) bool {
+		if e.Type != nil {
+			for _, y := range leafrefTypes(e.Type) {
+				if _, err := e.ResolveLeafref(y); err != nil {
+					errs = append(errs, LeafrefError{Entry: e, Path: y.Path, Err: err})
+				}
+			}
+		}
+		return true
+	}
+	for _, name := range ms.moduleNames() {
+		ToEntry(ms.Modules[name]).walk(validate)
+	}
+	return errs
+}
+
+// LeafrefTarget returns the leaf or leaf-list that the path of the type of e,
+// a leaf or leaf-list of type leafref, refers to.  Relative paths are
+// resolved from e and the predicates of the path are ignored.  An error is
+// returned if e is not of type leafref or its path cannot be resolved.
+func (e *Entry) LeafrefTarget() (*Entry, error) {
+	if e.Kind != LeafEntry || e.Type == nil || e.Type.Kind != Yleafref {
+		return nil, fmt.Errorf("%s: %s is not a leaf or leaf-list of type leafref", Source(e.Node), e.Path())
+	}
+	return e.ResolveLeafref(e.Type)
+}
+
+// leafrefTypes returns y, if it is a leafref, or the leafref members of y, if
+// it is a union.
+func leafrefTypes(y *YangType) []*YangType {
+	switch y.Kind {
+	case Yleafref:
+		return []*YangType{y}
+	case Yunion:
+		var types []*YangType
+		for _, t := range y.Type {
+			types = append(types, leafrefTypes(t)...)
+		}
+		return types
+	}
+	return nil
+}
+
+// ResolveLeafref returns the leaf or leaf-list that the path of y, a leafref
+// type of e, such as a member of the union type of e, refers to.  Relative
+// paths are resolved from e through the data tree, in which choice and case
+// nodes are not steps, and the predicates of the path are ignored.  The
+// prefixes of the path are resolved in the module that defines the path,
+// which is not the module of e when the path is that of a typedef, and each
+// prefixed step must name a node in the namespace of its prefix.
+func (e *Entry) ResolveLeafref(y *YangType) (*Entry, error) {
+	path := y.Path
+	p := StripPredicates(path)
+	if p == "" {
+		return nil, fmt.Errorf("%s: leafref %s has no path", Source(e.Node), e.Path())
+	}
+	var pn Node = e.Node
+	if y.pathType != nil {
+		pn = y.pathType
+	}
+	t := e
+	if strings.HasPrefix(p, "/") {
+		for t.Parent != nil {
+			t = t.Parent
+		}
+		p = p[1:]
+	}
+	for i, step := range strings.Split(p, "/") {
+		switch step {
+		case ".":
+			continue
+		case "..":
+			if t = t.DataParent(); t == nil {
+				return nil, fmt.Errorf("%s: leafref path %q of %s goes above the root of the data tree", Source(e.Node), path, e.Path())
+			}
+			continue
+		}
+		prefix, name := getPrefix(step)
+		var m *Module
+		if prefix != "" {
+			if m = FindModuleByPrefix(pn, prefix); m == nil {
+				return nil, fmt.Errorf("%s: leafref path %q of %s has unknown prefix %s", Source(e.Node), path, e.Path(), prefix)
+			}
+		}
+		if i == 0 && t.Parent == nil && m != nil {
+			// The first step of an absolute path may name a top level
+			// node of another module.
+			if mn := moduleName(m); mn != t.Name {
+				bm := e.Modules().Modules[mn]
+				if bm == nil {
+					return nil, fmt.Errorf("%s: leafref path %q of %s refers to module %s, which has not been read", Source(e.Node), path, e.Path(), mn)
+				}
+				t = ToEntry(bm)
+			}
+		}
+		next := t.dataChild(name)
+		if next == nil {
+			return nil, fmt.Errorf("%s: leafref path %q of %s: %s not found in %s", Source(e.Node), path, e.Path(), step, t.Path())
+		}
+		if m != nil && next.ModuleName() != moduleName(m) {
+			return nil, fmt.Errorf("%s: leafref path %q of %s: %s is in module %s, not %s", Source(e.Node), path, e.Path(), next.Path(), next.ModuleName(), moduleName(m))
+		}
+		t = next
+	}
+	if !t.IsLeaf() && !t.IsLeafList() {
+		return nil, fmt.Errorf("%s: leafref path %q of %s refers to %s, which is not a leaf or leaf-list", Source(e.Node), path, e.Path(), t.Path())
+	}
+	return t, nil
+}
+
+// StripPredicates returns path with the predicates of its steps, and any
+// whitespace outside of the predicates, removed.
+func StripPredicates(path string) string {
+	var b strings.Builder
+	depth := 0
+	for _, c := range path {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case depth == 0 && c != ' ' && c != '\t' && c != '\n':
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,150 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestValidateLeafrefs(t *testing.T) {
+	ms := NewModules()
+	for name, text := range map[string]string{
+		"refs": `module refs {
+  prefix "r";
+  namespace "urn:r";
+  import other { prefix "ot"; }
+
+  container c {
+    list l {
+      key "name";
+      leaf name { type string; }
+      choice ch { leaf in-choice { type string; } }
+      container sub {}
+    }
+    leaf relative { type leafref { path "../l/name"; } }
+    leaf absolute { type leafref { path "/r:c/r:l[r:name = current()/../relative]/r:name"; } }
+    leaf through-choice { type leafref { path "../l/in-choice"; } }
+    leaf other-module { type leafref { path "/ot:top/ot:leaf"; } }
+    leaf-list list-ref { type leafref { path "../relative"; } }
+    leaf missing { type leafref { path "../l/no-such-leaf"; } }
+    leaf not-leaf { type leafref { path "../l/sub"; } }
+    leaf too-far { type leafref { path "../../../../x"; } }
+    leaf bad-prefix { type leafref { path "/x:c"; } }
+    leaf typedef-path { type ot:top-ref; }
+    leaf wrong-namespace { type leafref { path "../r:l/ot:name"; } }
+    leaf in-union {
+      type union {
+        type string;
+        type leafref { path "../l/missing-in-union"; }
+      }
+    }
+  }
+  rpc op {
+    input {
+      leaf in { type leafref { path "/r:c/r:relative"; } }
+    }
+  }
+}`,
+		"other": `module other {
+  prefix "o";
+  namespace "urn:o";
+  typedef top-ref { type leafref { path "/o:top/o:leaf"; } }
+  container top { leaf leaf { type string; } }
+}`,
+	} {
+		if err := ms.Parse(text, name+".yang"); err != nil {
+			t.Fatalf("Parse %s: %v", name, err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+
+	var got []string
+	for _, err := range ms.ValidateLeafrefs() {
+		got = append(got, err.Entry.Path()+" "+err.Path+": "+err.Error())
+	}
+	want := []string{
+		`/refs/c/missing ../l/no-such-leaf: refs.yang:18:5: leafref path "../l/no-such-leaf" of /refs/c/missing: no-such-leaf not found in /refs/c/l`,
+		`/refs/c/not-leaf ../l/sub: refs.yang:19:5: leafref path "../l/sub" of /refs/c/not-leaf refers to /refs/c/l/sub, which is not a leaf or leaf-list`,
+		`/refs/c/too-far ../../../../x: refs.yang:20:5: leafref path "../../../../x" of /refs/c/too-far goes above the root of the data tree`,
+		`/refs/c/bad-prefix /x:c: refs.yang:21:5: leafref path "/x:c" of /refs/c/bad-prefix has unknown prefix x`,
+		`/refs/c/wrong-namespace ../r:l/ot:name: refs.yang:23:5: leafref path "../r:l/ot:name" of /refs/c/wrong-namespace: /refs/c/l/name is in module refs, not other`,
+		`/refs/c/in-union ../l/missing-in-union: refs.yang:24:5: leafref path "../l/missing-in-union" of /refs/c/in-union: missing-in-union not found in /refs/c/l`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ValidateLeafrefs (-want, +got):\n%s", diff)
+	}
+}
+
+func TestLeafrefTarget(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module refs {
+  prefix "r";
+  namespace "urn:r";
+
+  container interfaces {
+    list interface {
+      key "name";
+      leaf name { type string; }
+      leaf mtu { type uint16; }
+      leaf-list address { type string; }
+    }
+  }
+  container bindings {
+    leaf ifname { type leafref { path "/interfaces/interface/name"; } }
+    leaf-list names { type leafref { path "/r:interfaces/r:interface/r:name"; } }
+    leaf-list mtus {
+      type leafref { path "../../interfaces/interface[name = current()/../ifname]/mtu"; }
+    }
+    leaf-list addresses { type leafref { path "../../interfaces/interface/address"; } }
+    leaf-list broken { type leafref { path "../../interfaces/interface/nosuch"; } }
+    leaf-list plain { type string; }
+  }
+}`, "refs.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	e := ToEntry(ms.Modules["refs"])
+	b := e.Dir["bindings"]
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "ifname", want: "/refs/interfaces/interface/name"},
+		{name: "names", want: "/refs/interfaces/interface/name"},
+		{name: "mtus", want: "/refs/interfaces/interface/mtu"},
+		{name: "addresses", want: "/refs/interfaces/interface/address"},
+		{name: "broken", wantErr: "nosuch not found in /refs/interfaces/interface"},
+		{name: "plain", wantErr: "/refs/bindings/plain is not a leaf or leaf-list of type leafref"},
+	}
+	for _, tt := range tests {
+		target, err := b.Dir[tt.name].LeafrefTarget()
+		if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+			t.Errorf("%s: %s", tt.name, diff)
+			continue
+		}
+		if got := target.Path(); got != tt.want {
+			t.Errorf("%s: got target %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -18,7 +18,14 @@ package yang
 // include and import statements, which must be done prior to turning the
 // module into an Entry tree.
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+)
 
 // Modules contains information about all the top level modules and
 // submodules that are read into it via its Read method.
@@ -28,6 +35,28 @@ type Modules struct {
 	includes   map[*Module]bool   // Modules we have already done include on
 	byPrefix   map[string]*Module // Cache of prefix lookup
 	byNS       map[string]*Module // Cache of namespace lookup
+
+	// reader, if set, is used in place of Path to read the modules and
+	// submodules that are imported or included.
+	reader ModuleReader
+
+	// resolver, if set, returns the YANG source of a module or submodule
+	// that cannot be found in Path.
+	resolver func(name string) (string, error)
+
+	// warnings are the warnings found by the last call to Process.
+	warnings []error
+
+	// allImports caches the modules returned by AllImports for each
+	// module.
+	allImports map[*Module][]*Module
+
+	// httpClient, if set, is used in place of http.DefaultClient by
+	// ParseURL.
+	httpClient *http.Client
+
+	// failFast, if set, makes Process stop at the first error.
+	failFast bool
 }
 
 // NewModules returns a newly created and initialized Modules.
@@ -53,6 +82,124 @@ func (ms *Modules) Read(name string) error {
 	return ms.Parse(string(data), name)
 }
 
+// SetModuleReader sets r as the ModuleReader ms uses to read the modules and
+// submodules that are imported or included but have not already been read,
+// for example to fetch them from a remote repository.  Setting r to nil
+// restores the default of reading them from Path, as PathReader does.
+func (ms *Modules) SetModuleReader(r ModuleReader) {
+	ms.reader = r
+}
+
+// SetSourceMap sets sources, a map from the file names or names of modules
+// and submodules to their YANG source, as the source ms reads the modules and
+// submodules that are imported or included from, as described by MapReader.
+// It is the same as calling SetModuleReader with MapReader(sources).
+func (ms *Modules) SetSourceMap(sources map[string]string) {
+	ms.SetModuleReader(MapReader(sources))
+}
+
+// SetImportResolver sets fn as the function ms uses to obtain the YANG source
+// text of a module or submodule that is imported or included but cannot be
+// found in Path.  fn is called with the name of the module, and may fetch
+// the module from a registry, a database or a cache.  Setting fn to nil
+// removes the resolver.
+func (ms *Modules) SetImportResolver(fn func(moduleName string) (text string, err error)) {
+	ms.resolver = fn
+}
+
+// SetFailFast sets whether Process stops at the first error it finds,
+// returning only that error, rather than continuing to collect all the
+// errors it can.  This is useful when only whether the modules are valid
+// matters, as in an editor checking a module as it is typed.  The default
+// is to collect all the errors.
+func (ms *Modules) SetFailFast(failFast bool) {
+	ms.failFast = failFast
+}
+
+// Import reads the named module or submodule into ms if it has not already
+// been read.  The directories in searchPaths are searched before Path, using
+// the same rules as Path.  If the module is not found in any directory, the
+// resolver set by SetImportResolver, if any, is used.
+func (ms *Modules) Import(name string, searchPaths []string) error {
+	if ms.Modules[name] != nil || ms.SubModules[name] != nil {
+		return nil
+	}
+	return ms.find(name, "", searchPaths)
+}
+
+// find reads the named module or submodule into ms, searching the
+// directories in searchPaths before using load.  If revision is not empty,
+// the file name@revision.yang is read in preference to any other revision
+// found in a directory.
+func (ms *Modules) find(name, revision string, searchPaths []string) error {
+	if found, err := ms.findIn(name, revision, searchPaths); found {
+		return err
+	}
+	return ms.load(name, revision, nil)
+}
+
+// findIn reads the named module or submodule into ms from the first of dirs
+// that has a file for it, as described by find.  It reports whether such a
+// file was found, and the error reading it, if any.
+func (ms *Modules) findIn(name, revision string, dirs []string) (bool, error) {
+	fname := name + ".yang"
+	for _, dir := range dirs {
+		recurse := filepath.Base(dir) == "..."
+		if recurse {
+			dir = filepath.Dir(dir)
+		}
+		var n string
+		if revision != "" {
+			n = scanDir(dir, name+"@"+revision+".yang", recurse)
+		}
+		if n == "" {
+			n = scanDir(dir, fname, recurse)
+		}
+		if n == "" {
+			continue
+		}
+		if data, err := readFile(n); err == nil {
+			return true, ms.Parse(string(data), n)
+		}
+	}
+	return false, nil
+}
+
+// load reads the named module or submodule into ms, first by using the
+// ModuleReader of ms, or by searching Path if ms has none, then by searching
+// the directories in fallback, and then by using the resolver of ms, if set.
+// If revision is not empty, that revision of the module is read if the
+// ModuleReader is able to.
+func (ms *Modules) load(name, revision string, fallback []string) error {
+	var err error
+	if ms.reader == nil {
+		err = ms.Read(name)
+	} else {
+		var data []byte
+		if data, err = ms.reader.ReadModule(name, revision); err == nil {
+			fname := name
+			if revision != "" {
+				fname += "@" + revision
+			}
+			err = ms.Parse(string(data), fname+".yang")
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	if found, ferr := ms.findIn(name, revision, fallback); found {
+		return ferr
+	}
+	if ms.resolver == nil {
+		return err
+	}
+	data, rerr := ms.resolver(name)
+	if rerr != nil {
+		return fmt.Errorf("%v; resolver: %v", err, rerr)
+	}
+	return ms.Parse(data, name)
+}
+
 // Parse parses data as YANG source and adds it to ms.  The name should reflect
 // the source of data.
 func (ms *Modules) Parse(data, name string) error {
@@ -65,7 +212,87 @@ func (ms *Modules) Parse(data, name string) error {
 		if err != nil {
 			return err
 		}
-		ms.add(n)
+		if err := ms.add(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseReader reads YANG source from r and adds it to ms, as Parse does.  The
+// name should reflect the source of r, such as the name of a file.  An error
+// is returned if r cannot be read.
+func (ms *Modules) ParseReader(r io.Reader, name string) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return ms.Parse(string(data), name)
+}
+
+// Merge returns a new Modules containing all the modules and submodules of
+// base and overlay, such as a vendor's modules and the modules of an
+// operator that augment and deviate them.  The modules are rebuilt from their
+// statements, so base and overlay are not changed and the returned Modules
+// must be processed before use.  An error is returned if a module or
+// submodule of the same name, and revision if it has one, is in both base
+// and overlay.  The import resolver of base, or of overlay if base has none,
+// is used by the returned Modules.
+func Merge(base, overlay *Modules) (*Modules, error) {
+	ms := NewModules()
+	ms.resolver = base.resolver
+	if ms.resolver == nil {
+		ms.resolver = overlay.resolver
+	}
+	for _, from := range []*Modules{base, overlay} {
+		if err := ms.rebuild(from); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+// Reset discards the results of processing the modules and submodules of ms,
+// rebuilding each of them from its statements, so that ms can be processed
+// again, such as after more modules have been parsed into it.  The Entry
+// trees built by earlier calls to Process are not changed, but are no longer
+// those returned by ToEntry for the modules of ms.  The import resolver,
+// module reader and fail fast setting of ms are kept.
+func (ms *Modules) Reset() error {
+	old := &Modules{Modules: ms.Modules, SubModules: ms.SubModules}
+	ms.Modules = map[string]*Module{}
+	ms.SubModules = map[string]*Module{}
+	ms.includes = map[*Module]bool{}
+	ms.byPrefix = map[string]*Module{}
+	ms.byNS = map[string]*Module{}
+	ms.warnings = nil
+	ms.allImports = nil
+	return ms.rebuild(old)
+}
+
+// rebuild adds a copy of each module and submodule of from, built from its
+// statements, to ms.
+func (ms *Modules) rebuild(from *Modules) error {
+	for _, mods := range []map[string]*Module{from.Modules, from.SubModules} {
+		// A module is in mods under both its name and its full name.
+		seen := map[*Module]bool{}
+		var names []string
+		for name, m := range mods {
+			if !seen[m] {
+				seen[m] = true
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			n, err := BuildAST(mods[name].Source)
+			if err != nil {
+				return err
+			}
+			if err := ms.add(n); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -94,6 +321,25 @@ func (ms *Modules) GetModule(name string) (*Entry, []error) {
 	return ToEntry(ms.Modules[name]), nil
 }
 
+// GetModuleRevision returns the Entry of the module named by name at the
+// specified revision.  Unlike GetModule, GetModuleRevision does not search
+// for the module; it must already have been read into ms.  If revision is ""
+// then the most recent revision of the module is returned.
+func (ms *Modules) GetModuleRevision(name, revision string) (*Entry, []error) {
+	fullName := name
+	if revision != "" {
+		fullName = name + "@" + revision
+	}
+	m := ms.Modules[fullName]
+	if m == nil {
+		return nil, []error{fmt.Errorf("module not found: %s", fullName)}
+	}
+	if errs := ms.Process(); len(errs) != 0 {
+		return nil, errs
+	}
+	return ToEntry(m), nil
+}
+
 // GetModule optionally reads in a set of YANG source files, named by sources,
 // and then returns the Entry for the module named module.  If sources is
 // missing, or the named module is not yet known, GetModule searches for name
@@ -118,7 +364,9 @@ func GetModule(name string, sources ...string) (*Entry, []error) {
 
 // add adds Node n to ms.  n must be assignable to *Module (i.e., it is a
 // "module" or "submodule").  An error is returned if n is a duplicate of
-// a name already added, or n is not assignable to *Module.
+// a name already added, or n is not assignable to *Module.  Modules with the
+// same name but different revisions are not duplicates; each is stored under
+// its full name (name@revision) and the bare name refers to the most recent.
 func (ms *Modules) add(n Node) error {
 	var m map[string]*Module
 
@@ -137,8 +385,10 @@ func (ms *Modules) add(n Node) error {
 	fullName := mod.FullName()
 	mod.modules = ms
 
-	if o := m[fullName]; o != nil {
-		return fmt.Errorf("duplicate %s %s at %s and %s", kind, fullName, Source(o), Source(n))
+	// m[fullName] may be the bare name of a revisioned module, which is
+	// not a duplicate of an unrevisioned module of the same name.
+	if o := m[fullName]; o != nil && o.FullName() == fullName {
+		return fmt.Errorf("duplicate %s name %q: first seen at %s, second at %s", kind, fullName, Source(o), Source(n))
 	}
 	m[fullName] = mod
 	if fullName == name {
@@ -154,25 +404,55 @@ func (ms *Modules) add(n Node) error {
 	return nil
 }
 
+// moduleNames returns the sorted names of the modules of ms, naming only the
+// latest revision of each module.
+func (ms *Modules) moduleNames() []string {
+	return sortedModuleNames(ms.Modules, false)
+}
+
+// sortedModuleNames returns the sorted keys of mods, such as ms.Modules or
+// ms.SubModules, that name a module once.  Each module is under its name
+// and, if it has a revision, its name@revision, but a revision that is not
+// the latest is only under the latter, which is returned if revisions is
+// true.
+func sortedModuleNames(mods map[string]*Module, revisions bool) []string {
+	var names []string
+	for name, m := range mods {
+		if name == m.Name || revisions && mods[m.Name] != m {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // FindModule returns the Module/Submodule specified by n, which must be a
 // *Include or *Import.  If n is a *Include then a submodule is returned.  If n
 // is a *Import then a module is returned.
+//
+// A module or submodule that has not already been read into ms is read by
+// the ModuleReader of ms, if set, or else, as by Read, from the current
+// directory or the first directory of Path that has it.  If it is not found
+// there, and ms has no ModuleReader, the directory of the file that n is in
+// is searched, and then the import resolver of ms, if set, is used.
 func (ms *Modules) FindModule(n Node) *Module {
 	name := n.NName()
 	rev := name
+	var revision string
 	var m map[string]*Module
 
 	switch i := n.(type) {
 	case *Include:
 		m = ms.SubModules
 		if i.RevisionDate != nil {
-			rev = name + "@" + i.RevisionDate.Name
+			revision = i.RevisionDate.Name
+			rev = name + "@" + revision
 		}
-		// TODO(borman): we should check the BelongsTo field below?
 	case *Import:
 		m = ms.Modules
 		if i.RevisionDate != nil {
-			rev = name + "@" + i.RevisionDate.Name
+			revision = i.RevisionDate.Name
+			rev = name + "@" + revision
 		}
 	default:
 		return nil
@@ -184,8 +464,14 @@ func (ms *Modules) FindModule(n Node) *Module {
 		return n
 	}
 
-	// Try to read it in.
-	if err := ms.Read(name); err != nil {
+	// Try to read it in, falling back to the directory of the file n is
+	// in, as an imported or included file is often alongside the file
+	// that refers to it.  A ModuleReader is used without searching.
+	var dirs []string
+	if f := n.Statement().file; f != "" && ms.reader == nil {
+		dirs = []string{filepath.Dir(f)}
+	}
+	if err := ms.load(name, revision, dirs); err != nil {
 		return nil
 	}
 	if n := m[rev]; n != nil {
@@ -272,6 +558,18 @@ func (ms *Modules) process() []error {
 	for _, m := range mods {
 		if err := ms.include(m); err != nil {
 			errs = append(errs, err)
+			if ms.failFast {
+				return errs
+			}
+		}
+	}
+	// Report import cycles before processing the modules any further, as
+	// the errors they would cause later are harder to understand.  Import
+	// cycles were historically accepted, so unless they are rejected they
+	// are only reported as warnings.
+	if ParseOptions.RejectImportCycles {
+		if err := ms.CyclicDependencyCheck(); err != nil {
+			return append(errs, err)
 		}
 	}
 
@@ -279,8 +577,11 @@ func (ms *Modules) process() []error {
 	// typedef that has an identityref within it, then the identity dictionary
 	// has not yet been built.
 	errs = append(errs, ms.resolveIdentities()...)
+	if ms.failFast && len(errs) > 0 {
+		return errs
+	}
 	// Append any errors found trying to resolve typedefs
-	errs = append(errs, resolveTypedefs()...)
+	errs = append(errs, resolveTypedefs(ms.failFast)...)
 
 	return errs
 }
@@ -313,27 +614,38 @@ func (ms *Modules) process() []error {
 // Process may return multiple errors if multiple errors were encountered
 // while processing.  Even though multiple errors may be returned, this does
 // not mean these are all the errors.  Process will terminate processing early
-// based on the type and location of the error.
+// based on the type and location of the error.  If SetFailFast has been
+// called with true, Process stops at, and returns only, the first error it
+// finds, leaving the Entry trees incomplete.
 func (ms *Modules) Process() []error {
 	// Reset globals that may remain stale if multiple Process() calls are
 	// made by the same caller.
 	mergedSubmodule = map[string]bool{}
 	entryCache = map[Node]*Entry{}
 
+	// Warnings are reported whether or not there are errors, for whatever
+	// could be processed.
+	defer func() { ms.warnings = ms.lint() }()
+
 	errs := ms.process()
 	if len(errs) > 0 {
-		return errorSort(errs)
+		return ms.processErrors(errs)
 	}
+	failed := func() bool { return ms.failFast && len(errs) > 0 }
 
 	for _, m := range ms.Modules {
-		errs = append(errs, ToEntry(m).GetErrors()...)
+		if errs = append(errs, toEntry(m, &entryState{failFast: ms.failFast}).GetErrors()...); failed() {
+			return ms.processErrors(errs)
+		}
 	}
 	for _, m := range ms.SubModules {
-		errs = append(errs, ToEntry(m).GetErrors()...)
+		if errs = append(errs, toEntry(m, &entryState{failFast: ms.failFast}).GetErrors()...); failed() {
+			return ms.processErrors(errs)
+		}
 	}
 
 	if len(errs) > 0 {
-		return errorSort(errs)
+		return ms.processErrors(errs)
 	}
 
 	// Now handle all the augments.  We don't have a good way to know
@@ -350,7 +662,10 @@ func (ms *Modules) Process() []error {
 		var processed int
 		for i := 0; i < len(mods); {
 			m := mods[i]
-			p, s := ToEntry(m).Augment(false)
+			p, s, err := ToEntry(m).augment(false, ms.failFast)
+			if err != nil {
+				return ms.processErrors(append(errs, err))
+			}
 			processed += p
 			if s == 0 {
 				mods[i] = mods[len(mods)-1]
@@ -376,8 +691,12 @@ func (ms *Modules) Process() []error {
 	// Go through any modules that have remaining augments and collect
 	// the errors.
 	for _, m := range mods {
-		ToEntry(m).Augment(true)
-		errs = append(errs, ToEntry(m).GetErrors()...)
+		if _, _, err := ToEntry(m).augment(true, ms.failFast); err != nil {
+			return ms.processErrors(append(errs, err))
+		}
+		if errs = append(errs, ToEntry(m).GetErrors()...); failed() {
+			return ms.processErrors(errs)
+		}
 	}
 
 	// The deviation statement is only valid under a module or submodule,
@@ -385,23 +704,62 @@ func (ms *Modules) Process() []error {
 	// rather we can just walk all modules and submodules *after* entries
 	// are resolved. This means we do not need to concern ourselves that
 	// an entry does not exist.
-	dvP := map[string]bool{} // cache the modules we've handled since we have both modname and modname@revision-date
+	dvP := map[*Module]bool{} // cache the modules we've handled since we have both modname and modname@revision-date
 	for _, devmods := range []map[string]*Module{ms.Modules, ms.SubModules} {
 		for _, m := range devmods {
-			e := ToEntry(m)
-			if !dvP[e.Name] {
-				errs = append(errs, e.ApplyDeviate()...)
-				dvP[e.Name] = true
+			if !dvP[m] {
+				if errs = append(errs, ToEntry(m).applyDeviate(ms.failFast)...); failed() {
+					return ms.processErrors(errs)
+				}
+				dvP[m] = true
 			}
 		}
 	}
 
-	return errorSort(errs)
+	// When statements can only be evaluated, and lists and choices can only
+	// be checked, once the tree is complete, as nodes may be added or removed
+	// by augments and deviations.
+	lkP := map[string]bool{}
+	for _, m := range ms.Modules {
+		e := ToEntry(m)
+		if !lkP[e.Name] {
+			if ParseOptions.PruneStaticWhen {
+				for e.pruneStaticWhen() {
+				}
+			}
+			errs = append(errs, e.checkSchema(true)...)
+			if ParseOptions.FlattenChoices {
+				errs = append(errs, e.flattenChoices()...)
+			}
+			if failed() {
+				return ms.processErrors(errs)
+			}
+			SyncOrderedDir(e)
+			lkP[e.Name] = true
+		}
+	}
+
+	// The must statements of a module may refer to the nodes of any module,
+	// so they can only be indexed once all the trees are complete.
+	ms.indexMusts()
+
+	return ms.processErrors(errs)
+}
+
+// processErrors returns errs, the errors found by Process, sorted, or only
+// the first of them if ms is set to fail fast.
+func (ms *Modules) processErrors(errs []error) []error {
+	errs = errorSort(errs)
+	if ms.failFast && len(errs) > 1 {
+		errs = errs[:1]
+	}
+	return errs
 }
 
 // include resolves all the include and import statements for m.  It returns
 // an error if m, or recursively, any of the modules it includes or imports,
-// reference a module that cannot be found.
+// reference a module that cannot be found, include a submodule that does not
+// belong to the module of m, or import a submodule.
 func (ms *Modules) include(m *Module) error {
 	if ms.includes[m] {
 		return nil
@@ -411,8 +769,17 @@ func (ms *Modules) include(m *Module) error {
 	// First process any includes in this module.
 	for _, i := range m.Include {
 		im := ms.FindModule(i)
-		if im == nil {
+		switch {
+		case im == nil && ms.Modules[i.Name] != nil:
+			return fmt.Errorf("%s: included %s is a module, not a submodule", Source(i), i.Name)
+		case im == nil:
 			return fmt.Errorf("no such submodule: %s", i.Name)
+		case im.BelongsTo == nil || im.BelongsTo.Name != moduleName(m):
+			var owner string
+			if im.BelongsTo != nil {
+				owner = im.BelongsTo.Name
+			}
+			return fmt.Errorf("%s: included submodule %s belongs to module %q, not %s", Source(i), i.Name, owner, moduleName(m))
 		}
 		// Process the include statements in our included module.
 		if err := ms.include(im); err != nil {
@@ -425,7 +792,10 @@ func (ms *Modules) include(m *Module) error {
 	// when searching.
 	for _, i := range m.Import {
 		im := ms.FindModule(i)
-		if im == nil {
+		switch {
+		case im == nil && ms.SubModules[i.Name] != nil:
+			return fmt.Errorf("%s: imported %s is a submodule, not a module", Source(i), i.Name)
+		case im == nil:
 			return fmt.Errorf("no such module: %s", i.Name)
 		}
 		// Process the include statements in our included module.
@@ -15,8 +15,16 @@
 package yang
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
 )
 
 var testdataFindModulesText = map[string]string{
@@ -182,3 +190,932 @@ func TestModulesTotalProcess(t *testing.T) {
 		})
 	}
 }
+
+func TestModulesDuplicates(t *testing.T) {
+	tests := []struct {
+		desc       string
+		inMods     []string
+		wantErr    string
+		wantLatest string
+	}{{
+		desc: "duplicate module",
+		inMods: []string{
+			`module foo { prefix "foo"; namespace "urn:foo"; }`,
+			`module foo { prefix "foo"; namespace "urn:foo"; }`,
+		},
+		wantErr: `duplicate module name "foo": first seen at 0.yang:1:1, second at 1.yang:1:1`,
+	}, {
+		desc: "duplicate module with revision",
+		inMods: []string{
+			`module foo { prefix "foo"; namespace "urn:foo"; revision 2020-01-01; }`,
+			`module foo { prefix "foo"; namespace "urn:foo"; revision 2020-01-01; }`,
+		},
+		wantErr: `duplicate module name "foo@2020-01-01"`,
+	}, {
+		desc: "duplicate submodule",
+		inMods: []string{
+			`submodule sub { belongs-to foo { prefix "foo"; } }`,
+			`submodule sub { belongs-to foo { prefix "foo"; } }`,
+		},
+		wantErr: `duplicate submodule name "sub"`,
+	}, {
+		desc: "different revisions",
+		inMods: []string{
+			`module foo { prefix "foo"; namespace "urn:foo"; revision 2020-01-01; }`,
+			`module foo { prefix "foo"; namespace "urn:foo"; revision 2021-01-01; }`,
+			`module foo { prefix "foo"; namespace "urn:foo"; revision 2019-01-01; }`,
+		},
+		wantLatest: "2021-01-01",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			var err error
+			for i, m := range tt.inMods {
+				if err = ms.Parse(m, fmt.Sprintf("%d.yang", i)); err != nil {
+					break
+				}
+			}
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("Parse: %s", diff)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+
+			e, errs := ms.GetModuleRevision("foo", "")
+			if errs != nil {
+				t.Fatalf("GetModuleRevision(foo, \"\"): %v", errs)
+			}
+			if got := e.Node.(*Module).Current(); got != tt.wantLatest {
+				t.Errorf("GetModuleRevision(foo, \"\"): got revision %s, want %s", got, tt.wantLatest)
+			}
+			for _, rev := range []string{"2019-01-01", "2020-01-01", "2021-01-01"} {
+				e, errs := ms.GetModuleRevision("foo", rev)
+				if errs != nil {
+					t.Errorf("GetModuleRevision(foo, %s): %v", rev, errs)
+					continue
+				}
+				if got := e.Node.(*Module).Current(); got != rev {
+					t.Errorf("GetModuleRevision(foo, %s): got revision %s", rev, got)
+				}
+			}
+			if _, errs := ms.GetModuleRevision("foo", "2000-01-01"); errs == nil {
+				t.Errorf("GetModuleRevision(foo, 2000-01-01): did not get expected error")
+			}
+		})
+	}
+}
+
+func TestModulesProcessSchemaChecks(t *testing.T) {
+	tests := []struct {
+		desc            string
+		inModule        string
+		requireListKeys bool
+		wantErrs        []string
+	}{{
+		desc: "valid keys",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { key "a b"; leaf a { type string; } leaf b { type string; } }
+  container state { config false; list keyless { leaf a { type string; } } }
+  notification n { list keyless { leaf a { type string; } } }
+  rpc r { input { list keyless { leaf a { type string; } } } }
+}`,
+	}, {
+		desc: "missing key leaf",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  container c { list l { key "nope"; leaf a { type string; } } }
+}`,
+		wantErrs: []string{`test.yang:2:17: key "nope" is not a child of list /test/c/l`},
+	}, {
+		desc: "key is not a leaf",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { key "c"; container c { leaf a { type string; } } }
+}`,
+		wantErrs: []string{`test.yang:2:3: key "c" of list /test/l is not a leaf`},
+	}, {
+		desc: "key is a leaf in a child container",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { key "a"; container c { leaf a { type string; } } }
+}`,
+		wantErrs: []string{`test.yang:2:3: key "a" is not a child of list /test/l`},
+	}, {
+		desc: "key is mandatory false",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { key "a"; leaf a { type string; mandatory false; } }
+}`,
+		wantErrs: []string{`test.yang:2:21: key "a" of list /test/l cannot be mandatory false`},
+	}, {
+		desc: "config list without key",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { leaf a { type string; } }
+}`,
+	}, {
+		desc: "config list without key when keys are required",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { leaf a { type string; } }
+}`,
+		requireListKeys: true,
+		wantErrs:        []string{`test.yang:2:3: config list /test/l has no key`},
+	}, {
+		desc: "unique names a missing node",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { key "a"; unique "b"; leaf a { type string; } }
+}`,
+		wantErrs: []string{`test.yang:2:3: unique "b" of list /test/l is not a descendant of the list`},
+	}, {
+		desc: "unique names a container",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { key "a"; unique "c"; leaf a { type string; } container c { leaf b { type string; } } }
+}`,
+		wantErrs: []string{`test.yang:2:3: unique "c" of list /test/l is not a leaf`},
+	}, {
+		desc: "choice default is not a case",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  choice ch { default c; leaf a { type string; } }
+}`,
+		wantErrs: []string{`test.yang:2:3: default "c" of choice /test/ch is not a case of the choice`},
+	}, {
+		desc: "mandatory choice with default",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  choice ch { mandatory true; default a; leaf a { type string; } }
+}`,
+		wantErrs: []string{`test.yang:2:3: mandatory choice /test/ch cannot have a default`},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ParseOptions.RequireListKeys = tt.requireListKeys
+			defer func() { ParseOptions.RequireListKeys = false }()
+			ms := NewModules()
+			if err := ms.Parse(tt.inModule, "test.yang"); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var got []string
+			for _, err := range ms.Process() {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("Process (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModulesFailFast(t *testing.T) {
+	tests := []struct {
+		desc     string
+		inModule string
+		wantAll  []string
+		wantFast []string
+	}{{
+		desc: "valid module",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { key "a"; leaf a { type string; } }
+}`,
+	}, {
+		desc: "errors building the entries",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  container c { uses nope; }
+  container d { uses nada; }
+}`,
+		wantAll: []string{
+			"test.yang:2:17: unknown group: nope",
+			"test.yang:3:17: unknown group: nada",
+		},
+		wantFast: []string{"test.yang:2:17: unknown group: nope"},
+	}, {
+		desc: "errors checking the schema",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  list l { key "a"; unique "b"; leaf a { type string; } }
+  list m { key "nope"; leaf a { type string; } }
+}`,
+		wantAll: []string{
+			`test.yang:2:3: unique "b" of list /test/l is not a descendant of the list`,
+			`test.yang:3:3: key "nope" is not a child of list /test/m`,
+		},
+		wantFast: []string{`test.yang:2:3: unique "b" of list /test/l is not a descendant of the list`},
+	}, {
+		desc: "errors applying the augments",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  augment "/t:nope" { leaf a { type string; } }
+  augment "/t:nada" { leaf b { type string; } }
+}`,
+		wantAll: []string{
+			"test.yang:2:3: augment /t:nope not found",
+			"test.yang:3:3: augment /t:nada not found",
+		},
+		wantFast: []string{"test.yang:2:3: augment /t:nope not found"},
+	}, {
+		desc: "errors applying the deviations",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  leaf a { type string; }
+  deviation "/t:nope" { deviate not-supported; }
+  deviation "/t:nada" { deviate not-supported; }
+}`,
+		wantAll: []string{
+			"cannot find target node to deviate, /t:nada",
+			"cannot find target node to deviate, /t:nope",
+		},
+		wantFast: []string{"cannot find target node to deviate, /t:nope"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for _, failFast := range []bool{false, true} {
+				ms := NewModules()
+				ms.SetFailFast(failFast)
+				if err := ms.Parse(tt.inModule, "test.yang"); err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				var got []string
+				for _, err := range ms.Process() {
+					got = append(got, err.Error())
+				}
+				want := tt.wantAll
+				if failFast {
+					want = tt.wantFast
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("Process with fail fast %v (-want, +got):\n%s", failFast, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestModulesFailFastStops(t *testing.T) {
+	tests := []struct {
+		desc     string
+		inModule string
+		// done reports whether the part of the processing that fail
+		// fast skips was done.
+		done func(ms *Modules) bool
+	}{{
+		desc: "building the entries",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  container c { uses nope; }
+  container d { leaf x { type string; } }
+}`,
+		done: func(ms *Modules) bool {
+			return entryCache[ms.Modules["test"].Container[1]] != nil
+		},
+	}, {
+		desc: "applying the deviations",
+		inModule: `module test { prefix "t"; namespace "urn:t";
+  leaf a { type string; }
+  deviation "/t:nope" { deviate not-supported; }
+  deviation "/t:a" { deviate not-supported; }
+}`,
+		done: func(ms *Modules) bool {
+			return ToEntry(ms.Modules["test"]).Find("a") == nil
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for _, failFast := range []bool{false, true} {
+				ms := NewModules()
+				ms.SetFailFast(failFast)
+				if err := ms.Parse(tt.inModule, "test.yang"); err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				if errs := ms.Process(); len(errs) == 0 {
+					t.Fatalf("Process with fail fast %v: got no errors", failFast)
+				}
+				if got := tt.done(ms); got == failFast {
+					t.Errorf("Process with fail fast %v: got done %v, want %v", failFast, got, !failFast)
+				}
+			}
+		})
+	}
+}
+
+func TestModulesImportResolver(t *testing.T) {
+	sources := map[string]string{
+		"resolved-dep": `module resolved-dep { prefix "d"; namespace "urn:d"; include resolved-sub; }`,
+		"resolved-sub": `submodule resolved-sub { belongs-to resolved-dep { prefix "d"; } leaf l { type string; } }`,
+	}
+	var calls []string
+	resolver := func(name string) (string, error) {
+		calls = append(calls, name)
+		if text, ok := sources[name]; ok {
+			return text, nil
+		}
+		return "", fmt.Errorf("unknown module %s", name)
+	}
+
+	ms := NewModules()
+	ms.SetImportResolver(resolver)
+	if err := ms.Parse(`module resolved { prefix "r"; namespace "urn:r"; import resolved-dep { prefix "d"; } }`, "resolved.yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	if diff := cmp.Diff([]string{"resolved-dep", "resolved-sub"}, calls); diff != "" {
+		t.Errorf("resolver calls (-want, +got):\n%s", diff)
+	}
+	if _, errs := ms.GetModule("resolved-dep"); errs != nil {
+		t.Errorf("GetModule(resolved-dep): %v", errs)
+	}
+
+	ms = NewModules()
+	ms.SetImportResolver(resolver)
+	if err := ms.Parse(`module unresolved { prefix "u"; namespace "urn:u"; import missing-dep { prefix "m"; } }`, "unresolved.yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := ms.Process(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "no such module: missing-dep") {
+		t.Errorf("Process with unresolvable import: got %v, want no such module error", errs)
+	}
+	if err := ms.Import("missing-dep", nil); err == nil || !strings.Contains(err.Error(), "resolver: unknown module missing-dep") {
+		t.Errorf("Import(missing-dep): got %v, want resolver error", err)
+	}
+}
+
+// mapReader is a ModuleReader that reads modules from a map of module names,
+// with an optional "@revision" suffix, to YANG source.
+type mapReader struct {
+	sources map[string]string
+	calls   []string
+}
+
+func (r *mapReader) ReadModule(name, revision string) ([]byte, error) {
+	key := name
+	if revision != "" {
+		key += "@" + revision
+	}
+	r.calls = append(r.calls, key)
+	text, ok := r.sources[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown module %s", key)
+	}
+	return []byte(text), nil
+}
+
+func TestModulesModuleReader(t *testing.T) {
+	r := &mapReader{sources: map[string]string{
+		"remote-dep@2020-01-01": `module remote-dep { prefix "d"; namespace "urn:d"; revision 2020-01-01; include remote-sub; }`,
+		"remote-sub":            `submodule remote-sub { belongs-to remote-dep { prefix "d"; } leaf l { type string; } }`,
+	}}
+	ms := NewModules()
+	ms.SetModuleReader(r)
+	if err := ms.Parse(`module remote { prefix "r"; namespace "urn:r"; import remote-dep { prefix "d"; revision-date 2020-01-01; } }`, "remote.yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	if diff := cmp.Diff([]string{"remote-dep@2020-01-01", "remote-sub"}, r.calls); diff != "" {
+		t.Errorf("ReadModule calls (-want, +got):\n%s", diff)
+	}
+	e, errs := ms.GetModule("remote-dep")
+	if errs != nil {
+		t.Fatalf("GetModule(remote-dep): %v", errs)
+	}
+	if e.Dir["l"] == nil {
+		t.Errorf("GetModule(remote-dep): leaf l of remote-sub not found")
+	}
+
+	// The resolver is used if the ModuleReader fails.
+	ms = NewModules()
+	ms.SetModuleReader(r)
+	ms.SetImportResolver(func(name string) (string, error) {
+		return "", fmt.Errorf("not resolved %s", name)
+	})
+	if err := ms.Import("missing-dep", nil); err == nil || !strings.Contains(err.Error(), "unknown module missing-dep; resolver: not resolved missing-dep") {
+		t.Errorf("Import(missing-dep): got %v, want reader and resolver errors", err)
+	}
+}
+
+func TestModulesSourceMap(t *testing.T) {
+	ms := NewModules()
+	ms.SetSourceMap(map[string]string{
+		"models/dep.yang":           `module dep { prefix "d"; namespace "urn:d"; include sub; }`,
+		"sub":                       `submodule sub { belongs-to dep { prefix "d"; } leaf l { type string; } }`,
+		"versioned@2019-01-01.yang": `module versioned { prefix "v"; namespace "urn:v"; revision 2019-01-01; }`,
+		"versioned@2020-01-01.yang": `module versioned { prefix "v"; namespace "urn:v"; revision 2020-01-01; leaf latest { type string; } }`,
+		"pinned@2019-01-01.yang":    `module pinned { prefix "p"; namespace "urn:p"; revision 2019-01-01; leaf old { type string; } }`,
+		"pinned@2020-01-01.yang":    `module pinned { prefix "p"; namespace "urn:p"; revision 2020-01-01; }`,
+	})
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  import dep { prefix d; }
+  import versioned { prefix v; }
+  import pinned { prefix p; revision-date 2019-01-01; }
+}`, "m.yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	for name, leaf := range map[string]string{
+		"dep":       "l",
+		"versioned": "latest",
+		"pinned":    "old",
+	} {
+		m := ms.Modules[name]
+		if name == "pinned" {
+			m = ms.Modules["pinned@2019-01-01"]
+		}
+		if m == nil {
+			t.Errorf("module %s not read", name)
+			continue
+		}
+		if ToEntry(m).Dir[leaf] == nil {
+			t.Errorf("module %s: leaf %s not found", name, leaf)
+		}
+	}
+
+	if err := ms.Import("missing", nil); err == nil || !strings.Contains(err.Error(), "no such module: missing") {
+		t.Errorf("Import(missing): got %v, want no such module", err)
+	}
+}
+
+// errReader is an io.Reader that always fails.
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, fmt.Errorf("read failed") }
+
+func TestModulesParseReader(t *testing.T) {
+	ms := NewModules()
+	if err := ms.ParseReader(strings.NewReader(`module r { prefix "r"; namespace "urn:r"; leaf l { type string; } }`), "r.yang"); err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	if ms.Modules["r"] == nil {
+		t.Errorf("module r not added by ParseReader")
+	}
+
+	if err := ms.ParseReader(strings.NewReader(`module bad { prefix "b"; namespace "urn:b"; bogus; }`), "bad.yang"); err == nil {
+		t.Errorf("ParseReader of invalid YANG did not return an error")
+	}
+	err := ms.ParseReader(errReader{}, "fail.yang")
+	if diff := errdiff.Substring(err, "fail.yang: read failed"); diff != "" {
+		t.Errorf("ParseReader of failing reader: %s", diff)
+	}
+}
+
+func TestModulesImport(t *testing.T) {
+	// disable any readFile or scanDir mock setup by other tests
+	readFile = ioutil.ReadFile
+	scanDir = findInDir
+
+	dir, err := ioutil.TempDir("", "goyang-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "on-disk@2020-01-01.yang"), []byte(`module on-disk { prefix "o"; namespace "urn:o"; }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ms := NewModules()
+	if err := ms.Import("on-disk", []string{dir}); err != nil {
+		t.Fatalf("Import(on-disk): %v", err)
+	}
+	if ms.Modules["on-disk"] == nil {
+		t.Errorf("Import(on-disk): module was not added")
+	}
+	// Importing the module again must not report a duplicate.
+	if err := ms.Import("on-disk", []string{dir}); err != nil {
+		t.Errorf("second Import(on-disk): %v", err)
+	}
+	if err := ms.Import("not-on-disk", []string{dir}); err == nil {
+		t.Errorf("Import(not-on-disk): did not get expected error")
+	}
+}
+
+func TestModuleStringAccessors(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module full {
+  prefix "f";
+  namespace "urn:f";
+  organization "Example Org";
+  contact "noc@example.com";
+  description "A full module.";
+  reference "RFC 0000";
+}`, "full.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.Parse(`module bare { prefix "b"; namespace "urn:b"; }`, "bare.yang"); err != nil {
+		t.Fatal(err)
+	}
+
+	type accessors struct{ Organization, Contact, Description, Reference string }
+	for name, want := range map[string]accessors{
+		"full": {"Example Org", "noc@example.com", "A full module.", "RFC 0000"},
+		"bare": {},
+	} {
+		m := ms.Modules[name]
+		got := accessors{m.OrganizationString(), m.ContactString(), m.DescriptionString(), m.ReferenceString()}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: string accessors (-want, +got):\n%s", name, diff)
+		}
+		if org, contact := m.OrganizationAndContact(); org != want.Organization || contact != want.Contact {
+			t.Errorf("%s: OrganizationAndContact() = %q, %q, want %q, %q", name, org, contact, want.Organization, want.Contact)
+		}
+	}
+}
+
+func TestModuleSortedDefinitions(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module defs {
+  prefix "d";
+  namespace "urn:d";
+  typedef zeta { type string; }
+  typedef alpha { type string; }
+  grouping red { leaf r { type string; } }
+  grouping blue { leaf b { type string; } }
+  identity second;
+  identity first;
+  feature on;
+  feature off;
+  extension tag;
+  extension note;
+}`, "defs.yang"); err != nil {
+		t.Fatal(err)
+	}
+	m := ms.Modules["defs"]
+
+	type names struct{ Typedefs, Groupings, Identities, Features, Extensions []string }
+	var got names
+	for _, n := range m.SortedTypedefs() {
+		got.Typedefs = append(got.Typedefs, n.Name)
+	}
+	for _, n := range m.SortedGroupings() {
+		got.Groupings = append(got.Groupings, n.Name)
+	}
+	for _, n := range m.SortedIdentities() {
+		got.Identities = append(got.Identities, n.Name)
+	}
+	for _, n := range m.SortedFeatures() {
+		got.Features = append(got.Features, n.Name)
+	}
+	for _, n := range m.SortedExtensions() {
+		got.Extensions = append(got.Extensions, n.Name)
+	}
+	want := names{
+		Typedefs:   []string{"alpha", "zeta"},
+		Groupings:  []string{"blue", "red"},
+		Identities: []string{"first", "second"},
+		Features:   []string{"off", "on"},
+		Extensions: []string{"note", "tag"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("sorted definitions (-want, +got):\n%s", diff)
+	}
+
+	// The fields of the module keep the order of definition.
+	if got := m.Typedef[0].Name; got != "zeta" {
+		t.Errorf("first Typedef is %s, want zeta", got)
+	}
+}
+
+func TestModulesDuplicateNodes(t *testing.T) {
+	base := `module base {
+  prefix "b";
+  namespace "urn:b";
+  container c {
+    leaf a { type string; }
+  }
+}`
+	tests := []struct {
+		desc     string
+		inMods   map[string]string
+		wantErrs []string
+	}{{
+		desc: "duplicate leaves",
+		inMods: map[string]string{
+			"base": `module base { prefix "b"; namespace "urn:b";
+  container c {
+    leaf a { type string; }
+    leaf a { type int8; }
+  }
+}`,
+		},
+		wantErrs: []string{"base.yang:2:3: duplicate key from base.yang:4:5: a (first defined at base.yang:3:5)"},
+	}, {
+		desc: "leaf and grouping",
+		inMods: map[string]string{
+			"base": `module base { prefix "b"; namespace "urn:b";
+  grouping g { leaf a { type int8; } }
+  container c {
+    leaf a { type string; }
+    uses g;
+  }
+}`,
+		},
+		wantErrs: []string{"base.yang:3:3: duplicate key from base.yang:4:5: a (first defined at base.yang:2:16)"},
+	}, {
+		desc: "augment from another module",
+		inMods: map[string]string{
+			"base": base,
+			"aug": `module aug { prefix "a"; namespace "urn:a";
+  import base { prefix "b"; }
+  augment "/b:c" { leaf a { type int8; } }
+}`,
+		},
+		wantErrs: []string{`aug.yang:3:20: augment from module aug adds "a" to /base/c, which is already defined at base.yang:5:5 by module base`},
+	}, {
+		desc: "augment without collision",
+		inMods: map[string]string{
+			"base": base,
+			"aug": `module aug { prefix "a"; namespace "urn:a";
+  import base { prefix "b"; }
+  augment "/b:c" { leaf other { type int8; } }
+}`,
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for name, text := range tt.inMods {
+				if err := ms.Parse(text, name+".yang"); err != nil {
+					t.Fatalf("Parse(%s): %v", name, err)
+				}
+			}
+			var got []string
+			for _, err := range ms.Process() {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("Process (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	parse := func(t *testing.T, mods map[string]string) *Modules {
+		t.Helper()
+		ms := NewModules()
+		for name, text := range mods {
+			if err := ms.Parse(text, name+".yang"); err != nil {
+				t.Fatalf("Parse %s: %v", name, err)
+			}
+		}
+		return ms
+	}
+	vendor := map[string]string{
+		"base": `module base { prefix "b"; namespace "urn:b";
+  include base-sub;
+  revision 2020-01-01;
+  container c { leaf a { type string; } }
+}`,
+		"base-sub": `submodule base-sub { belongs-to base { prefix "b"; }
+  container s {}
+}`,
+	}
+	operator := map[string]string{
+		"ops": `module ops { prefix "o"; namespace "urn:o";
+  import base { prefix "b"; }
+  augment "/b:c" { leaf added { type string; } }
+  deviation "/b:c/b:a" { deviate not-supported; }
+}`,
+	}
+
+	t.Run("augment and deviate", func(t *testing.T) {
+		base, overlay := parse(t, vendor), parse(t, operator)
+		ms, err := Merge(base, overlay)
+		if err != nil {
+			t.Fatalf("Merge: %v", err)
+		}
+		if errs := ms.Process(); errs != nil {
+			t.Fatalf("Process: %v", errs)
+		}
+		var got []string
+		for _, e := range ToEntry(ms.Modules["base"]).Dir["c"].OrderedChildren() {
+			got = append(got, e.Name)
+		}
+		if diff := cmp.Diff([]string{"added"}, got); diff != "" {
+			t.Errorf("children of /base/c (-want, +got):\n%s", diff)
+		}
+		if ms.Modules["base@2020-01-01"] == nil || ms.SubModules["base-sub"] == nil {
+			t.Errorf("Merge: missing base or base-sub, got modules %v, submodules %v", ms.Modules, ms.SubModules)
+		}
+		if ms.Modules["base"] == base.Modules["base"] {
+			t.Errorf("Merge: module base is shared with base")
+		}
+		if len(base.Modules) != 2 || len(overlay.Modules) != 1 {
+			t.Errorf("Merge changed its arguments: base has %d modules, overlay has %d", len(base.Modules), len(overlay.Modules))
+		}
+	})
+
+	t.Run("other revision", func(t *testing.T) {
+		older := map[string]string{
+			"base": `module base { prefix "b"; namespace "urn:b";
+  revision 2019-01-01;
+}`,
+		}
+		ms, err := Merge(parse(t, vendor), parse(t, older))
+		if err != nil {
+			t.Fatalf("Merge: %v", err)
+		}
+		if ms.Modules["base@2019-01-01"] == nil || ms.Modules["base"] != ms.Modules["base@2020-01-01"] {
+			t.Errorf("Merge: got modules %v, want both revisions of base with base the latest", ms.Modules)
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		_, err := Merge(parse(t, vendor), parse(t, vendor))
+		if diff := errdiff.Substring(err, "duplicate"); diff != "" {
+			t.Errorf("Merge: %s", diff)
+		}
+	})
+}
+
+func TestModulesReset(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module a {
+  prefix "a";
+  namespace "urn:a";
+  include s;
+  identity base;
+  identity x { base base; }
+  container c { leaf l { type identityref { base base; } } }
+}`, "a.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.Parse(`submodule s {
+  belongs-to a { prefix "a"; }
+  identity z { base base; }
+}`, "s.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	oldA := ms.Modules["a"]
+	oldC := ToEntry(oldA).Dir["c"]
+
+	// Parse a module that augments a after a has been processed.
+	if err := ms.Parse(`module b {
+  prefix "b";
+  namespace "urn:b";
+  import a { prefix a; }
+  identity y { base a:base; }
+  augment /a:c { leaf m { type string; } }
+}`, "b.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if ms.Modules["a"] == oldA {
+		t.Errorf("Reset did not rebuild module a")
+	}
+	if ms.SubModules["s"] == nil || ms.Modules["b"] == nil {
+		t.Fatalf("Reset lost modules: modules %v, submodules %v", ms.Modules, ms.SubModules)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process after Reset: %v", errs)
+	}
+
+	c := ToEntry(ms.Modules["a"]).Dir["c"]
+	var got []string
+	for k := range c.Dir {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	if diff := cmp.Diff([]string{"l", "m"}, got); diff != "" {
+		t.Errorf("children of c after Reset (-want, +got):\n%s", diff)
+	}
+	var ids []string
+	for _, v := range c.Dir["l"].Type.IdentityBase.Values {
+		ids = append(ids, v.Name)
+	}
+	sort.Strings(ids)
+	if diff := cmp.Diff([]string{"x", "y", "z"}, ids); diff != "" {
+		t.Errorf("identities derived from base after Reset (-want, +got):\n%s", diff)
+	}
+	// The entries of the earlier Process are not changed.
+	if oldC.Dir["m"] != nil {
+		t.Errorf("Reset and Process changed the entries of the earlier Process")
+	}
+}
+
+func TestModulesIncludeResolution(t *testing.T) {
+	defer testPathReset()
+	// disable any readFile or scanDir mock setup by other tests
+	readFile = ioutil.ReadFile
+	scanDir = findInDir
+
+	dir, err := ioutil.TempDir("", "goyang-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, text := range map[string]string{
+		"sub@2019-01-01.yang": `submodule sub { belongs-to main { prefix "m"; } revision 2019-01-01; leaf old { type string; } }`,
+		"sub@2020-01-01.yang": `submodule sub { belongs-to main { prefix "m"; } revision 2020-01-01; leaf new { type string; } }`,
+		"other-sub.yang":      `submodule other-sub { belongs-to other { prefix "o"; } }`,
+		"not-sub.yang":        `module not-sub { prefix "n"; namespace "urn:n"; }`,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		desc       string
+		in         string
+		wantLeaf   string
+		wantErrSub string
+	}{{
+		desc:     "submodule alongside the including module",
+		in:       `module main { prefix "m"; namespace "urn:m"; include sub; }`,
+		wantLeaf: "new",
+	}, {
+		desc:     "revision of submodule alongside the including module",
+		in:       `module main { prefix "m"; namespace "urn:m"; include sub { revision-date 2019-01-01; } }`,
+		wantLeaf: "old",
+	}, {
+		desc:       "included module",
+		in:         `module main { prefix "m"; namespace "urn:m"; include not-sub; }`,
+		wantErrSub: "main.yang:1:46: included not-sub is a module, not a submodule",
+	}, {
+		desc:       "submodule of another module",
+		in:         `module main { prefix "m"; namespace "urn:m"; include other-sub; }`,
+		wantErrSub: `main.yang:1:46: included submodule other-sub belongs to module "other", not main`,
+	}, {
+		desc:       "imported submodule",
+		in:         `module main { prefix "m"; namespace "urn:m"; import sub { prefix "s"; } }`,
+		wantErrSub: "main.yang:1:46: imported sub is a submodule, not a module",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(tt.in, filepath.Join(dir, "main.yang")); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			errs := ms.Process()
+			var err error
+			if len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("Process: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if e := ToEntry(ms.Modules["main"]); e.Dir[tt.wantLeaf] == nil {
+				t.Errorf("leaf %s of the included submodule not found, got %v", tt.wantLeaf, e.Dir)
+			}
+		})
+	}
+}
+
+func TestModulesFindModulePrecedence(t *testing.T) {
+	defer testPathReset()
+	readFile = ioutil.ReadFile
+	scanDir = findInDir
+
+	root, err := ioutil.TempDir("", "goyang-precedence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	pathDir := filepath.Join(root, "path")
+	importerDir := filepath.Join(root, "importer")
+	for name, text := range map[string]string{
+		filepath.Join(pathDir, "dep.yang"):          `module dep { prefix "d"; namespace "urn:d"; leaf from-path { type string; } }`,
+		filepath.Join(importerDir, "dep.yang"):      `module dep { prefix "d"; namespace "urn:d"; leaf from-importer { type string; } }`,
+		filepath.Join(importerDir, "only-dep.yang"): `module only-dep { prefix "o"; namespace "urn:o"; leaf from-importer { type string; } }`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	AddPath(pathDir)
+
+	tests := []struct {
+		dep      string
+		wantLeaf string
+	}{
+		{dep: "dep", wantLeaf: "from-path"},
+		{dep: "only-dep", wantLeaf: "from-importer"},
+	}
+	for _, tt := range tests {
+		ms := NewModules()
+		in := `module main { prefix "m"; namespace "urn:m"; import ` + tt.dep + ` { prefix "d"; } }`
+		if err := ms.Parse(in, filepath.Join(importerDir, "main.yang")); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if errs := ms.Process(); errs != nil {
+			t.Fatalf("Process: %v", errs)
+		}
+		if e := ToEntry(ms.Modules[tt.dep]); e.Dir[tt.wantLeaf] == nil {
+			t.Errorf("%s: got leaves %v, want %s", tt.dep, e.Dir, tt.wantLeaf)
+		}
+	}
+}
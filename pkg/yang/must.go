@@ -0,0 +1,150 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the must statements that constrain entries.
+
+import (
+	"reflect"
+)
+
+// A MustCondition is a must statement that constrains the instances of an
+// entry.
+type MustCondition struct {
+	XPath string          // the expression of the must statement
+	Error ConstraintError // the error-message and error-app-tag, if any
+	Must  *Must           // the must statement
+}
+
+// MustConditions returns the must statements of e, in the order they are
+// defined, followed by those added to e by refine statements.
+func (e *Entry) MustConditions() []MustCondition {
+	var musts []*Must
+	if e.Node != nil {
+		v := reflect.ValueOf(e.Node)
+		if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+			if f := v.Elem().FieldByName("Must"); f.IsValid() {
+				musts, _ = f.Interface().([]*Must)
+			}
+		}
+	}
+	for _, x := range e.Extra["must"] {
+		if m, ok := x.([]*Must); ok {
+			musts = append(musts, m...)
+		}
+	}
+
+	var conds []MustCondition
+	seen := map[*Must]bool{}
+	for _, m := range musts {
+		if m == nil || seen[m] {
+			continue
+		}
+		seen[m] = true
+		conds = append(conds, MustCondition{
+			XPath: m.Name,
+			Error: constraintError(m.ErrorMessage, m.ErrorAppTag),
+			Must:  m,
+		})
+	}
+	return conds
+}
+
+// RequiredBy returns the entries with must statements whose expressions
+// refer to e, ordered by module name and then in the order the entries are
+// defined.  An expression refers to each node named by a step of its
+// location paths, including the steps of their predicates, so that a must
+// of "../a/b" refers to both a and b.  Steps that cannot be followed in the
+// schema tree, such as those along the descendant axis, and the rest of the
+// path after them, refer to no nodes.  The index is built by Process, and
+// RequiredBy returns nil before Process is called.
+func (e *Entry) RequiredBy() []*Entry {
+	return append([]*Entry(nil), e.requiredBy...)
+}
+
+// indexMusts records, in each entry of the modules of ms, the entries with
+// must statements that refer to it.
+func (ms *Modules) indexMusts() {
+	index := func(e *Entry) bool {
+		seen := map[*Entry]bool{}
+		for _, c := range e.MustConditions() {
+			paths, err := xpathPaths(c.XPath)
+			if err != nil {
+				continue
+			}
+			for _, lp := range paths {
+				e.mustRefs(c.Must, e, lp, func(t *Entry) {
+					if !seen[t] {
+						seen[t] = true
+						t.requiredBy = append(t.requiredBy, e)
+					}
+				})
+			}
+		}
+		return true
+	}
+	for _, name := range ms.moduleNames() {
+		ToEntry(ms.Modules[name]).walk(index)
+	}
+}
+
+// mustRefs calls visit with each node named by a step of lp, a location path
+// of the must statement m of e, evaluated with ctx as the context node.
+func (e *Entry) mustRefs(m *Must, ctx *Entry, lp *xpathPath, visit func(*Entry)) {
+	t := ctx
+	switch {
+	case lp.absolute:
+		for t.Parent != nil {
+			t = t.Parent
+		}
+	case lp.current:
+		t = e
+	}
+	for i, st := range lp.steps {
+		switch st.name {
+		case "":
+			return
+		case ".":
+		case "..":
+			if t = t.DataParent(); t == nil {
+				return
+			}
+		default:
+			prefix, name := getPrefix(st.name)
+			if i == 0 && lp.absolute && prefix != "" {
+				// The first step of an absolute path may name a top level
+				// node of another module.
+				mod := FindModuleByPrefix(m, prefix)
+				if mod == nil {
+					return
+				}
+				if mn := moduleName(mod); mn != t.Name {
+					rm := e.Modules().Modules[mn]
+					if rm == nil {
+						return
+					}
+					t = ToEntry(rm)
+				}
+			}
+			if t = t.dataChild(name); t == nil {
+				return
+			}
+			visit(t)
+		}
+		for _, pp := range st.preds {
+			e.mustRefs(m, t, pp, visit)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConstraintErrors(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef name {
+    type string {
+      length "1..64" { error-message "too long"; }
+      pattern "[a-z]+" {
+        error-message "lowercase letters only";
+        error-app-tag "bad-name";
+      }
+    }
+  }
+  grouping g {
+    leaf g { type string; }
+  }
+  container c {
+    must "count(l) > 0" { error-message "l is missing"; }
+    must "true()";
+    leaf l {
+      type name {
+        pattern "[a-m]+";
+      }
+    }
+    leaf n {
+      type int8 { range "0..10" { error-app-tag "out-of-range"; } }
+      must ". != 5" { error-app-tag "five"; }
+    }
+    uses g {
+      refine g { must ". != 'x'" { error-message "not x"; } }
+    }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	c := ToEntry(ms.Modules["m"]).Dir["c"]
+
+	l := c.Dir["l"].Type
+	if got, want := l.PatternError("[a-z]+"), (ConstraintError{Message: "lowercase letters only", AppTag: "bad-name"}); got != want {
+		t.Errorf("PatternError([a-z]+) = %+v, want %+v", got, want)
+	}
+	if got := l.PatternError("[a-m]+"); got != (ConstraintError{}) {
+		t.Errorf("PatternError([a-m]+) = %+v, want none", got)
+	}
+	if got, want := l.LengthError(), (ConstraintError{Message: "too long"}); got != want {
+		t.Errorf("LengthError() = %+v, want %+v", got, want)
+	}
+	if got, want := c.Dir["n"].Type.RangeError(), (ConstraintError{AppTag: "out-of-range"}); got != want {
+		t.Errorf("RangeError() = %+v, want %+v", got, want)
+	}
+
+	type must struct {
+		XPath string
+		Error ConstraintError
+	}
+	for _, tt := range []struct {
+		entry *Entry
+		want  []must
+	}{{
+		entry: c,
+		want: []must{
+			{XPath: "count(l) > 0", Error: ConstraintError{Message: "l is missing"}},
+			{XPath: "true()"},
+		},
+	}, {
+		entry: c.Dir["n"],
+		want:  []must{{XPath: ". != 5", Error: ConstraintError{AppTag: "five"}}},
+	}, {
+		entry: c.Dir["g"],
+		want:  []must{{XPath: ". != 'x'", Error: ConstraintError{Message: "not x"}}},
+	}, {
+		entry: c.Dir["l"],
+	}} {
+		var got []must
+		for _, m := range tt.entry.MustConditions() {
+			got = append(got, must{XPath: m.XPath, Error: m.Error})
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: MustConditions (-want, +got):\n%s", tt.entry.Path(), diff)
+		}
+	}
+}
+
+func TestRequiredBy(t *testing.T) {
+	ms := NewModules()
+	for name, src := range map[string]string{
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  container c {
+    leaf enabled { type boolean; }
+    leaf mtu {
+      type uint16;
+      must "../enabled = 'true' or . < 1500";
+    }
+    list iface {
+      key "name";
+      leaf name { type string; }
+      leaf speed { type uint32; }
+      choice media {
+        leaf fiber { type string; }
+      }
+    }
+    leaf primary {
+      type string;
+      must "../iface[name = current()/../backup]/speed > 0";
+    }
+    leaf backup { type string; }
+    leaf any {
+      type string;
+      must "count(//a:name) > 0 and not(@x) and ../iface/fiber";
+    }
+  }
+}`,
+		"b.yang": `module b {
+  prefix "b";
+  namespace "urn:b";
+  import a { prefix x; }
+  container state {
+    must "/x:c/x:mtu > 0";
+    leaf s { type string; }
+  }
+}`,
+	} {
+		if err := ms.Parse(src, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	c := ToEntry(ms.Modules["a"]).Dir["c"]
+	iface := c.Dir["iface"]
+
+	tests := []struct {
+		e    *Entry
+		want []string
+	}{
+		{e: c.Dir["enabled"], want: []string{"/a/c/mtu"}},
+		{e: c.Dir["mtu"], want: []string{"/b/state"}},
+		{e: c, want: []string{"/b/state"}},
+		{e: iface, want: []string{"/a/c/primary", "/a/c/any"}},
+		{e: iface.Dir["name"], want: []string{"/a/c/primary"}},
+		{e: iface.Dir["speed"], want: []string{"/a/c/primary"}},
+		{e: iface.Dir["media"].Dir["fiber"].Dir["fiber"], want: []string{"/a/c/any"}},
+		{e: c.Dir["backup"], want: []string{"/a/c/primary"}},
+		{e: c.Dir["primary"]},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, r := range tt.e.RequiredBy() {
+			got = append(got, r.Path())
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: RequiredBy (-want, +got):\n%s", tt.e.Path(), diff)
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/openconfig/goyang/pkg/indent"
@@ -38,6 +39,13 @@ type Node interface {
 	ParentNode() Node
 	// Exts returns the list of extension statements found.
 	Exts() []*Statement
+	// NumChildren returns the number of children of this Node, the
+	// substatements of this Node that are Nodes.
+	NumChildren() int
+	// NthChild returns the nth child of this Node, in the order of
+	// their statements, or an error if n is not in the range 0 through
+	// NumChildren()-1.  Use Children to visit all the children.
+	NthChild(n int) (Node, error)
 }
 
 // A Typedefer is a Node that defines typedefs.
@@ -53,11 +61,96 @@ type ErrorNode struct {
 	Error error
 }
 
-func (ErrorNode) Kind() string             { return "error" }
-func (s *ErrorNode) ParentNode() Node      { return s.Parent }
-func (s *ErrorNode) NName() string         { return "error" }
-func (s *ErrorNode) Statement() *Statement { return &Statement{} }
-func (s *ErrorNode) Exts() []*Statement    { return nil }
+func (ErrorNode) Kind() string                    { return "error" }
+func (s *ErrorNode) ParentNode() Node             { return s.Parent }
+func (s *ErrorNode) NName() string                { return "error" }
+func (s *ErrorNode) Statement() *Statement        { return &Statement{} }
+func (s *ErrorNode) Exts() []*Statement           { return nil }
+func (s *ErrorNode) NumChildren() int             { return 0 }
+func (s *ErrorNode) NthChild(n int) (Node, error) { return nthChild(s, nil, n) }
+
+// Children returns the children of n, the Nodes that NthChild returns, in
+// order.  Use Children, rather than NthChild, to visit all the children of a
+// node, as NthChild finds all the children of its node each time it is
+// called.
+func Children(n Node) []Node {
+	if s, ok := n.(*Statement); ok {
+		children := make([]Node, len(s.statements))
+		for i, ss := range s.statements {
+			children[i] = ss
+		}
+		return children
+	}
+	return childNodes(n)
+}
+
+// eachChildNode calls f with each of the children of n, the Nodes in the
+// fields of n that hold its substatements, in the order of the fields.
+func eachChildNode(n Node, f func(Node)) {
+	v := reflect.ValueOf(n).Elem()
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		// The substatements are the fields whose names are lower case.
+		name := strings.Split(t.Field(i).Tag.Get("yang"), ",")[0]
+		if name == "" || name[0] < 'a' || name[0] > 'z' {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() != reflect.Slice {
+			fv = reflect.Append(reflect.MakeSlice(reflect.SliceOf(fv.Type()), 0, 1), fv)
+		}
+		for j := 0; j < fv.Len(); j++ {
+			c := fv.Index(j)
+			if c.IsNil() {
+				continue
+			}
+			if cn, ok := c.Interface().(Node); ok {
+				f(cn)
+			}
+		}
+	}
+}
+
+// numChildNodes returns the number of children of n.
+func numChildNodes(n Node) int {
+	count := 0
+	eachChildNode(n, func(Node) { count++ })
+	return count
+}
+
+// childNodes returns the children of n in the order of their statements in
+// the statement of n.  Children without a statement follow those with one.
+func childNodes(n Node) []Node {
+	var children []Node
+	eachChildNode(n, func(c Node) { children = append(children, c) })
+
+	order := map[*Statement]int{}
+	if stmt := n.Statement(); stmt != nil {
+		for i, ss := range stmt.statements {
+			order[ss] = i
+		}
+	}
+	index := func(c Node) int {
+		if i, ok := order[c.Statement()]; ok {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(children, func(i, j int) bool { return index(children[i]) < index(children[j]) })
+	return children
+}
+
+// nthChild returns child i of children, the children of n, or an error if n
+// has no such child.
+func nthChild(n Node, children []Node, i int) (Node, error) {
+	if i < 0 || i >= len(children) {
+		return nil, fmt.Errorf("%s: %s %s has no child %d", Source(n), n.Kind(), n.NName(), i)
+	}
+	return children[i], nil
+}
 
 // isRPCNode is a terrible hack to return back that a path points into
 // an RPC and we should ignore it.
@@ -71,6 +164,15 @@ func Source(n Node) string {
 	return "unknown"
 }
 
+// SubStatements returns the substatements of the statement of n, in the
+// order they appear in the source, or nil if n has no statement.
+func SubStatements(n Node) []*Statement {
+	if n == nil || n.Statement() == nil {
+		return nil
+	}
+	return n.Statement().SubStatements()
+}
+
 // getPrefix returns the prefix and base name of s.  If s has no prefix
 // then the returned prefix is "".
 func getPrefix(s string) (string, string) {
@@ -124,6 +226,52 @@ func FindModuleByPrefix(n Node, prefix string) *Module {
 	return nil
 }
 
+// PrefixMap returns a map from each prefix that may be used within s to the
+// module it refers to: the prefix of s, or of the belongs-to statement of s
+// if s is a submodule, and the prefix of each import of s.  The prefix of a
+// submodule refers to the module the submodule belongs to rather than to the
+// submodule itself, unless that module has not been read.  An import refers
+// to the module selected for it when the modules were processed or, before
+// then, to the module of its name and revision date that has been read.
+// Prefixes of modules that have not been read are left out.
+func (s *Module) PrefixMap() map[string]*Module {
+	pm := map[string]*Module{}
+	for _, i := range s.Import {
+		m := i.Module
+		if m == nil {
+			m = s.loadedModule(i.Name, i.RevisionDate.asString())
+		}
+		if m != nil && i.Prefix != nil {
+			pm[i.Prefix.Name] = m
+		}
+	}
+	switch {
+	case s.BelongsTo != nil:
+		if s.BelongsTo.Prefix != nil {
+			m := s.loadedModule(s.BelongsTo.Name, "")
+			if m == nil {
+				m = s
+			}
+			pm[s.BelongsTo.Prefix.Name] = m
+		}
+	case s.Prefix != nil:
+		pm[s.Prefix.Name] = s
+	}
+	return pm
+}
+
+// loadedModule returns the module named name, with the revision rev if there
+// is one, that has been read into the Modules of s, or nil if there is none.
+func (s *Module) loadedModule(name, rev string) *Module {
+	if s.modules == nil {
+		return nil
+	}
+	if m := s.modules.Modules[name+"@"+rev]; rev != "" && m != nil {
+		return m
+	}
+	return s.modules.Modules[name]
+}
+
 // RootNode returns the submodule or module that n was defined in.
 func RootNode(n Node) *Module {
 	for ; n.ParentNode() != nil; n = n.ParentNode() {
@@ -134,6 +282,20 @@ func RootNode(n Node) *Module {
 	return nil
 }
 
+// moduleName returns the name of the module that n is defined in.  If n is
+// defined in a submodule, the name of the module the submodule belongs to is
+// returned.
+func moduleName(n Node) string {
+	m := RootNode(n)
+	if m == nil {
+		return ""
+	}
+	if m.BelongsTo != nil {
+		return m.BelongsTo.Name
+	}
+	return m.Name
+}
+
 // FindNode finds the node referenced by path relative to n.  If path does not
 // reference a node then nil is returned (i.e. path not found).  The path looks
 // similar to an XPath but curently has no wildcarding.  For example:
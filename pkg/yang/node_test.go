@@ -18,6 +18,8 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 // TestNode provides a framework for processing tests that can check particular
@@ -151,3 +153,210 @@ func TestNode(t *testing.T) {
 		})
 	}
 }
+
+func TestNthChild(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  leaf a { type string; }
+  description "d";
+  container c {
+    leaf b { type string; }
+  }
+  leaf-list d { type string; }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	m := ms.Modules["m"]
+
+	// children returns the keywords and names of the children of n.
+	children := func(n Node) []string {
+		var got []string
+		for i := 0; i < n.NumChildren(); i++ {
+			c, err := n.NthChild(i)
+			if err != nil {
+				t.Fatalf("NthChild(%d): %v", i, err)
+			}
+			got = append(got, c.Kind()+" "+c.NName())
+		}
+		return got
+	}
+	want := []string{"string m", "string urn:m", "leaf a", "string d", "container c", "leaf-list d"}
+	if diff := cmp.Diff(want, children(m)); diff != "" {
+		t.Errorf("children of module (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"leaf b"}, children(m.Container[0])); diff != "" {
+		t.Errorf("children of container (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"prefix m", "namespace urn:m", "leaf a", "description d", "container c", "leaf-list d"}, children(m.Source)); diff != "" {
+		t.Errorf("children of statement (-want, +got):\n%s", diff)
+	}
+	for _, n := range []Node{m, m.Source} {
+		var got []string
+		for _, c := range Children(n) {
+			got = append(got, c.Kind()+" "+c.NName())
+		}
+		if diff := cmp.Diff(children(n), got); diff != "" {
+			t.Errorf("%s: Children differs from NthChild (-NthChild, +Children):\n%s", n.Kind(), diff)
+		}
+	}
+	for _, n := range []Node{m, m.Source} {
+		for _, i := range []int{-1, 6} {
+			if _, err := n.NthChild(i); err == nil {
+				t.Errorf("%s: NthChild(%d) did not return an error", n.Kind(), i)
+			}
+		}
+	}
+}
+
+func TestNodeStatements(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  grouping g {
+    leaf from-group { type string; }
+  }
+  container c {
+    uses g;
+    choice ch {
+      container implied { leaf x { type string; } }
+    }
+    leaf-list ll { type string; max-elements 3; description "d"; }
+  }
+  rpc r {
+    input { leaf in { type string; } }
+    output { leaf out { type string; } }
+  }
+  notification n { leaf event { type string; } }
+  augment "/c" { leaf added { type string; } }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+
+	// Every node of the AST has a statement.
+	var walk func(n Node)
+	walk = func(n Node) {
+		if n.Statement() == nil || n.Statement().Keyword == "" {
+			t.Errorf("%s %s has no statement", n.Kind(), n.NName())
+		}
+		for i := 0; i < n.NumChildren(); i++ {
+			c, err := n.NthChild(i)
+			if err != nil {
+				t.Fatalf("%s %s: NthChild(%d): %v", n.Kind(), n.NName(), i, err)
+			}
+			walk(c)
+		}
+	}
+	walk(ms.Modules["m"])
+
+	// So does every entry, including the synthesized ones.
+	var walkEntry func(e *Entry)
+	walkEntry = func(e *Entry) {
+		if e.Node == nil || e.Node.Statement() == nil {
+			t.Errorf("%s has no statement", e.Path())
+		}
+		for _, c := range e.OrderedChildren() {
+			walkEntry(c)
+		}
+	}
+	mod := ToEntry(ms.Modules["m"])
+	walkEntry(mod)
+
+	for _, tt := range []struct {
+		path string
+		want []string
+	}{
+		{"c/ll", []string{"type", "max-elements", "description"}},
+		{"c/ch/implied", []string{"leaf"}},
+		{"c/from-group", []string{"type"}},
+		{"r/input", []string{"leaf"}},
+	} {
+		e := mod.Find(tt.path)
+		if e == nil {
+			t.Errorf("%s not found", tt.path)
+			continue
+		}
+		var got []string
+		for _, s := range SubStatements(e.Node) {
+			got = append(got, s.Keyword)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: SubStatements (-want, +got):\n%s", tt.path, diff)
+		}
+	}
+	if got := SubStatements(nil); got != nil {
+		t.Errorf("SubStatements(nil) = %v, want nil", got)
+	}
+}
+
+func TestPrefixMap(t *testing.T) {
+	ms := NewModules()
+	for name, src := range map[string]string{
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  import b { prefix "x"; }
+  import c { prefix "c"; revision-date 2020-01-01; }
+  include s;
+}`,
+		"s.yang": `submodule s {
+  belongs-to a { prefix "p"; }
+  import b { prefix "y"; }
+}`,
+		"b.yang": `module b { prefix "a"; namespace "urn:b"; }`,
+		"c.yang": `module c { prefix "c"; namespace "urn:c"; revision 2020-01-01; }`,
+	} {
+		if err := ms.Parse(src, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary := func(m *Module) map[string]string {
+		got := map[string]string{}
+		for prefix, pm := range m.PrefixMap() {
+			got[prefix] = pm.FullName()
+		}
+		return got
+	}
+	check := func(when string) {
+		if diff := cmp.Diff(map[string]string{"a": "a", "x": "b", "c": "c@2020-01-01"}, summary(ms.Modules["a"])); diff != "" {
+			t.Errorf("%s: PrefixMap of a (-want, +got):\n%s", when, diff)
+		}
+		if diff := cmp.Diff(map[string]string{"p": "a", "y": "b"}, summary(ms.SubModules["s"])); diff != "" {
+			t.Errorf("%s: PrefixMap of s (-want, +got):\n%s", when, diff)
+		}
+	}
+	check("before Process")
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	check("after Process")
+
+	// A module that has not been read is left out.
+	lone := NewModules()
+	if err := lone.Parse(`module d { prefix "d"; namespace "urn:d"; import e { prefix "e"; } }`, "d.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{"d": "d"}, summary(lone.Modules["d"])); diff != "" {
+		t.Errorf("PrefixMap of d (-want, +got):\n%s", diff)
+	}
+
+	// The prefix of a submodule whose module has not been read refers to
+	// the submodule itself.
+	if err := lone.Parse(`submodule t { belongs-to f { prefix "f"; } container c { leaf l { type string; } } }`, "t.yang"); err != nil {
+		t.Fatal(err)
+	}
+	sub := lone.SubModules["t"]
+	if diff := cmp.Diff(map[string]string{"f": "t"}, summary(sub)); diff != "" {
+		t.Errorf("PrefixMap of t (-want, +got):\n%s", diff)
+	}
+	e := ToEntry(sub)
+	if got := e.Find("/f:c/f:l"); got == nil || got.Name != "l" {
+		t.Errorf("Find(/f:c/f:l) in t: got %v, want leaf l", got)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi generates OpenAPI 3.0 schema objects from processed YANG
+// Entry trees.
+//
+// ToOpenAPI returns the schema of an Entry as a map that encodes to JSON
+// with encoding/json.  Containers and modules are object schemas with a
+// property for each of their data nodes, lists are array schemas of object
+// schemas, and leaves and leaf-lists are schemas of the JSON type of their
+// YANG type.
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// ToOpenAPI returns the OpenAPI 3.0 schema object of e, which must be a
+// module, container, list, leaf or leaf-list, or a choice or case.
+//
+// The schema of a container, module, choice or case is an object schema with
+// a property for each of the data nodes that are its children in the data
+// tree, with the data nodes within choices and cases as direct properties.
+// Its children that are mandatory, as reported by yang.Entry.IsMandatory,
+// other than choices, are required.  The schema of a list is an array of the
+// object schema of a list entry, in which the keys of the list are also
+// required, and its min-elements and max-elements are the minItems and
+// maxItems of the array.  Anydata and anyxml nodes are objects
+// with any properties.  RPCs and notifications are not included.
+//
+// Leaves are schemas of the JSON type of the built-in type their type is
+// derived from, and leaf-lists are arrays of them.  Integers have the int32
+// or int64 format that holds all of their values, and the minimum and maximum
+// of their range.  Decimal64 leaves are numbers with the double format, and
+// binary leaves are strings with the byte format.  Strings have the length
+// and pattern of their type, with the pattern anchored, or the allOf of their
+// patterns if there are more than one.  Enumerations are
+// strings with an enum of their names.  Bits, identityref and
+// instance-identifier leaves are strings.  A leaf of type empty is encoded as
+// in RFC 7951, as an array holding a single null.  A union is the oneOf of
+// the schemas of its member types, and a leafref has the schema of the leaf
+// it refers to, or is a string if its path cannot be resolved.
+//
+// The description of a schema is the description of its node, followed by
+// its must and when statements.  Nodes that are not configuration are
+// readOnly.  An error is returned if a leaf has no type.
+func ToOpenAPI(e *yang.Entry) (map[string]interface{}, error) {
+	if e == nil {
+		return nil, fmt.Errorf("no entry to convert")
+	}
+	if e.RPC != nil || e.Kind == yang.NotificationEntry {
+		return nil, fmt.Errorf("%s is not a data node", e.Path())
+	}
+	return schema(e)
+}
+
+// schema returns the schema of the data node e.
+func schema(e *yang.Entry) (map[string]interface{}, error) {
+	var s map[string]interface{}
+	var err error
+	switch {
+	case e.Kind == yang.AnyDataEntry || e.Kind == yang.AnyXMLEntry:
+		s = map[string]interface{}{"type": "object"}
+	case e.IsList():
+		var items map[string]interface{}
+		if items, err = objectSchema(e); err != nil {
+			return nil, err
+		}
+		required := strings.Fields(e.Key)
+		if r, ok := items["required"].([]string); ok {
+			for _, name := range r {
+				if !isKey(e, name) {
+					required = append(required, name)
+				}
+			}
+		}
+		if len(required) > 0 {
+			items["required"] = required
+		}
+		s = arraySchema(e, items)
+	case e.IsDir():
+		if s, err = objectSchema(e); err != nil {
+			return nil, err
+		}
+	default:
+		if e.Type == nil {
+			return nil, fmt.Errorf("%s: leaf %s has no type", yang.Source(e.Node), e.Path())
+		}
+		if s, err = typeSchema(e, e.Type); err != nil {
+			return nil, err
+		}
+		if e.IsLeafList() {
+			s = arraySchema(e, s)
+		}
+	}
+
+	var desc []string
+	if e.Description != "" {
+		desc = append(desc, e.Description)
+	}
+	for _, mc := range e.MustConditions() {
+		desc = append(desc, "must: "+mc.XPath)
+	}
+	if when, ok := e.GetWhenXPath(); ok {
+		desc = append(desc, "when: "+when)
+	}
+	if len(desc) > 0 {
+		s["description"] = strings.Join(desc, "\n\n")
+	}
+	if e.ReadOnly() {
+		s["readOnly"] = true
+	}
+	return s, nil
+}
+
+// isKey reports whether name is a key of the list e.
+func isKey(e *yang.Entry, name string) bool {
+	for _, k := range strings.Fields(e.Key) {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// objectSchema returns the object schema of the container, list entry,
+// module, choice or case e.
+func objectSchema(e *yang.Entry) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	var required []string
+	for _, c := range e.Flatten() {
+		if c.RPC != nil || c.Kind == yang.NotificationEntry {
+			continue
+		}
+		s, err := schema(c)
+		if err != nil {
+			return nil, err
+		}
+		properties[c.Name] = s
+	}
+	// The mandatory data nodes within choices and cases are only required
+	// if their choice is, so only the direct children of e are checked.
+	for _, c := range e.MandatoryChildren() {
+		if c.Kind != yang.ChoiceEntry {
+			required = append(required, c.Name)
+		}
+	}
+	s := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s, nil
+}
+
+// arraySchema returns the array schema of the list or leaf-list e with the
+// schema items.
+func arraySchema(e *yang.Entry, items map[string]interface{}) map[string]interface{} {
+	s := map[string]interface{}{
+		"type":  "array",
+		"items": items,
+	}
+	if la := e.ListAttr; la != nil {
+		if la.MinElements != nil {
+			if n, err := strconv.ParseUint(la.MinElements.Name, 10, 64); err == nil && n > 0 {
+				s["minItems"] = n
+			}
+		}
+		if la.MaxElements != nil {
+			if n, err := strconv.ParseUint(la.MaxElements.Name, 10, 64); err == nil {
+				s["maxItems"] = n
+			}
+		}
+	}
+	return s
+}
+
+// integerFormats maps the kinds of the YANG integer types to the OpenAPI
+// format that holds all of their values.
+var integerFormats = map[yang.TypeKind]string{
+	yang.Yint8:   "int32",
+	yang.Yint16:  "int32",
+	yang.Yint32:  "int32",
+	yang.Yint64:  "int64",
+	yang.Yuint8:  "int32",
+	yang.Yuint16: "int32",
+	yang.Yuint32: "int64",
+	yang.Yuint64: "int64",
+}
+
+// typeSchema returns the schema of a value of type t, the type of the leaf
+// or leaf-list e.
+func typeSchema(e *yang.Entry, t *yang.YangType) (map[string]interface{}, error) {
+	for seen := map[*yang.Entry]bool{e: true}; t.Kind == yang.Yleafref; {
+		target, err := e.ResolveLeafref(t)
+		if err != nil || target.Type == nil || seen[target] {
+			return map[string]interface{}{"type": "string"}, nil
+		}
+		seen[target] = true
+		e, t = target, target.Type
+	}
+
+	if format, ok := integerFormats[t.Kind]; ok {
+		s := map[string]interface{}{"type": "integer", "format": format}
+		if len(t.Range) > 0 {
+			if n, err := t.Range[0].Min.Int(); err == nil {
+				s["minimum"] = n
+			}
+			if n, err := t.Range[len(t.Range)-1].Max.Int(); err == nil {
+				s["maximum"] = n
+			}
+		}
+		return s, nil
+	}
+
+	switch t.Kind {
+	case yang.Ystring:
+		s := map[string]interface{}{"type": "string"}
+		if len(t.Length) > 0 {
+			if n, err := t.Length[0].Min.Int(); err == nil && n > 0 {
+				s["minLength"] = n
+			}
+			if n, err := t.Length[len(t.Length)-1].Max.Int(); err == nil {
+				s["maxLength"] = n
+			}
+		}
+		// The patterns of YANG are anchored, and a value must match all
+		// of them.
+		switch len(t.Pattern) {
+		case 0:
+		case 1:
+			s["pattern"] = anchor(t.Pattern[0])
+		default:
+			var allOf []interface{}
+			for _, p := range t.Pattern {
+				allOf = append(allOf, map[string]interface{}{"pattern": anchor(p)})
+			}
+			s["allOf"] = allOf
+		}
+		return s, nil
+	case yang.Ybool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case yang.Ydecimal64:
+		return map[string]interface{}{"type": "number", "format": "double"}, nil
+	case yang.Ybinary:
+		return map[string]interface{}{"type": "string", "format": "byte"}, nil
+	case yang.Yenum:
+		var names []string
+		for _, v := range t.Enum.Values() {
+			names = append(names, t.Enum.Name(v))
+		}
+		return map[string]interface{}{"type": "string", "enum": names}, nil
+	case yang.Ybits, yang.Yidentityref, yang.YinstanceIdentifier:
+		return map[string]interface{}{"type": "string"}, nil
+	case yang.Yempty:
+		return map[string]interface{}{
+			"type":     "array",
+			"items":    map[string]interface{}{"type": "string", "nullable": true, "enum": []interface{}{nil}},
+			"minItems": 1,
+			"maxItems": 1,
+		}, nil
+	case yang.Yunion:
+		types, err := t.UniqueUnionTypes()
+		if err != nil {
+			return nil, fmt.Errorf("%s: leaf %s: %v", yang.Source(e.Node), e.Path(), err)
+		}
+		var oneOf []interface{}
+		for _, ut := range types {
+			s, err := typeSchema(e, ut)
+			if err != nil {
+				return nil, err
+			}
+			oneOf = append(oneOf, s)
+		}
+		return map[string]interface{}{"oneOf": oneOf}, nil
+	}
+	return nil, fmt.Errorf("%s: leaf %s has unsupported type %s", yang.Source(e.Node), e.Path(), t.Name)
+}
+
+// anchor returns the pattern p, which is implicitly anchored in YANG, with
+// explicit anchors.
+func anchor(p string) string {
+	return "^(?:" + p + ")$"
+}
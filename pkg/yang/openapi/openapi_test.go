@@ -0,0 +1,223 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+const deviceModule = `module device {
+  prefix "d";
+  namespace "urn:d";
+
+  typedef counter { type uint64; }
+
+  container interfaces {
+    description "The interfaces.";
+    list interface {
+      key "name";
+      max-elements 8;
+      leaf name { type string { length "1..16"; pattern "[a-z]+[0-9]*"; } }
+      leaf mtu {
+        type uint16 { range "68..9000"; }
+        must ". >= 1280";
+      }
+      leaf admin {
+        type enumeration { enum up { value 1; } enum down { value 2; } }
+        mandatory true;
+      }
+      leaf-list address { type string; min-elements 1; }
+      leaf in-octets { type counter; config false; }
+      leaf enabled { type empty; }
+      leaf mac { type binary; }
+      leaf ratio { type decimal64 { fraction-digits 2; } }
+      leaf up { type boolean; when "../admin = 'up'"; }
+      leaf peer { type leafref { path "../name"; } }
+      leaf id { type union { type int8; type string; type int8; } }
+      leaf alias { type string { pattern "[a-z]+"; pattern "[a-c].*"; } }
+      choice kind {
+        leaf ethernet { type string; }
+        leaf loopback { type empty; }
+        leaf tunnel-mtu { type leafref { path "../mtu"; } }
+      }
+    }
+  }
+  rpc reset { input { leaf name { type string; } } }
+  notification changed { leaf name { type string; } }
+}`
+
+const deviceSchema = `{
+  "type": "object",
+  "properties": {
+    "interfaces": {
+      "type": "object",
+      "description": "The interfaces.",
+      "properties": {
+        "interface": {
+          "type": "array",
+          "maxItems": 8,
+          "items": {
+            "type": "object",
+            "required": ["name", "address", "admin"],
+            "properties": {
+              "name": {
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 16,
+                "pattern": "^(?:[a-z]+[0-9]*)$"
+              },
+              "mtu": {
+                "type": "integer",
+                "format": "int32",
+                "minimum": 68,
+                "maximum": 9000,
+                "description": "must: . >= 1280"
+              },
+              "admin": {"type": "string", "enum": ["up", "down"]},
+              "address": {
+                "type": "array",
+                "minItems": 1,
+                "items": {"type": "string"}
+              },
+              "in-octets": {
+                "type": "integer",
+                "format": "int64",
+                "minimum": 0,
+                "readOnly": true
+              },
+              "enabled": {
+                "type": "array",
+                "minItems": 1,
+                "maxItems": 1,
+                "items": {"type": "string", "nullable": true, "enum": [null]}
+              },
+              "mac": {"type": "string", "format": "byte"},
+              "ratio": {"type": "number", "format": "double"},
+              "up": {"type": "boolean", "description": "when: ../admin = 'up'"},
+              "peer": {
+                "type": "string",
+                "minLength": 1,
+                "maxLength": 16,
+                "pattern": "^(?:[a-z]+[0-9]*)$"
+              },
+              "id": {
+                "oneOf": [
+                  {"type": "integer", "format": "int32", "minimum": -128, "maximum": 127},
+                  {"type": "string"}
+                ]
+              },
+              "alias": {
+                "type": "string",
+                "allOf": [
+                  {"pattern": "^(?:[a-z]+)$"},
+                  {"pattern": "^(?:[a-c].*)$"}
+                ]
+              },
+              "ethernet": {"type": "string"},
+              "loopback": {
+                "type": "array",
+                "minItems": 1,
+                "maxItems": 1,
+                "items": {"type": "string", "nullable": true, "enum": [null]}
+              },
+              "tunnel-mtu": {
+                "type": "integer",
+                "format": "int32",
+                "minimum": 68,
+                "maximum": 9000
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// getEntry returns the Entry of the module named name parsed from in.
+func getEntry(t *testing.T, in, name string) *yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	if err := ms.Parse(in, name+".yang"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	e, errs := ms.GetModule(name)
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	return e
+}
+
+func TestToOpenAPI(t *testing.T) {
+	s, err := ToOpenAPI(getEntry(t, deviceModule, "device"))
+	if err != nil {
+		t.Fatalf("ToOpenAPI: %v", err)
+	}
+	// The schema is compared as JSON, as it is meant to be encoded.
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if err := json.Unmarshal([]byte(deviceSchema), &want); err != nil {
+		t.Fatalf("json.Unmarshal of deviceSchema: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ToOpenAPI (-want, +got):\n%s", diff)
+	}
+}
+
+func TestToOpenAPIErrors(t *testing.T) {
+	e := getEntry(t, deviceModule, "device")
+	tests := []struct {
+		desc       string
+		in         *yang.Entry
+		wantErrSub string
+	}{{
+		desc:       "nil entry",
+		wantErrSub: "no entry to convert",
+	}, {
+		desc:       "rpc",
+		in:         e.Dir["reset"],
+		wantErrSub: "/device/reset is not a data node",
+	}, {
+		desc:       "notification",
+		in:         e.Dir["changed"],
+		wantErrSub: "/device/changed is not a data node",
+	}, {
+		desc:       "leaf without a type",
+		in:         &yang.Entry{Name: "l", Kind: yang.LeafEntry},
+		wantErrSub: "leaf /l has no type",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := ToOpenAPI(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("ToOpenAPI: %s", diff)
+			}
+		})
+	}
+}
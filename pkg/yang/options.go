@@ -27,8 +27,54 @@ type Options struct {
 	// generated within the schema to store the logical grouping from which it
 	// is derived.
 	StoreUses bool
+	// FlattenChoices controls whether choice and case nodes are removed from
+	// the Entry tree once it has been processed. Setting this value to true
+	// will cause the data nodes within each case of a choice to be moved into
+	// the Dir of the choice's parent, with the Case field of each moved Entry
+	// set to the case it was defined in.
+	FlattenChoices bool
+	// PruneStaticWhen controls whether nodes whose when statements, including
+	// those of the augment and uses statements that added them, are always
+	// false are removed from the Entry tree once it has been processed.
+	// Setting this value to true will cause each node for which
+	// Entry.StaticWhen reports a false value, and all of its descendants, to
+	// be removed.  Nodes whose when statement depends on instance data are
+	// not removed.
+	PruneStaticWhen bool
+	// StrictStatements controls whether the substatements of extension
+	// statements are checked for unknown statements when modules are
+	// parsed.  Statements without a prefix that are not YANG statements
+	// are always an error within the YANG statements themselves, but
+	// within extension statements they are kept without being checked.
+	// Setting this value to true will cause such a statement, which may
+	// be a misspelled YANG statement, to be an error.  Statements with a
+	// prefix are still allowed, as they may be extensions.
+	StrictStatements bool
+	// MaxEntryDepth is the maximum depth of the recursion of ToEntry, which
+	// recurses into the statements of each node and into the grouping of
+	// each uses statement.  A node that would be expanded below this depth
+	// is an error rather than exhausting the stack.  If zero,
+	// DefaultMaxEntryDepth is used.
+	MaxEntryDepth int
+	// RequireListKeys controls whether a list that is configuration must
+	// have a key, as RFC 7950 requires.  Keyless configuration lists were
+	// historically accepted, so they are not an error by default.  Setting
+	// this value to true will cause Process to report each config list
+	// without a key.
+	RequireListKeys bool
+	// RejectImportCycles controls whether modules that import each other,
+	// directly or through other modules, are an error.  RFC 7950 does not
+	// allow import cycles, but they were historically accepted, so Process
+	// reports them as warnings by default.  Setting this value to true will
+	// cause Process to stop with the error returned by CyclicDependencyCheck.
+	RejectImportCycles bool
 }
 
+// DefaultMaxEntryDepth is the maximum depth of the recursion of ToEntry if
+// ParseOptions.MaxEntryDepth is not set.  It is far deeper than any
+// legitimate model nests.
+const DefaultMaxEntryDepth = 1000
+
 // ParseOptions sets the options for the current YANG module parsing. It can be
 // directly set by the caller to influence how goyang will behave in the presence
 // of certain exceptional cases.
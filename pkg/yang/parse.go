@@ -75,6 +75,13 @@ func (s *Statement) Kind() string          { return s.Keyword }
 func (s *Statement) Statement() *Statement { return s }
 func (s *Statement) ParentNode() Node      { return nil }
 func (s *Statement) Exts() []*Statement    { return nil }
+func (s *Statement) NumChildren() int      { return len(s.statements) }
+func (s *Statement) NthChild(n int) (Node, error) {
+	if n < 0 || n >= len(s.statements) {
+		return nil, fmt.Errorf("%s: %s has no child %d", Source(s), s.Keyword, n)
+	}
+	return s.statements[n], nil
+}
 
 // Arg returns the optional argument to s.  It returns false if s has no
 // argument.
@@ -86,6 +93,34 @@ func (s *Statement) Arg() (string, bool) { return s.Argument, s.HasArgument }
 // SubStatements returns a slice of Statements found in s.
 func (s *Statement) SubStatements() []*Statement { return s.statements }
 
+// ChildByKeyword returns the first substatement of s with the given keyword,
+// or nil if s has no such substatement.
+func (s *Statement) ChildByKeyword(keyword string) *Statement {
+	for _, ss := range s.statements {
+		if ss.Keyword == keyword {
+			return ss
+		}
+	}
+	return nil
+}
+
+// ChildrenByKeyword returns the substatements of s with the given keyword, in
+// the order they are found in s.
+func (s *Statement) ChildrenByKeyword(keyword string) []*Statement {
+	var children []*Statement
+	for _, ss := range s.statements {
+		if ss.Keyword == keyword {
+			children = append(children, ss)
+		}
+	}
+	return children
+}
+
+// HasKeyword returns true if s has a substatement with the given keyword.
+func (s *Statement) HasKeyword(keyword string) bool {
+	return s.ChildByKeyword(keyword) != nil
+}
+
 // String returns s's tree as a string.
 func (s *Statement) String() string {
 	var b bytes.Buffer
@@ -110,7 +145,8 @@ func (s *Statement) Location() string {
 // Write writes the tree in s to w, each line indented by ident.  Children
 // nodes are indented further by a tab.  Typically indent is "" at the top
 // level.  Write is intended to display the contents of Statement, but
-// not necessarily reproduce the input of Statement.
+// not necessarily reproduce the input of Statement.  An input or output
+// statement is always written with a body, as YANG requires.
 func (s *Statement) Write(w io.Writer, indent string) error {
 	if s.Keyword == "" {
 		// We are just a collection of statements at the top level.
@@ -144,7 +180,13 @@ func (s *Statement) Write(w io.Writer, indent string) error {
 		}
 	}
 
-	if len(s.statements) == 0 {
+	switch {
+	case len(s.statements) > 0:
+	case s.Keyword == "input" || s.Keyword == "output":
+		// An input or output statement has a body even if it is empty.
+		_, err := fmt.Fprintf(w, "%s {\n%s}\n", strings.Join(parts, ""), indent)
+		return err
+	default:
 		_, err := fmt.Fprintf(w, "%s;\n", strings.Join(parts, ""))
 		return err
 	}
@@ -162,6 +204,40 @@ func (s *Statement) Write(w io.Writer, indent string) error {
 	return nil
 }
 
+// WriteDebug writes the tree in s to w for debugging, one statement per line
+// with its keyword, its quoted argument, if it has one, and its location, as
+// returned by Location.  Substatements are indented by two spaces per level
+// and are not enclosed in braces.
+func (s *Statement) WriteDebug(w io.Writer) error {
+	return s.writeDebug(w, "")
+}
+
+// writeDebug writes s as WriteDebug does, with each line indented by indent.
+func (s *Statement) writeDebug(w io.Writer, indent string) error {
+	if s.Keyword == "" {
+		// We are just a collection of statements at the top level.
+		for _, s := range s.statements {
+			if err := s.writeDebug(w, indent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	line := indent + s.Keyword
+	if s.HasArgument {
+		line += fmt.Sprintf(" %q", s.Argument)
+	}
+	if _, err := fmt.Fprintf(w, "%s [%s]\n", line, s.Location()); err != nil {
+		return err
+	}
+	for _, s := range s.statements {
+		if err := s.writeDebug(w, indent+"  "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ignoreMe is returned to continue processing after an error (the parse will
 // fail, but we want to look for more errors).
 var ignoreMe = &Statement{}
@@ -16,6 +16,7 @@ package yang
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 )
 
@@ -321,6 +322,16 @@ Testing:
 		key "arg";
 	}
 }
+`,
+		},
+		{line: line(),
+			in: `rpc r { input {} output; }`,
+			out: `rpc "r" {
+	input {
+	}
+	output {
+	}
+}
 `,
 		},
 		{line: line(),
@@ -389,3 +400,69 @@ module base {
 		}
 	}
 }
+
+func TestWriteDebug(t *testing.T) {
+	s, err := Parse(`module m {
+  prefix "m";
+  container c {
+    description "two
+      lines";
+  }
+}`, "m.yang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := s[0].WriteDebug(&buf); err != nil {
+		t.Fatalf("WriteDebug: %v", err)
+	}
+	want := `module "m" [m.yang:1:1]
+  prefix "m" [m.yang:2:3]
+  container "c" [m.yang:3:3]
+    description "two\nlines" [m.yang:4:5]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteDebug got:\n%swant:\n%s", got, want)
+	}
+}
+
+func TestChildByKeyword(t *testing.T) {
+	ss, err := Parse(`module m {
+  prefix p;
+  leaf a { type string; }
+  leaf b { type int8; }
+  c:ext "x";
+}`, "m.yang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ss[0]
+
+	if got := m.ChildByKeyword("leaf"); got == nil || got.Argument != "a" {
+		t.Errorf("ChildByKeyword(leaf): got %v, want leaf a", got)
+	}
+	if got := m.ChildByKeyword("c:ext"); got == nil || got.Argument != "x" {
+		t.Errorf("ChildByKeyword(c:ext): got %v, want c:ext x", got)
+	}
+	if got := m.ChildByKeyword("container"); got != nil {
+		t.Errorf("ChildByKeyword(container): got %v, want nil", got)
+	}
+
+	var names []string
+	for _, s := range m.ChildrenByKeyword("leaf") {
+		names = append(names, s.Argument)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ChildrenByKeyword(leaf): got %v, want %v", names, want)
+	}
+	if got := m.ChildrenByKeyword("container"); got != nil {
+		t.Errorf("ChildrenByKeyword(container): got %v, want nil", got)
+	}
+
+	if !m.HasKeyword("prefix") {
+		t.Errorf("HasKeyword(prefix): got false, want true")
+	}
+	if m.HasKeyword("namespace") {
+		t.Errorf("HasKeyword(namespace): got true, want false")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements listing the leaves of the data tree.
+
+// A SchemaLeaf is a leaf or leaf-list of the data tree.
+type SchemaLeaf struct {
+	Path  string    // the path of the leaf, as returned by DataPath
+	Type  *YangType // the resolved type of the leaf
+	Entry *Entry
+}
+
+// SchemaLeavesOptions select the leaves returned by SchemaLeaves.
+type SchemaLeavesOptions struct {
+	// ExcludeState excludes the leaves that are config false, that is,
+	// those for which ReadOnly returns true.
+	ExcludeState bool
+	// IncludeOperations includes the leaves of the input and output of
+	// rpcs and actions, and those of notifications.
+	IncludeOperations bool
+}
+
+// SchemaLeaves returns the leaves and leaf-lists in the sub-tree rooted at
+// e, in the order they are defined.  Choice and case entries are looked
+// through.
+func (e *Entry) SchemaLeaves(opts SchemaLeavesOptions) []SchemaLeaf {
+	var leaves []SchemaLeaf
+	e.walk(func(e *Entry) bool {
+		if !opts.IncludeOperations && (e.RPC != nil || e.Kind == NotificationEntry) {
+			return false
+		}
+		if e.Kind == LeafEntry {
+			if !opts.ExcludeState || !e.ReadOnly() {
+				leaves = append(leaves, SchemaLeaf{Path: e.DataPath(), Type: e.Type, Entry: e})
+			}
+			return false
+		}
+		return true
+	})
+	return leaves
+}
+
+// SchemaLeaves returns the SchemaLeaves of each module of ms, ordered by
+// module name.  Process must be called before SchemaLeaves.
+func (ms *Modules) SchemaLeaves(opts SchemaLeavesOptions) []SchemaLeaf {
+	var leaves []SchemaLeaf
+	for _, name := range ms.moduleNames() {
+		leaves = append(leaves, ToEntry(ms.Modules[name]).SchemaLeaves(opts)...)
+	}
+	return leaves
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchemaLeaves(t *testing.T) {
+	ms := NewModules()
+	for name, src := range map[string]string{
+		"b.yang": `module b {
+  prefix "b";
+  namespace "urn:b";
+  leaf z { type int8; }
+}`,
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  container c {
+    leaf x { type string; }
+    leaf-list tags { type string; }
+    container state {
+      config false;
+      leaf counter { type uint64; }
+    }
+    choice mode {
+      leaf auto { type boolean; }
+      case manual {
+        leaf value { type int32; }
+      }
+    }
+    list l {
+      key "k";
+      leaf k { type string; }
+      action reset {
+        input { leaf force { type boolean; } }
+      }
+    }
+  }
+  rpc ping {
+    input { leaf host { type string; } }
+    output { leaf rtt { type uint32; } }
+  }
+  notification alarm {
+    leaf text { type string; }
+  }
+}`,
+	} {
+		if err := ms.Parse(src, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+
+	for _, tt := range []struct {
+		desc string
+		opts SchemaLeavesOptions
+		want []string
+	}{{
+		desc: "default",
+		want: []string{
+			"/a/c/x string",
+			"/a/c/tags string",
+			"/a/c/state/counter uint64",
+			"/a/c/auto boolean",
+			"/a/c/value int32",
+			"/a/c/l/k string",
+			"/b/z int8",
+		},
+	}, {
+		desc: "exclude state",
+		opts: SchemaLeavesOptions{ExcludeState: true},
+		want: []string{
+			"/a/c/x string",
+			"/a/c/tags string",
+			"/a/c/auto boolean",
+			"/a/c/value int32",
+			"/a/c/l/k string",
+			"/b/z int8",
+		},
+	}, {
+		desc: "include operations",
+		opts: SchemaLeavesOptions{IncludeOperations: true},
+		want: []string{
+			"/a/c/x string",
+			"/a/c/tags string",
+			"/a/c/state/counter uint64",
+			"/a/c/auto boolean",
+			"/a/c/value int32",
+			"/a/c/l/k string",
+			"/a/c/l/reset/input/force boolean",
+			"/a/ping/input/host string",
+			"/a/ping/output/rtt uint32",
+			"/a/alarm/text string",
+			"/b/z int8",
+		},
+	}} {
+		var got []string
+		for _, l := range ms.SchemaLeaves(tt.opts) {
+			got = append(got, fmt.Sprintf("%s %s", l.Path, l.Type.Name))
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: SchemaLeaves (-want, +got):\n%s", tt.desc, diff)
+		}
+	}
+}
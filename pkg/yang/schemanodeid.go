@@ -0,0 +1,142 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the parsing and resolution of schema node
+// identifiers, as defined in RFC 7950 section 6.5.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A SchemaNodeIDStep is a single node identifier of a schema node identifier.
+// Prefix is empty if the node identifier has no prefix.
+type SchemaNodeIDStep struct {
+	Prefix string
+	Name   string
+}
+
+// String returns s as it appears in a schema node identifier.
+func (s SchemaNodeIDStep) String() string {
+	if s.Prefix == "" {
+		return s.Name
+	}
+	return s.Prefix + ":" + s.Name
+}
+
+// ParseSchemaNodeID parses s, an absolute schema node identifier such as
+// "/if:interfaces/if:interface", and returns its steps.  An error is returned
+// if s is not a valid absolute schema node identifier.  Descendant schema
+// node identifiers are parsed by ParseDescendantSchemaNodeID.
+func ParseSchemaNodeID(s string) ([]SchemaNodeIDStep, error) {
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("invalid schema node identifier %q: not absolute", s)
+	}
+	return parseNodeIdentifiers(s, s[1:])
+}
+
+// ParseDescendantSchemaNodeID parses s, a descendant schema node identifier
+// such as "config/name", and returns its steps.  An error is returned if s is
+// not a valid descendant schema node identifier.
+func ParseDescendantSchemaNodeID(s string) ([]SchemaNodeIDStep, error) {
+	if strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("invalid descendant schema node identifier %q: absolute", s)
+	}
+	return parseNodeIdentifiers(s, s)
+}
+
+// parseNodeIdentifiers returns the steps of id, the "/" separated node
+// identifiers of the schema node identifier s.
+func parseNodeIdentifiers(s, id string) ([]SchemaNodeIDStep, error) {
+	if id == "" {
+		return nil, fmt.Errorf("invalid schema node identifier %q: no node identifiers", s)
+	}
+	var steps []SchemaNodeIDStep
+	for _, part := range strings.Split(id, "/") {
+		prefix, name := getPrefix(part)
+		if (strings.Contains(part, ":") && !isIdentifier(prefix)) || !isIdentifier(name) {
+			return nil, fmt.Errorf("invalid schema node identifier %q: invalid node identifier %q", s, part)
+		}
+		steps = append(steps, SchemaNodeIDStep{Prefix: prefix, Name: name})
+	}
+	return steps, nil
+}
+
+// isIdentifier returns true if s is a YANG identifier.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case i > 0 && (c >= '0' && c <= '9' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveSchemaNodeID returns the Entry named by steps, the steps of an
+// absolute schema node identifier returned by ParseSchemaNodeID.  The
+// prefixes of the steps are resolved within context, and a step with no
+// prefix names a node in the module of context.  The first step names a top
+// level node of its module.  The modules of ms must have been processed.
+func (ms *Modules) ResolveSchemaNodeID(steps []SchemaNodeIDStep, context *Module) (*Entry, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("empty schema node identifier")
+	}
+	if context == nil {
+		return nil, fmt.Errorf("no context module to resolve schema node identifier")
+	}
+	var e *Entry
+	for i, step := range steps {
+		m, err := ms.stepModule(step, context)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			e = ToEntry(m)
+		}
+		next := e.Dir[step.Name]
+		if next == nil {
+			return nil, fmt.Errorf("schema node %s not found in %s", step, e.Path())
+		}
+		if ns := next.Namespace().Name; ns != "" && m.Namespace != nil && ns != m.Namespace.Name {
+			return nil, fmt.Errorf("schema node %s found in %s is in namespace %s, not %s", step, e.Path(), ns, m.Namespace.Name)
+		}
+		e = next
+	}
+	return e, nil
+}
+
+// stepModule returns the module that the prefix of step refers to within
+// context.  A submodule is resolved to the module it belongs to.
+func (ms *Modules) stepModule(step SchemaNodeIDStep, context *Module) (*Module, error) {
+	m := FindModuleByPrefix(context, step.Prefix)
+	if m == nil {
+		return nil, fmt.Errorf("%s: unknown prefix %q in schema node identifier", Source(context), step.Prefix)
+	}
+	if m.BelongsTo != nil {
+		bm := ms.Modules[m.BelongsTo.Name]
+		if bm == nil {
+			return nil, fmt.Errorf("%s: module %s of submodule %s not found", Source(context), m.BelongsTo.Name, m.Name)
+		}
+		m = bm
+	}
+	return m, nil
+}
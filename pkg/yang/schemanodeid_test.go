@@ -0,0 +1,153 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestParseSchemaNodeID(t *testing.T) {
+	tests := []struct {
+		in         string
+		want       []SchemaNodeIDStep
+		wantErrSub string
+	}{
+		{in: "/if:interfaces/if:interface", want: []SchemaNodeIDStep{{"if", "interfaces"}, {"if", "interface"}}},
+		{in: "/a/b:c", want: []SchemaNodeIDStep{{"", "a"}, {"b", "c"}}},
+		{in: "/x_1.y-z", want: []SchemaNodeIDStep{{"", "x_1.y-z"}}},
+		{in: "config/name", wantErrSub: "not absolute"},
+		{in: "", wantErrSub: "not absolute"},
+		{in: "/", wantErrSub: "no node identifiers"},
+		{in: "/a//b", wantErrSub: `invalid node identifier ""`},
+		{in: "/a/", wantErrSub: `invalid node identifier ""`},
+		{in: "/:a", wantErrSub: `invalid node identifier ":a"`},
+		{in: "/p:", wantErrSub: `invalid node identifier "p:"`},
+		{in: "/1a", wantErrSub: `invalid node identifier "1a"`},
+		{in: "/a b", wantErrSub: `invalid node identifier "a b"`},
+	}
+	for _, tt := range tests {
+		got, err := ParseSchemaNodeID(tt.in)
+		if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+			t.Errorf("ParseSchemaNodeID(%q): %s", tt.in, diff)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("ParseSchemaNodeID(%q) (-want, +got):\n%s", tt.in, diff)
+		}
+	}
+}
+
+func TestParseDescendantSchemaNodeID(t *testing.T) {
+	tests := []struct {
+		in         string
+		want       []SchemaNodeIDStep
+		wantErrSub string
+	}{
+		{in: "config/name", want: []SchemaNodeIDStep{{"", "config"}, {"", "name"}}},
+		{in: "x_1.y-z", want: []SchemaNodeIDStep{{"", "x_1.y-z"}}},
+		{in: "a/b:c", want: []SchemaNodeIDStep{{"", "a"}, {"b", "c"}}},
+		{in: "/a/b", wantErrSub: "absolute"},
+		{in: "", wantErrSub: "no node identifiers"},
+		{in: "a//b", wantErrSub: `invalid node identifier ""`},
+	}
+	for _, tt := range tests {
+		got, err := ParseDescendantSchemaNodeID(tt.in)
+		if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+			t.Errorf("ParseDescendantSchemaNodeID(%q): %s", tt.in, diff)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("ParseDescendantSchemaNodeID(%q) (-want, +got):\n%s", tt.in, diff)
+		}
+	}
+}
+
+func TestResolveSchemaNodeID(t *testing.T) {
+	ms := NewModules()
+	for name, text := range map[string]string{
+		"snid-base": `module snid-base {
+  prefix "b";
+  namespace "urn:b";
+  include snid-sub;
+  container interfaces {
+    list interface {
+      key "name";
+      leaf name { type string; }
+    }
+  }
+  rpc reset { input { leaf delay { type uint8; } } }
+}`,
+		"snid-sub": `submodule snid-sub {
+  belongs-to snid-base { prefix "bs"; }
+  container system { leaf hostname { type string; } }
+}`,
+		"snid-aug": `module snid-aug {
+  prefix "a";
+  namespace "urn:a";
+  import snid-base { prefix "base"; }
+  augment "/base:interfaces/base:interface" {
+    leaf mtu { type uint16; }
+  }
+}`,
+	} {
+		if err := ms.Parse(text, name+".yang"); err != nil {
+			t.Fatalf("Parse(%s): %v", name, err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+
+	tests := []struct {
+		desc       string
+		in         string
+		context    string
+		wantPath   string
+		wantErrSub string
+	}{
+		{desc: "imported prefix", in: "/base:interfaces/base:interface/base:name", context: "snid-aug", wantPath: "/snid-base/interfaces/interface/name"},
+		{desc: "own prefix", in: "/b:interfaces/b:interface", context: "snid-base", wantPath: "/snid-base/interfaces/interface"},
+		{desc: "no prefix", in: "/interfaces", context: "snid-base", wantPath: "/snid-base/interfaces"},
+		{desc: "augmented node", in: "/base:interfaces/base:interface/a:mtu", context: "snid-aug", wantPath: "/snid-base/interfaces/interface/mtu"},
+		{desc: "rpc input", in: "/b:reset/b:input/b:delay", context: "snid-base", wantPath: "/snid-base/reset/input/delay"},
+		{desc: "node from submodule", in: "/b:system/b:hostname", context: "snid-base", wantPath: "/snid-base/system/hostname"},
+		{desc: "submodule prefix", in: "/bs:system", context: "snid-sub", wantPath: "/snid-base/system"},
+		{desc: "augmented node with wrong prefix", in: "/base:interfaces/base:interface/base:mtu", context: "snid-aug", wantErrSub: "is in namespace urn:a, not urn:b"},
+		{desc: "unknown prefix", in: "/x:interfaces", context: "snid-aug", wantErrSub: `unknown prefix "x"`},
+		{desc: "not found", in: "/b:interfaces/b:nope", context: "snid-base", wantErrSub: "schema node b:nope not found in /snid-base/interfaces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			steps, err := ParseSchemaNodeID(tt.in)
+			if err != nil {
+				t.Fatalf("ParseSchemaNodeID(%q): %v", tt.in, err)
+			}
+			context := ms.Modules[tt.context]
+			if context == nil {
+				context = ms.SubModules[tt.context]
+			}
+			e, err := ms.ResolveSchemaNodeID(steps, context)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("ResolveSchemaNodeID(%q): %s", tt.in, diff)
+			}
+			if got := e.Path(); got != tt.wantPath {
+				t.Errorf("ResolveSchemaNodeID(%q): got %s, want %s", tt.in, got, tt.wantPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the statistics of schema trees.
+
+import "sync"
+
+// SchemaStats are the numbers of the nodes of each kind in a sub-tree of
+// the schema.  The input and output of rpcs and actions are part of the
+// sub-tree, while a module or submodule is only counted as a node.
+type SchemaStats struct {
+	Nodes         int // all nodes, including the root of the sub-tree
+	Containers    int
+	Lists         int
+	Leaves        int // leaves and leaf-lists
+	LeafLists     int
+	Choices       int
+	Cases         int
+	RPCs          int // rpcs and actions
+	Notifications int
+}
+
+// add adds the counts of s to t.
+func (t *SchemaStats) add(s *SchemaStats) {
+	t.Nodes += s.Nodes
+	t.Containers += s.Containers
+	t.Lists += s.Lists
+	t.Leaves += s.Leaves
+	t.LeafLists += s.LeafLists
+	t.Choices += s.Choices
+	t.Cases += s.Cases
+	t.RPCs += s.RPCs
+	t.Notifications += s.Notifications
+}
+
+// statsMu guards the cached statistics of all entries, so that the
+// statistics of a tree may be read concurrently.
+var statsMu sync.Mutex
+
+// Stats returns the statistics of the sub-tree rooted at e.  The statistics
+// of each entry are computed once and cached until a node is added to or
+// removed from its sub-tree.  The cache is not cleared when Dir is changed
+// directly.  Stats, CountDescendants and CountLeaves may be called
+// concurrently on the same tree, as the cache is guarded, but not while the
+// tree is being changed.
+func (e *Entry) Stats() SchemaStats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	return *e.computeStats()
+}
+
+// computeStats returns the cached statistics of e, computing them first if
+// needed.  statsMu must be held.
+func (e *Entry) computeStats() *SchemaStats {
+	if e.stats != nil {
+		return e.stats
+	}
+	s := &SchemaStats{Nodes: 1}
+	_, module := e.Node.(*Module)
+	switch {
+	case module:
+	case e.RPC != nil:
+		s.RPCs++
+	case e.Kind == NotificationEntry:
+		s.Notifications++
+	case e.IsList():
+		s.Lists++
+	case e.IsContainer():
+		s.Containers++
+	case e.IsLeafList():
+		s.Leaves++
+		s.LeafLists++
+	case e.IsLeaf():
+		s.Leaves++
+	case e.IsChoice():
+		s.Choices++
+	case e.IsCase():
+		s.Cases++
+	}
+	for _, c := range e.Dir {
+		s.add(c.computeStats())
+	}
+	e.stats = s
+	return s
+}
+
+// clearStats clears the cached statistics of e and of its ancestors.
+func (e *Entry) clearStats() {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	for ; e != nil; e = e.Parent {
+		e.stats = nil
+	}
+}
+
+// CountDescendants returns the number of nodes of the sub-tree rooted at e,
+// including e itself.
+func (e *Entry) CountDescendants() int {
+	return e.Stats().Nodes
+}
+
+// CountLeaves returns the number of leaves and leaf-lists of the sub-tree
+// rooted at e.
+func (e *Entry) CountLeaves() int {
+	return e.Stats().Leaves
+}
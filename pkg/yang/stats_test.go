@@ -0,0 +1,122 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStats(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf a { type string; }
+    leaf-list b { type string; }
+    list l {
+      key "k";
+      leaf k { type string; }
+      action reset {
+        input { leaf force { type boolean; } }
+      }
+    }
+    choice ch {
+      leaf x { type string; }
+      case y {
+        leaf y1 { type string; }
+        leaf y2 { type string; }
+      }
+    }
+  }
+  rpc ping {
+    input { leaf host { type string; } }
+    output { leaf rtt { type uint32; } }
+  }
+  notification alarm {
+    leaf text { type string; }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	top := ToEntry(ms.Modules["m"])
+
+	want := SchemaStats{
+		Nodes:         22,
+		Containers:    1,
+		Lists:         1,
+		Leaves:        10,
+		LeafLists:     1,
+		Choices:       1,
+		Cases:         2,
+		RPCs:          2,
+		Notifications: 1,
+	}
+	if diff := cmp.Diff(want, top.Stats()); diff != "" {
+		t.Errorf("Stats (-want, +got):\n%s", diff)
+	}
+
+	c := top.Dir["c"]
+	if got, want := c.CountDescendants(), 14; got != want {
+		t.Errorf("CountDescendants of c: got %d, want %d", got, want)
+	}
+	if got, want := c.CountLeaves(), 7; got != want {
+		t.Errorf("CountLeaves of c: got %d, want %d", got, want)
+	}
+
+	// Removing a node clears the cached statistics of its ancestors.
+	c.delete("ch")
+	if got, want := top.CountLeaves(), 7; got != want {
+		t.Errorf("CountLeaves after delete: got %d, want %d", got, want)
+	}
+}
+
+func TestStatsConcurrent(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf a { type string; }
+    container d { leaf b { type string; } }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	top := ToEntry(ms.Modules["m"])
+
+	// Run with -race to check that the cached statistics may be read
+	// concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := top.CountLeaves(); got != 2 {
+				t.Errorf("CountLeaves() = %d, want 2", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
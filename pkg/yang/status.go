@@ -0,0 +1,37 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements finding the nodes that are no longer current.
+
+// FindDeprecatedNodes returns the entries of the modules of ms whose
+// effective status, as returned by EffectiveStatus, is deprecated or
+// obsolete.  As the status of a node is inherited by its descendants, the
+// descendants of a deprecated or obsolete node are returned along with it.
+// The entries are ordered by module name and then in the order they are
+// defined, with each node before its descendants.  Process must be called
+// before FindDeprecatedNodes.
+func (ms *Modules) FindDeprecatedNodes() []*Entry {
+	var found []*Entry
+	for _, name := range ms.moduleNames() {
+		ToEntry(ms.Modules[name]).walk(func(e *Entry) bool {
+			if !e.IsCurrent() {
+				found = append(found, e)
+			}
+			return true
+		})
+	}
+	return found
+}
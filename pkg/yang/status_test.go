@@ -0,0 +1,74 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindDeprecatedNodes(t *testing.T) {
+	ms := NewModules()
+	for name, src := range map[string]string{
+		"b.yang": `module b {
+  prefix "b";
+  namespace "urn:b";
+  container old {
+    status obsolete;
+    leaf x { type string; }
+  }
+}`,
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  container c {
+    leaf current { type string; }
+    leaf dep { type string; status deprecated; }
+    container sub {
+      status deprecated;
+      leaf gone { type string; status obsolete; }
+      leaf inherited { type string; }
+    }
+  }
+  rpc r {
+    input { leaf arg { type string; status deprecated; } }
+  }
+}`,
+	} {
+		if err := ms.Parse(src, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	var got []string
+	for _, e := range ms.FindDeprecatedNodes() {
+		got = append(got, e.Path()+" "+e.EffectiveStatus().String())
+	}
+	want := []string{
+		"/a/c/dep deprecated",
+		"/a/c/sub deprecated",
+		"/a/c/sub/gone obsolete",
+		"/a/c/sub/inherited deprecated",
+		"/a/r/input/arg deprecated",
+		"/b/old obsolete",
+		"/b/old/x obsolete",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FindDeprecatedNodes (-want, +got):\n%s", diff)
+	}
+}
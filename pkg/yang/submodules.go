@@ -0,0 +1,87 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements finding the submodules of a module and the module a
+// submodule belongs to.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Submodules returns the submodules that s includes, directly or through the
+// submodules it includes, in the order they are first included.  Only the
+// includes that have been resolved, by processing the modules, are followed.
+func (s *Module) Submodules() []*Module {
+	var subs []*Module
+	seen := map[*Module]bool{s: true}
+	for includes := s.Include; len(includes) > 0; {
+		i := includes[0]
+		includes = includes[1:]
+		if i.Module == nil || seen[i.Module] {
+			continue
+		}
+		seen[i.Module] = true
+		subs = append(subs, i.Module)
+		includes = append(includes, i.Module.Include...)
+	}
+	return subs
+}
+
+// SubmoduleFor returns the module that includes the named submodule, directly
+// or through its other submodules.  An error is returned if there is no such
+// submodule, or if it is not included by exactly one module.  The modules of
+// ms must have been processed.
+func (ms *Modules) SubmoduleFor(name string) (*Module, error) {
+	sm := ms.SubModules[name]
+	if sm == nil {
+		return nil, fmt.Errorf("no such submodule: %s", name)
+	}
+
+	// ms.Modules has an entry for both the name and the name@revision of
+	// each module.
+	var mods []*Module
+	seen := map[*Module]bool{}
+	for _, m := range ms.Modules {
+		if !seen[m] {
+			seen[m] = true
+			mods = append(mods, m)
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool { return mods[i].FullName() < mods[j].FullName() })
+	var parents []*Module
+	for _, m := range mods {
+		for _, s := range m.Submodules() {
+			if s == sm {
+				parents = append(parents, m)
+				break
+			}
+		}
+	}
+
+	switch len(parents) {
+	case 0:
+		return nil, fmt.Errorf("%s: submodule %s is not included by any module", Source(sm), sm.Name)
+	case 1:
+		return parents[0], nil
+	}
+	var pnames []string
+	for _, m := range parents {
+		pnames = append(pnames, m.FullName())
+	}
+	return nil, fmt.Errorf("%s: submodule %s is included by more than one module: %s", Source(sm), sm.Name, strings.Join(pnames, ", "))
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestSubmodules(t *testing.T) {
+	tests := []struct {
+		desc       string
+		in         []string
+		sub        string
+		wantParent string
+		wantSubs   []string // the submodules of the parent
+		wantErrSub string
+	}{{
+		desc: "nested includes",
+		in: []string{
+			`module m { prefix "m"; namespace "urn:m"; include s1; include s2; }`,
+			`submodule s1 { belongs-to m { prefix "m"; } include s3; }`,
+			`submodule s2 { belongs-to m { prefix "m"; } include s3; }`,
+			`submodule s3 { belongs-to m { prefix "m"; } }`,
+		},
+		sub:        "s3",
+		wantParent: "m",
+		wantSubs:   []string{"s1", "s2", "s3"},
+	}, {
+		desc:       "no such submodule",
+		in:         []string{`module m { prefix "m"; namespace "urn:m"; }`},
+		sub:        "s",
+		wantErrSub: "no such submodule: s",
+	}, {
+		desc: "not included",
+		in: []string{
+			`module m { prefix "m"; namespace "urn:m"; }`,
+			`submodule s { belongs-to m { prefix "m"; } }`,
+		},
+		sub:        "s",
+		wantErrSub: "submodule s is not included by any module",
+	}, {
+		desc: "included by two revisions",
+		in: []string{
+			`module m { prefix "m"; namespace "urn:m"; revision 2020-01-01; include s; }`,
+			`module m { prefix "m"; namespace "urn:m"; revision 2021-01-01; include s; }`,
+			`submodule s { belongs-to m { prefix "m"; } }`,
+		},
+		sub:        "s",
+		wantErrSub: "submodule s is included by more than one module: m@2020-01-01, m@2021-01-01",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for _, in := range tt.in {
+				if err := ms.Parse(in, "in.yang"); err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+			}
+			if errs := ms.Process(); errs != nil {
+				t.Fatalf("Process: %v", errs)
+			}
+			m, err := ms.SubmoduleFor(tt.sub)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("SubmoduleFor: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if m.Name != tt.wantParent {
+				t.Errorf("SubmoduleFor(%q) = %s, want %s", tt.sub, m.Name, tt.wantParent)
+			}
+			var subs []string
+			for _, s := range m.Submodules() {
+				subs = append(subs, s.Name)
+			}
+			if diff := cmp.Diff(tt.wantSubs, subs); diff != "" {
+				t.Errorf("Submodules (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements a registry of validators for the values of named
+// typedefs, such as the date-and-time typedef of ietf-yang-types, whose
+// format is not fully captured by their YANG definition.
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A TypeValidator returns an error if value, the string form of a value, is
+// not a valid value of the typedef it was registered for.
+type TypeValidator func(value string) error
+
+// typeValidatorDictionary maps module:typedef names to their validators.
+type typeValidatorDictionary struct {
+	mu   sync.Mutex
+	dict map[string]TypeValidator
+}
+
+// Global dictionary of registered type validators.
+var typeValidators = typeValidatorDictionary{dict: map[string]TypeValidator{}}
+
+// RegisterTypeValidator registers v as the validator of the values of the
+// typedef named typedef defined by the module named module, replacing any
+// validator already registered for it.  If v is nil the registered validator
+// is removed.  A registered validator is used in addition to the validation
+// goyang does itself, by ValidateFormat and when checking the defaults of
+// leaves and leaf-lists, for every type derived from the typedef.
+func RegisterTypeValidator(module, typedef string, v TypeValidator) {
+	typeValidators.mu.Lock()
+	defer typeValidators.mu.Unlock()
+	key := module + ":" + typedef
+	if v == nil {
+		delete(typeValidators.dict, key)
+		return
+	}
+	typeValidators.dict[key] = v
+}
+
+// lookup returns the validator registered for the typedef td, if any.
+func (d *typeValidatorDictionary) lookup(td *Typedef) TypeValidator {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dict[moduleName(td)+":"+td.Name]
+}
+
+// ValidateFormat returns an error if value is rejected by the validator, if
+// any, registered with RegisterTypeValidator for y or for any of the typedefs
+// y is derived from.  A union accepts value if any of its members does.
+// ValidateFormat only applies the registered validators; a type without
+// registered validators accepts any value.
+func (y *YangType) ValidateFormat(value string) error {
+	if err := y.validateRegistered(value); err != nil {
+		return err
+	}
+	if y.Kind != Yunion || len(y.Type) == 0 {
+		return nil
+	}
+	var err error
+	for _, t := range y.Type {
+		if err = t.ValidateFormat(value); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not a valid value of any member of union %s", value, y.Name)
+}
+
+// validateRegistered applies the validators registered for the typedefs y
+// is derived from, closest first, without looking at the members of unions.
+func (y *YangType) validateRegistered(value string) error {
+	for ; y != nil && y.Base != nil; y = y.Base.YangType {
+		td, ok := y.Base.Parent.(*Typedef)
+		if !ok {
+			break
+		}
+		if v := typeValidators.lookup(td); v != nil {
+			if err := v(value); err != nil {
+				return fmt.Errorf("%q is not a valid %s:%s: %v", value, moduleName(td), td.Name, err)
+			}
+		}
+		if y.Base.YangType == y {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestRegisterTypeValidator(t *testing.T) {
+	RegisterTypeValidator("types", "date-and-time", func(value string) error {
+		_, err := time.Parse(time.RFC3339, value)
+		return err
+	})
+	RegisterTypeValidator("types", "even", func(value string) error {
+		if len(value)%2 != 0 {
+			return errors.New("odd length")
+		}
+		return nil
+	})
+	defer RegisterTypeValidator("types", "date-and-time", nil)
+	defer RegisterTypeValidator("types", "even", nil)
+
+	ms := NewModules()
+	if err := ms.Parse(`module types {
+  prefix "t";
+  namespace "urn:t";
+  typedef date-and-time { type string; }
+  typedef timestamp { type date-and-time { length 1..64; } }
+  typedef even { type string { pattern "[a-z]*"; } }
+  container c {
+    leaf when { type timestamp; }
+    leaf either { type union { type date-and-time; type even; } }
+    leaf plain { type string; }
+    leaf both { type even; }
+  }
+}`, "types.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	c := ToEntry(ms.Modules["types"]).Dir["c"]
+
+	for _, tt := range []struct {
+		leaf    string
+		value   string
+		wantErr string
+	}{
+		{leaf: "when", value: "2020-01-02T03:04:05Z"},
+		{leaf: "when", value: "yesterday", wantErr: `"yesterday" is not a valid types:date-and-time`},
+		{leaf: "either", value: "2020-01-02T03:04:05Z"},
+		{leaf: "either", value: "monday"},
+		{leaf: "either", value: "today", wantErr: `"today" is not a valid value of any member of union union`},
+		{leaf: "plain", value: "yesterday"},
+		{leaf: "both", value: "ab"},
+		{leaf: "both", value: "abc", wantErr: `"abc" is not a valid types:even: odd length`},
+	} {
+		err := c.Dir[tt.leaf].Type.ValidateFormat(tt.value)
+		if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+			t.Errorf("%s: ValidateFormat(%q): %s", tt.leaf, tt.value, diff)
+		}
+	}
+
+	ms = NewModules()
+	if err := ms.Parse(`module types {
+  prefix "t";
+  namespace "urn:t";
+  typedef date-and-time { type string; }
+  leaf l { type date-and-time; default "yesterday"; }
+}`, "types.yang"); err != nil {
+		t.Fatal(err)
+	}
+	errs := ms.Process()
+	if len(errs) != 1 {
+		t.Fatalf("Process: got %v, want 1 error", errs)
+	}
+	if diff := errdiff.Substring(errs[0], `"yesterday" is not a valid types:date-and-time`); diff != "" {
+		t.Errorf("Process: %s", diff)
+	}
+
+	RegisterTypeValidator("types", "date-and-time", nil)
+	if err := c.Dir["when"].Type.ValidateFormat("yesterday"); err != nil {
+		t.Errorf("ValidateFormat after removing the validator: %v", err)
+	}
+}
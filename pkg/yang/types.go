@@ -100,15 +100,18 @@ func addTypedefs(t Typedefer) {
 
 // resolveTypedefs is called after all of modules and submodules have been read,
 // as well as their imports and includes.  It resolves all typedefs found in all
-// modules and submodules read in.
-func resolveTypedefs() []error {
+// modules and submodules read in.  If failFast is set, resolveTypedefs stops at
+// the first typedef that cannot be resolved.
+func resolveTypedefs(failFast bool) []error {
 	var errs []error
 
 	// When resolve typedefs, we may need to look up other typedefs.
 	// We gather all typedefs into a slice so we don't deadlock on
 	// typeDict.
 	for _, td := range typeDict.typedefs() {
-		errs = append(errs, td.resolve()...)
+		if errs = append(errs, td.resolve()...); failFast && len(errs) > 0 {
+			break
+		}
 	}
 	return errs
 }
@@ -228,6 +231,9 @@ check:
 	t.YangType = &y
 
 	if v := t.RequireInstance; v != nil {
+		if y.Kind != Yleafref && y.Kind != YinstanceIdentifier {
+			errs = append(errs, fmt.Errorf("%s: require-instance is only valid for leafref and instance-identifier types, not %s", Source(v), t.Name))
+		}
 		b, err := v.asBool()
 		if err != nil {
 			errs = append(errs, err)
@@ -236,17 +242,22 @@ check:
 	}
 	if v := t.Path; v != nil {
 		y.Path = v.asString()
+		y.pathType = t
 	}
 	// If we are directly of type decimal64 then we must specify
-	// fraction-digits.
-	isDecimal64 := y.Kind == Ydecimal64 && (t.Name == "decimal64" || t.FractionDigits != nil)
+	// fraction-digits.  A type derived from decimal64 inherits the
+	// fraction-digits of its base type and cannot change them, so its range
+	// is at the scale of its base type.
+	isDecimal64 := y.Kind == Ydecimal64
 	switch {
-	case isDecimal64:
+	case isDecimal64 && source == "builtin":
 		i, err := t.FractionDigits.asRangeInt(1, 18)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("%s: %v", Source(t), err))
 		}
 		y.FractionDigits = int(i)
+	case isDecimal64 && t.FractionDigits != nil:
+		errs = append(errs, fmt.Errorf("%s: fraction-digits cannot be changed by a type derived from %s", Source(t), t.Name))
 	case t.FractionDigits != nil:
 		errs = append(errs, fmt.Errorf("%s: fraction-digits only allowed for decimal64 values", Source(t)))
 	case y.Kind == Yidentityref:
@@ -283,8 +294,10 @@ check:
 		case !y.Range.Contains(yr):
 			errs = append(errs, fmt.Errorf("%s: bad range: %v not within %v", Source(t.Range), yr, y.Range))
 		case yr.Equal(y.Range):
+			y.rangeError = constraintError(t.Range.ErrorMessage, t.Range.ErrorAppTag)
 		default:
 			y.Range = yr
+			y.rangeError = constraintError(t.Range.ErrorMessage, t.Range.ErrorAppTag)
 		}
 	}
 
@@ -296,6 +309,7 @@ check:
 		case !y.Length.Contains(yr):
 			errs = append(errs, fmt.Errorf("%s: bad length: %v not within %v", Source(t.Length), yr, y.Length))
 		case yr.Equal(y.Length):
+			y.lengthError = constraintError(t.Length.ErrorMessage, t.Length.ErrorAppTag)
 		default:
 			for _, r := range yr {
 				if r.Min.Kind == Negative {
@@ -304,6 +318,7 @@ check:
 				}
 			}
 			y.Length = yr
+			y.lengthError = constraintError(t.Length.ErrorMessage, t.Length.ErrorAppTag)
 		}
 	}
 
@@ -322,24 +337,92 @@ check:
 		return e.Set(name, i)
 	}
 
+	// restrict sets name in e, a restriction of the enums or bits of base,
+	// to the value it has in base.  As described in sections 9.6.3 and
+	// 9.7.3 of RFC 7950, name must be in base, and value, if given, must
+	// be the value name has in base.
+	restrict := func(e, base *EnumType, kind, name string, value *Value) error {
+		if !base.IsDefined(name) {
+			return fmt.Errorf("%s %s is not defined by the base type %s", kind, name, td.Name)
+		}
+		bv := base.Value(name)
+		if value != nil {
+			n, err := ParseInt(value.Name)
+			if err != nil {
+				return err
+			}
+			i, err := n.Int()
+			if err != nil {
+				return err
+			}
+			if i != bv {
+				return fmt.Errorf("%s %s has value %d, but is %d in the base type %s", kind, name, i, bv, td.Name)
+			}
+		}
+		return e.Set(name, bv)
+	}
+
+	// The enums and bits of a type derived from an enumeration or bits type
+	// restrict those of the base type, keeping their values.
 	if len(t.Enum) > 0 {
+		base := y.Enum
+		baseValues := map[string]EnumValue{}
+		for _, ev := range y.enumValues {
+			baseValues[ev.Name] = ev
+		}
 		enum := NewEnumType()
+		var values []EnumValue
 		for _, e := range t.Enum {
-			if err := set(enum, e.Name, e.Value); err != nil {
+			var err error
+			if base == nil {
+				err = set(enum, e.Name, e.Value)
+			} else {
+				err = restrict(enum, base, "enum", e.Name, e.Value)
+			}
+			if err != nil {
 				errs = append(errs, fmt.Errorf("%s: %v", Source(e), err))
+				continue
+			}
+			// An enum that restricts a base enum keeps the
+			// documentation of the base enum unless it has its own.
+			ev := baseValues[e.Name]
+			ev.Name = e.Name
+			ev.Value = int32(enum.Value(e.Name))
+			if e.Description != nil {
+				ev.Description = e.Description.Name
 			}
+			if e.Reference != nil {
+				ev.Reference = e.Reference.Name
+			}
+			values = append(values, ev)
 		}
 		y.Enum = enum
+		y.enumValues = values
 	}
 
 	if len(t.Bit) > 0 {
+		base := y.Bit
 		bit := NewBitfield()
+		desc := map[string]string{}
 		for _, e := range t.Bit {
-			if err := set(bit, e.Name, e.Position); err != nil {
+			var err error
+			if base == nil {
+				err = set(bit, e.Name, e.Position)
+			} else {
+				err = restrict(bit, base, "bit", e.Name, e.Position)
+				if d, ok := y.bitDescription[e.Name]; ok {
+					desc[e.Name] = d
+				}
+			}
+			if err != nil {
 				errs = append(errs, fmt.Errorf("%s: %v", Source(e), err))
 			}
+			if e.Description != nil {
+				desc[e.Name] = e.Description.Name
+			}
 		}
 		y.Bit = bit
+		y.bitDescription = desc
 	}
 
 	// Append any newly found patterns to the end of the list of patterns.
@@ -364,6 +447,15 @@ check:
 			patterns[p] = true
 			y.Pattern = append(y.Pattern, p)
 		}
+		if pv.ErrorMessage != nil || pv.ErrorAppTag != nil {
+			// The map is shared with the type y was copied from.
+			pe := make(map[string]ConstraintError, len(y.patternErrors)+1)
+			for k, v := range y.patternErrors {
+				pe[k] = v
+			}
+			pe[p] = constraintError(pv.ErrorMessage, pv.ErrorAppTag)
+			y.patternErrors = pe
+		}
 	}
 
 	// I don't know of an easy way to use a type as a key to a map,
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -287,6 +288,22 @@ type YangType struct {
 	Pattern          []string    `json:",omitempty"` // limiting XSD-TYPES expressions on strings
 	Range            YangRange   `json:",omitempty"` // range for integers
 	Type             []*YangType `json:",omitempty"` // for unions
+
+	// pathType is the type statement that holds the path of a leafref,
+	// whose prefixes are resolved in the module of the statement.
+	pathType *Type
+	// bitDescription maps the names of the bits of a bits type to their
+	// descriptions.
+	bitDescription map[string]string
+	// enumValues are the enums of an enumeration type, in the order they
+	// were declared.
+	enumValues []EnumValue
+	// rangeError and lengthError are the error-message and error-app-tag
+	// of the range and length restrictions of the type, and patternErrors
+	// those of its patterns, by pattern.
+	rangeError    ConstraintError
+	lengthError   ConstraintError
+	patternErrors map[string]ConstraintError
 }
 
 // BaseTypedefs is a map of all base types to the Typedef structure manufactured
@@ -353,14 +370,443 @@ func (y *YangType) Equal(t *YangType) bool {
 		!ssEqual(y.Pattern, t.Pattern),
 		len(y.Range) != len(t.Range),
 		!y.Range.Equal(t.Range),
-		!tsEqual(y.Type, t.Type):
+		!tsEqual(y.Type, t.Type),
+		!enumsEqual(y.Bit, t.Bit),
+		!enumsEqual(y.Enum, t.Enum):
 
 		return false
 	}
-	// TODO(borman): Base, Bit, Enum
+	// TODO(borman): Base
 	return true
 }
 
+// Compatible reports whether every value of y is also a value of t, that is,
+// whether y is the same type as t or a restriction of it, ignoring names,
+// units, defaults, descriptions and the typedefs the types are derived from.
+// Unlike Equal, which requires the types to be the same, Compatible allows y
+// to be narrower than t:
+//
+//	integer and decimal64 types must have the same builtin type, and each
+//	part of the range of y must be within a part of the range of t; y may
+//	not have more fraction-digits than t
+//	the length of a string or binary y must be within the length of t, and
+//	y must have all the patterns of t
+//	the enums of y must be enums of t with the same values, and the bits of
+//	y must be bits of t with the same positions
+//	the base of an identityref y must be the base of t or derived from it
+//	a leafref y must have the same path as t, and must require an instance
+//	if t does, as for an instance-identifier
+//	each member of a union y must be compatible with t, and a y that is not
+//	a union is compatible with a union t if it is compatible with any of
+//	its members
+//
+// Patterns are compared as text, so two patterns that match the same
+// strings, but are written differently, are different.
+func (y *YangType) Compatible(t *YangType) bool {
+	switch {
+	case y == nil || t == nil:
+		return y == t
+	case y.Kind == Yunion:
+		for _, m := range y.Type {
+			if !m.Compatible(t) {
+				return false
+			}
+		}
+		return len(y.Type) > 0
+	case t.Kind == Yunion:
+		for _, m := range t.Type {
+			if y.Compatible(m) {
+				return true
+			}
+		}
+		return false
+	case y.Kind != t.Kind:
+		return false
+	}
+
+	switch y.Kind {
+	case Yint8, Yint16, Yint32, Yint64, Yuint8, Yuint16, Yuint32, Yuint64, Ydecimal64:
+		if y.FractionDigits > t.FractionDigits {
+			return false
+		}
+		return rangeWithin(y.Range, t.Range, y.fullRange(), t.fullRange())
+	case Ystring, Ybinary:
+		full := Uint64Range
+		if !rangeWithin(y.Length, t.Length, full, full) {
+			return false
+		}
+		patterns := map[string]bool{}
+		for _, p := range y.Pattern {
+			patterns[p] = true
+		}
+		for _, p := range t.Pattern {
+			if !patterns[p] {
+				return false
+			}
+		}
+	case Yenum:
+		return enumsWithin(y.Enum, t.Enum)
+	case Ybits:
+		return enumsWithin(y.Bit, t.Bit)
+	case Yidentityref:
+		if y.IdentityBase == t.IdentityBase || t.IdentityBase == nil {
+			return true
+		}
+		if y.IdentityBase == nil {
+			return false
+		}
+		for _, v := range t.IdentityBase.Values {
+			if v == y.IdentityBase {
+				return true
+			}
+		}
+		return false
+	case Yleafref:
+		if y.Path != t.Path {
+			return false
+		}
+		return !t.RequireInstance() || y.RequireInstance()
+	case YinstanceIdentifier:
+		return !t.RequireInstance() || y.RequireInstance()
+	}
+	return true
+}
+
+// rangeWithin reports whether each part of the range r is within a part of
+// the range s.  An empty range, and the min and max of a range, are those of
+// rfull and sfull, the ranges of the builtin types of r and s.
+func rangeWithin(r, s, rfull, sfull YangRange) bool {
+	r, s = r.resolve(rfull), s.resolve(sfull)
+parts:
+	for _, rp := range r {
+		for _, sp := range s {
+			if !rp.Min.Less(sp.Min) && !sp.Max.Less(rp.Max) {
+				continue parts
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// enumsWithin reports whether each name of e has the same value in f.
+func enumsWithin(e, f *EnumType) bool {
+	if e == nil || f == nil {
+		return e == nil
+	}
+	for name, v := range e.toInt {
+		if fv, ok := f.toInt[name]; !ok || fv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// RequireInstance reports whether a value of the leafref or
+// instance-identifier type y must refer to an existing instance in the data
+// tree, as set by the require-instance statement of y or of the typedef it is
+// derived from.  It defaults to true, and is false for all other types.
+func (y *YangType) RequireInstance() bool {
+	return (y.Kind == Yleafref || y.Kind == YinstanceIdentifier) && !y.OptionalInstance
+}
+
+// KindName returns the name of the builtin type of y as used in a YANG file,
+// such as "uint32", "enumeration" or "union", regardless of the typedefs y
+// is derived from.  The member types of a union are available from its Type
+// field or from UnionTypes.
+func (y *YangType) KindName() string {
+	return y.Kind.String()
+}
+
+// TypeNames returns the name of the builtin type of y, as returned by
+// KindName, and the name y was declared with.  The two are the same unless y
+// is derived from a typedef, in which case declared is the name of the
+// typedef.
+func (y *YangType) TypeNames() (builtin, declared string) {
+	return y.KindName(), y.Name
+}
+
+// IsDerived reports whether y is derived from a typedef rather than being a
+// builtin type.
+func (y *YangType) IsDerived() bool {
+	return y.Name != y.KindName()
+}
+
+// UnionTypes returns the member types of the union y, replacing each member
+// that is itself a union, including a member whose type is a typedef of a
+// union, with its own member types.  An error is returned if
+// y is not a union or if any member type has not been resolved to a builtin
+// type.
+func (y *YangType) UnionTypes() ([]*YangType, error) {
+	if y == nil || y.Kind != Yunion {
+		return nil, errors.New("type is not a union")
+	}
+	var types []*YangType
+	for _, t := range y.Type {
+		switch {
+		case t == nil || t.Kind == Ynone:
+			return nil, fmt.Errorf("union %s has an unresolved member type", y.Name)
+		case t.Kind == Yunion:
+			ts, err := t.UnionTypes()
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, ts...)
+		default:
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+// UniqueUnionTypes returns the member types of the union y, as returned by
+// UnionTypes, without the members that are Equal to an earlier member.
+func (y *YangType) UniqueUnionTypes() ([]*YangType, error) {
+	types, err := y.UnionTypes()
+	if err != nil {
+		return nil, err
+	}
+	var unique []*YangType
+Types:
+	for _, t := range types {
+		for _, u := range unique {
+			if t.Equal(u) {
+				continue Types
+			}
+		}
+		unique = append(unique, t)
+	}
+	return unique, nil
+}
+
+// enumsEqual returns true if e1 and e2 define the same names with the same
+// values.
+func enumsEqual(e1, e2 *EnumType) bool {
+	if e1 == nil || e2 == nil {
+		return e1 == e2
+	}
+	return reflect.DeepEqual(e1.toInt, e2.toInt)
+}
+
+// UnionTypeKinds returns the kinds of the member types of the union y, as
+// returned by UnionTypes, in the order they first appear and without
+// duplicates.  UnionTypeKinds returns nil if y is not a valid union.
+func (y *YangType) UnionTypeKinds() []TypeKind {
+	types, err := y.UnionTypes()
+	if err != nil {
+		return nil
+	}
+	var kinds []TypeKind
+	seen := map[TypeKind]bool{}
+	for _, t := range types {
+		if !seen[t.Kind] {
+			seen[t.Kind] = true
+			kinds = append(kinds, t.Kind)
+		}
+	}
+	return kinds
+}
+
+// A YangBit is a single bit of a bits type.
+type YangBit struct {
+	Name        string
+	Position    uint32
+	Description string
+}
+
+// Bits returns the bits defined by the bits type y, sorted by position.  An
+// error is returned if y is not a bits type.
+func (y *YangType) Bits() ([]YangBit, error) {
+	if y.Kind != Ybits || y.Bit == nil {
+		return nil, fmt.Errorf("type %s is not a bits type", y.Name)
+	}
+	var bits []YangBit
+	for _, pos := range y.Bit.Values() {
+		name := y.Bit.Name(pos)
+		bits = append(bits, YangBit{
+			Name:        name,
+			Position:    uint32(pos),
+			Description: y.bitDescription[name],
+		})
+	}
+	return bits, nil
+}
+
+// ValidateBits returns an error if names is not a valid value of the bits
+// type y, that is, if any name is not a bit of y or appears more than once.
+func (y *YangType) ValidateBits(names []string) error {
+	if y.Kind != Ybits || y.Bit == nil {
+		return fmt.Errorf("type %s is not a bits type", y.Name)
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if !y.Bit.IsDefined(name) {
+			return fmt.Errorf("%q is not a valid bit of type %s", name, y.Name)
+		}
+		if seen[name] {
+			return fmt.Errorf("bit %q appears more than once", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// Bounds returns the smallest and the largest values of y, an integer or
+// decimal64 type, after the restriction of its range.  If y has a range made
+// of several parts, min is the lower bound of the lowest part and max is the
+// upper bound of the highest.  The bounds of a type whose range is not
+// restricted, and the min and max keywords of a range, are those of the
+// builtin type y is derived from.  The bounds of a decimal64 type have the
+// fraction-digits of y.
+func (y *YangType) Bounds() (min, max *Number, err error) {
+	full := y.fullRange()
+	if full == nil {
+		return nil, nil, fmt.Errorf("type %s is not an integer or decimal64 type", y.Name)
+	}
+	r := y.Range.resolve(full)
+	lo, hi := r[0].Min, r[0].Max
+	for _, yr := range r[1:] {
+		if yr.Min.Less(lo) {
+			lo = yr.Min
+		}
+		if hi.Less(yr.Max) {
+			hi = yr.Max
+		}
+	}
+	return &lo, &hi, nil
+}
+
+// fullRange returns the range of the builtin type of y, an integer or
+// decimal64 type, or nil for any other type.  The range of a decimal64 type
+// is at the scale of the fraction-digits of y.
+func (y *YangType) fullRange() YangRange {
+	switch y.Kind {
+	case Yint8, Yint16, Yint32, Yint64, Yuint8, Yuint16, Yuint32, Yuint64:
+		return baseTypes[y.Kind.String()].Range
+	case Ydecimal64:
+		fd := uint8(y.FractionDigits)
+		return YangRange{{
+			Min: Number{Kind: Negative, Value: AbsMinInt64, FractionDigits: fd},
+			Max: Number{Kind: Positive, Value: MaxInt64, FractionDigits: fd},
+		}}
+	}
+	return nil
+}
+
+// resolve returns a copy of r with the min and max keywords replaced by the
+// smallest and largest values of full, the range of the builtin type of r.
+// An empty r resolves to full.
+func (r YangRange) resolve(full YangRange) YangRange {
+	if len(r) == 0 {
+		return append(YangRange(nil), full...)
+	}
+	out := make(YangRange, len(r))
+	for i, p := range r {
+		if p.Min.Kind == MinNumber && len(full) > 0 {
+			p.Min = full[0].Min
+		}
+		if p.Max.Kind == MaxNumber && len(full) > 0 {
+			p.Max = full[len(full)-1].Max
+		}
+		out[i] = p
+	}
+	return out
+}
+
+// An EnumValue is a single enum of an enumeration type.
+type EnumValue struct {
+	Name        string
+	Value       int32
+	Description string
+	Reference   string
+}
+
+// A ConstraintError is the error-message and error-app-tag of a must, range,
+// length or pattern statement, which are reported when a value violates the
+// constraint, as described in section 7.5.4 of RFC 7950.  Both are empty if
+// the statement has neither.
+type ConstraintError struct {
+	Message string // the argument of the error-message statement, if any
+	AppTag  string // the argument of the error-app-tag statement, if any
+}
+
+// constraintError returns the ConstraintError of the error-message msg and
+// the error-app-tag tag, either of which may be nil.
+func constraintError(msg, tag *Value) ConstraintError {
+	return ConstraintError{Message: msg.asString(), AppTag: tag.asString()}
+}
+
+// RangeError returns the error-message and error-app-tag of the range
+// statement that restricts the Range of y, which may be that of a typedef y
+// is derived from.
+func (y *YangType) RangeError() ConstraintError {
+	return y.rangeError
+}
+
+// LengthError returns the error-message and error-app-tag of the length
+// statement that restricts the Length of y, which may be that of a typedef y
+// is derived from.
+func (y *YangType) LengthError() ConstraintError {
+	return y.lengthError
+}
+
+// PatternError returns the error-message and error-app-tag of pattern, one
+// of the Pattern of y.  They are those of the pattern statement of y, or of
+// the typedefs y is derived from, closest first, that declares pattern with
+// an error-message or error-app-tag.
+func (y *YangType) PatternError(pattern string) ConstraintError {
+	return y.patternErrors[pattern]
+}
+
+// validateDefault returns an error if value, a default written in the module
+// or submodule m, is not a valid value of y.  Only the values of enumeration,
+// bits and identityref types, and of unions with such members, are checked.
+// A union accepts value if any of its members does, and a member of a type
+// that is not checked accepts any value.  The validators registered with
+// RegisterTypeValidator for the typedefs y is derived from are also applied.
+func (y *YangType) validateDefault(value string, m *Module) error {
+	if err := y.validateRegistered(value); err != nil {
+		return err
+	}
+	switch y.Kind {
+	case Yenum:
+		if y.Enum == nil || !y.Enum.IsDefined(value) {
+			return fmt.Errorf("%q is not an enum of type %s", value, y.Name)
+		}
+	case Ybits:
+		return y.ValidateBits(strings.Fields(value))
+	case Yempty:
+		return fmt.Errorf("type %s cannot have a default", y.Name)
+	case Yidentityref:
+		if y.IdentityBase == nil {
+			return nil
+		}
+		identities.mu.Lock()
+		id, errs := m.findIdentityBase(value)
+		identities.mu.Unlock()
+		if errs != nil || id.Identity == nil {
+			return fmt.Errorf("%q is not a known identity", value)
+		}
+		for _, v := range y.IdentityBase.Values {
+			if v == id.Identity {
+				return nil
+			}
+		}
+		return fmt.Errorf("identity %s is not derived from %s", value, y.IdentityBase.Name)
+	case Yunion:
+		var err error
+		for _, t := range y.Type {
+			if err = t.validateDefault(value, m); err == nil {
+				return nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%q is not a valid value of any member of union %s", value, y.Name)
+		}
+	}
+	return nil
+}
+
 // Install builtin types as know types
 func init() {
 	for k, v := range baseTypes {
@@ -674,3 +674,583 @@ func TestNumberString(t *testing.T) {
 		})
 	}
 }
+
+func TestUnionTypes(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module union-types {
+  prefix "u";
+  namespace "urn:u";
+
+  typedef int-or-string {
+    type union { type int8; type string; }
+  }
+  leaf nested {
+    type union {
+      type int-or-string;
+      type boolean;
+      type union { type int8; type enumeration { enum one; } }
+    }
+  }
+  leaf flat { type int-or-string; }
+  leaf duplicates {
+    type union {
+      type enumeration { enum a; }
+      type enumeration { enum b; }
+      type int8;
+      type int8 { range "1..2"; }
+      type int-or-string;
+    }
+  }
+  leaf not-union { type string; }
+}`, "union-types.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("union-types")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	tests := []struct {
+		desc       string
+		leaf       string
+		wantTypes  []string
+		wantUnique []string
+		wantKinds  []TypeKind
+		wantErrSub string
+	}{{
+		desc:       "nested unions are flattened",
+		leaf:       "nested",
+		wantTypes:  []string{"int8", "string", "boolean", "int8", "enumeration"},
+		wantUnique: []string{"int8", "string", "boolean", "enumeration"},
+		wantKinds:  []TypeKind{Yint8, Ystring, Ybool, Yenum},
+	}, {
+		desc:       "union typedef",
+		leaf:       "flat",
+		wantTypes:  []string{"int8", "string"},
+		wantUnique: []string{"int8", "string"},
+		wantKinds:  []TypeKind{Yint8, Ystring},
+	}, {
+		desc:       "only identical members are duplicates",
+		leaf:       "duplicates",
+		wantTypes:  []string{"enumeration", "enumeration", "int8", "int8", "int8", "string"},
+		wantUnique: []string{"enumeration", "enumeration", "int8", "int8", "string"},
+		wantKinds:  []TypeKind{Yenum, Yint8, Ystring},
+	}, {
+		desc:       "not a union",
+		leaf:       "not-union",
+		wantErrSub: "not a union",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			y := e.Dir[tt.leaf].Type
+			types, err := y.UnionTypes()
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("UnionTypes: %s", diff)
+			}
+			var got []string
+			for _, t := range types {
+				got = append(got, t.Name)
+			}
+			if diff := cmp.Diff(tt.wantTypes, got); diff != "" {
+				t.Errorf("UnionTypes (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantKinds, y.UnionTypeKinds()); diff != "" {
+				t.Errorf("UnionTypeKinds (-want, +got):\n%s", diff)
+			}
+			unique, err := y.UniqueUnionTypes()
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("UniqueUnionTypes: %s", diff)
+			}
+			got = nil
+			for _, t := range unique {
+				got = append(got, t.Name)
+			}
+			if diff := cmp.Diff(tt.wantUnique, got); diff != "" {
+				t.Errorf("UniqueUnionTypes (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBits(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module bits-test {
+  prefix "b";
+  namespace "urn:b";
+
+  typedef flags {
+    type bits {
+      bit high { position 7; description "high bit"; }
+      bit low { position 0; }
+      bit middle { description "next bit"; }
+    }
+  }
+  leaf direct { type flags; }
+  leaf not-bits { type string; }
+}`, "bits-test.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("bits-test")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	y := e.Dir["direct"].Type
+	got, err := y.Bits()
+	if err != nil {
+		t.Fatalf("Bits: %v", err)
+	}
+	want := []YangBit{
+		{Name: "low", Position: 0},
+		{Name: "high", Position: 7, Description: "high bit"},
+		{Name: "middle", Position: 8, Description: "next bit"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Bits (-want, +got):\n%s", diff)
+	}
+	if _, err := e.Dir["not-bits"].Type.Bits(); err == nil {
+		t.Errorf("Bits of a string type: got no error")
+	}
+
+	tests := []struct {
+		desc       string
+		in         []string
+		wantErrSub string
+	}{{
+		desc: "empty",
+	}, {
+		desc: "valid bits",
+		in:   []string{"high", "low"},
+	}, {
+		desc:       "unknown bit",
+		in:         []string{"low", "bogus"},
+		wantErrSub: `"bogus" is not a valid bit`,
+	}, {
+		desc:       "duplicate bit",
+		in:         []string{"low", "low"},
+		wantErrSub: `bit "low" appears more than once`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if diff := errdiff.Substring(y.ValidateBits(tt.in), tt.wantErrSub); diff != "" {
+				t.Errorf("ValidateBits: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRestrictedEnumBits(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module restrict {
+  prefix "r";
+  namespace "urn:r";
+
+  typedef color {
+    type enumeration {
+      enum red { value 1; }
+      enum green { value 5; }
+      enum blue;
+      enum black { value 10; }
+    }
+  }
+  typedef primary {
+    type color {
+      enum red;
+      enum green { value 5; }
+      enum blue;
+    }
+  }
+  typedef flags {
+    type bits {
+      bit a { position 1; description "bit a"; }
+      bit b { position 3; }
+      bit c { position 4; }
+    }
+  }
+  leaf paint { type primary { enum blue; enum red; } }
+  leaf any { type primary; }
+  leaf some-flags { type flags { bit c; bit a; } }
+}`, "restrict.yang"); err != nil {
+		t.Fatal(err)
+	}
+	e, errs := ms.GetModule("restrict")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+
+	for name, want := range map[string]map[string]int64{
+		"paint": {"red": 1, "blue": 6},
+		"any":   {"red": 1, "green": 5, "blue": 6},
+	} {
+		if diff := cmp.Diff(want, e.Dir[name].Type.Enum.NameMap()); diff != "" {
+			t.Errorf("%s: enums (-want, +got):\n%s", name, diff)
+		}
+	}
+	got, err := e.Dir["some-flags"].Type.Bits()
+	if err != nil {
+		t.Fatalf("Bits: %v", err)
+	}
+	want := []YangBit{
+		{Name: "a", Position: 1, Description: "bit a"},
+		{Name: "c", Position: 4},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Bits (-want, +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		desc       string
+		leaf       string
+		wantErrSub string
+	}{{
+		desc:       "enum not in base",
+		leaf:       `leaf l { type primary { enum black; } }`,
+		wantErrSub: "enum black is not defined by the base type primary",
+	}, {
+		desc:       "enum with a different value",
+		leaf:       `leaf l { type color { enum red { value 2; } } }`,
+		wantErrSub: "enum red has value 2, but is 1 in the base type color",
+	}, {
+		desc:       "bit not in base",
+		leaf:       `leaf l { type flags { bit d; } }`,
+		wantErrSub: "bit d is not defined by the base type flags",
+	}, {
+		desc:       "bit with a different position",
+		leaf:       `leaf l { type flags { bit b { position 2; } } }`,
+		wantErrSub: "bit b has value 2, but is 3 in the base type flags",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(`module bad {
+  prefix "b";
+  namespace "urn:b";
+  typedef color { type enumeration { enum red { value 1; } enum blue; } }
+  typedef primary { type color { enum red; } }
+  typedef flags { type bits { bit a { position 1; } bit b { position 3; } } }
+  `+tt.leaf+`
+}`, "bad.yang"); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			if errs := ms.Process(); len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("Process: %s", diff)
+			}
+		})
+	}
+}
+
+func TestTypeNames(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef counter { type uint32; }
+  typedef big-counter { type counter; }
+  identity base-id;
+  leaf a { type uint32; }
+  leaf b { type big-counter; }
+  leaf c { type decimal64 { fraction-digits 2; } }
+  leaf d { type leafref { path "../a"; } }
+  leaf e { type identityref { base base-id; } }
+  leaf f { type union { type string; type counter; } }
+  leaf g { type enumeration { enum x; } }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+	for _, tt := range []struct {
+		leaf              string
+		builtin, declared string
+		derived           bool
+	}{
+		{leaf: "a", builtin: "uint32", declared: "uint32"},
+		{leaf: "b", builtin: "uint32", declared: "big-counter", derived: true},
+		{leaf: "c", builtin: "decimal64", declared: "decimal64"},
+		{leaf: "d", builtin: "leafref", declared: "leafref"},
+		{leaf: "e", builtin: "identityref", declared: "identityref"},
+		{leaf: "f", builtin: "union", declared: "union"},
+		{leaf: "g", builtin: "enumeration", declared: "enumeration"},
+	} {
+		y := mod.Dir[tt.leaf].Type
+		if got := y.KindName(); got != tt.builtin {
+			t.Errorf("%s: KindName() = %q, want %q", tt.leaf, got, tt.builtin)
+		}
+		builtin, declared := y.TypeNames()
+		if builtin != tt.builtin || declared != tt.declared {
+			t.Errorf("%s: TypeNames() = %q, %q, want %q, %q", tt.leaf, builtin, declared, tt.builtin, tt.declared)
+		}
+		if got := y.IsDerived(); got != tt.derived {
+			t.Errorf("%s: IsDerived() = %v, want %v", tt.leaf, got, tt.derived)
+		}
+	}
+
+	var members []string
+	for _, y := range mod.Dir["f"].Type.Type {
+		members = append(members, y.KindName())
+	}
+	if diff := cmp.Diff([]string{"string", "uint32"}, members); diff != "" {
+		t.Errorf("union member kinds (-want, +got):\n%s", diff)
+	}
+}
+
+func TestRequireInstance(t *testing.T) {
+	tests := []struct {
+		desc       string
+		leaf       string
+		want       bool
+		wantErrSub string
+	}{{
+		desc: "leafref default",
+		leaf: `leaf l { type leafref { path "../target"; } }`,
+		want: true,
+	}, {
+		desc: "instance-identifier default",
+		leaf: `leaf l { type instance-identifier; }`,
+		want: true,
+	}, {
+		desc: "instance-identifier not required",
+		leaf: `leaf l { type instance-identifier { require-instance false; } }`,
+	}, {
+		desc: "leafref not required",
+		leaf: `leaf l { type leafref { path "../target"; require-instance false; } }`,
+	}, {
+		desc: "inherited from typedef",
+		leaf: `leaf l { type optional-ref; }`,
+	}, {
+		desc: "overridden from typedef",
+		leaf: `leaf l { type optional-ref { require-instance true; } }`,
+		want: true,
+	}, {
+		desc: "not a reference",
+		leaf: `leaf l { type string; }`,
+	}, {
+		desc:       "require-instance of a string",
+		leaf:       `leaf l { type string { require-instance true; } }`,
+		wantErrSub: "require-instance is only valid for leafref and instance-identifier types, not string",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef optional-ref { type instance-identifier { require-instance false; } }
+  leaf target { type string; }
+  `+tt.leaf+`
+}`, "m.yang"); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			if errs := ms.Process(); len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("Process: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := ToEntry(ms.Modules["m"]).Dir["l"].Type.RequireInstance(); got != tt.want {
+				t.Errorf("RequireInstance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDerivedDecimal64(t *testing.T) {
+	tests := []struct {
+		desc       string
+		leaf       string
+		wantRange  string
+		wantErrSub string
+	}{{
+		desc:      "inherited fraction-digits",
+		leaf:      `leaf l { type money; }`,
+		wantRange: "-100.00..100.00",
+	}, {
+		desc:      "narrowed range at the base scale",
+		leaf:      `leaf l { type money { range "0.5..10.25"; } }`,
+		wantRange: "0.50..10.25",
+	}, {
+		desc:      "narrowed typedef",
+		leaf:      `leaf l { type small-money; }`,
+		wantRange: "0.00..1.50",
+	}, {
+		desc:       "range finer than the base scale",
+		leaf:       `leaf l { type money { range "0.125..1"; } }`,
+		wantErrSub: "bad range",
+	}, {
+		desc:       "range outside of the base range",
+		leaf:       `leaf l { type money { range "0..200"; } }`,
+		wantErrSub: "not within -100.00..100.00",
+	}, {
+		desc:       "redeclared fraction-digits",
+		leaf:       `leaf l { type money { fraction-digits 3; } }`,
+		wantErrSub: "fraction-digits cannot be changed by a type derived from money",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef money { type decimal64 { fraction-digits 2; range "-100..100"; } }
+  typedef small-money { type money { range "0..1.5"; } }
+  `+tt.leaf+`
+}`, "m.yang"); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			if errs := ms.Process(); len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("Process: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			y := ToEntry(ms.Modules["m"]).Dir["l"].Type
+			if y.FractionDigits != 2 {
+				t.Errorf("FractionDigits = %d, want 2", y.FractionDigits)
+			}
+			if got := y.Range.String(); got != tt.wantRange {
+				t.Errorf("Range = %s, want %s", got, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestBounds(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  typedef percent { type uint8 { range "0..100"; } }
+  leaf u8 { type uint8; }
+  leaf i64 { type int64; }
+  leaf pct { type percent; }
+  leaf parts { type int32 { range "-5..-1 | 10 | 20..30"; } }
+  leaf open { type int16 { range "min..0 | 100..max"; } }
+  leaf dec { type decimal64 { fraction-digits 2; range "-1.5..2 | 7.25"; } }
+  leaf dec-full { type decimal64 { fraction-digits 3; } }
+  leaf s { type string; }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+
+	tests := []struct {
+		leaf       string
+		wantMin    string
+		wantMax    string
+		wantErrSub string
+	}{
+		{leaf: "u8", wantMin: "0", wantMax: "255"},
+		{leaf: "i64", wantMin: "-9223372036854775808", wantMax: "9223372036854775807"},
+		{leaf: "pct", wantMin: "0", wantMax: "100"},
+		{leaf: "parts", wantMin: "-5", wantMax: "30"},
+		{leaf: "open", wantMin: "-32768", wantMax: "32767"},
+		{leaf: "dec", wantMin: "-1.50", wantMax: "7.25"},
+		{leaf: "dec-full", wantMin: "-9223372036854775.808", wantMax: "9223372036854775.807"},
+		{leaf: "s", wantErrSub: "type string is not an integer or decimal64 type"},
+	}
+	for _, tt := range tests {
+		min, max, err := mod.Dir[tt.leaf].Type.Bounds()
+		if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+			t.Errorf("%s: Bounds: %s", tt.leaf, diff)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := min.String(); got != tt.wantMin {
+			t.Errorf("%s: min = %s, want %s", tt.leaf, got, tt.wantMin)
+		}
+		if got := max.String(); got != tt.wantMax {
+			t.Errorf("%s: max = %s, want %s", tt.leaf, got, tt.wantMax)
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  identity base;
+  identity derived { base base; }
+  leaf i8 { type int8; }
+  leaf small { type int8 { range "0..10"; } }
+  leaf parts { type int8 { range "0..2 | 5..max"; } }
+  leaf i16 { type int16; }
+  leaf dec2 { type decimal64 { fraction-digits 2; range "0..10"; } }
+  leaf dec3 { type decimal64 { fraction-digits 3; range "0..10"; } }
+  leaf str { type string; description "cosmetic"; }
+  leaf short { type string { length "1..5"; pattern "[a-z]*"; } }
+  leaf letters { type string { pattern "[a-z]*"; } }
+  leaf rgb { type enumeration { enum red; enum green; enum blue; } }
+  leaf rg { type enumeration { enum red; enum green; } }
+  leaf renumbered { type enumeration { enum red { value 3; } } }
+  leaf base-ref { type identityref { base base; } }
+  leaf derived-ref { type identityref { base derived; } }
+  leaf ref { type leafref { path "../str"; } }
+  leaf weak-ref { type leafref { path "../str"; require-instance false; } }
+  leaf u { type union { type int8; type string; } }
+  leaf u-small { type union { type int8 { range "0..10"; } type string { length "1..5"; } } }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+
+	tests := []struct {
+		y, t string
+		want bool
+	}{
+		{"i8", "i8", true},
+		{"small", "i8", true},
+		{"i8", "small", false},
+		{"parts", "i8", true},
+		{"small", "parts", false},
+		{"i8", "i16", false},
+		{"dec2", "dec3", true},
+		{"dec3", "dec2", false},
+		{"short", "str", true},
+		{"short", "letters", true},
+		{"letters", "short", false},
+		{"str", "letters", false},
+		{"rg", "rgb", true},
+		{"rgb", "rg", false},
+		{"renumbered", "rgb", false},
+		{"derived-ref", "base-ref", true},
+		{"base-ref", "derived-ref", false},
+		{"ref", "weak-ref", true},
+		{"weak-ref", "ref", false},
+		{"small", "u", true},
+		{"short", "u", true},
+		{"rgb", "u", false},
+		{"u-small", "u", true},
+		{"u", "u-small", false},
+		{"u", "str", false},
+	}
+	for _, tt := range tests {
+		y, typ := mod.Dir[tt.y].Type, mod.Dir[tt.t].Type
+		if got := y.Compatible(typ); got != tt.want {
+			t.Errorf("%s.Compatible(%s) = %v, want %v", tt.y, tt.t, got, tt.want)
+		}
+	}
+}
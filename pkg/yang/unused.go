@@ -0,0 +1,154 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the detection of imports that are not used by the
+// module or submodule that imports them.
+
+import "strings"
+
+// An argumentKind is the kind of argument of a statement whose argument may
+// refer to the definitions of another module using its prefix.
+type argumentKind int
+
+const (
+	identifierArgument = argumentKind(iota) // a possibly prefixed identifier
+	expressionArgument                      // a schema node identifier or if-feature expression
+	xpathArgument                           // an XPath expression
+)
+
+// prefixedKeywords are the keywords whose arguments may refer to the
+// definitions of another module using its prefix, and the kinds of their
+// arguments.  The argument of a default statement is a value, which is only
+// a reference if it is a prefixed identifier, such as an identity.
+var prefixedKeywords = map[string]argumentKind{
+	"augment":    expressionArgument,
+	"base":       identifierArgument,
+	"default":    identifierArgument,
+	"deviation":  expressionArgument,
+	"if-feature": expressionArgument,
+	"must":       xpathArgument,
+	"path":       xpathArgument,
+	"refine":     expressionArgument,
+	"type":       identifierArgument,
+	"unique":     expressionArgument,
+	"uses":       identifierArgument,
+	"when":       xpathArgument,
+}
+
+// UnusedImports returns the imports of each module and submodule of ms that
+// are not used by it.  The returned map is keyed by the name of the importing
+// module or submodule, and then by the prefix of the import, and its values
+// are the names of the imported modules.  Only the latest revision of each
+// module is checked.  Modules that use all of their imports are not in the
+// map.
+//
+// An import is used if its prefix qualifies a reference to a type, grouping,
+// identity, feature or node in the argument of a type, uses, base,
+// if-feature, augment, deviation, refine, path, when, must or unique
+// statement, if it is the prefix of a default statement whose argument is a
+// prefixed name, such as the default of an identityref, or if its prefix
+// qualifies the keyword of an extension statement.  Other arguments, such as
+// descriptions and the defaults of strings, are not references.  A string
+// literal of the XPath expression of a when, must or path statement that is
+// a prefixed name, such as the identity in
+// derived-from(../type, 'x:ethernet'), is also a reference.
+func (ms *Modules) UnusedImports() map[string]map[string]string {
+	unused := map[string]map[string]string{}
+	for _, mods := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		for _, name := range sortedModuleNames(mods, false) {
+			m := mods[name]
+			if len(m.Import) == 0 {
+				continue
+			}
+			used := map[string]bool{}
+			usedPrefixes(m.Source, used)
+			for _, i := range m.Import {
+				if i.Prefix == nil || used[i.Prefix.Name] {
+					continue
+				}
+				if unused[name] == nil {
+					unused[name] = map[string]string{}
+				}
+				unused[name][i.Prefix.Name] = i.Name
+			}
+		}
+	}
+	return unused
+}
+
+// usedPrefixes adds the prefixes referred to by s and its substatements to
+// used.
+func usedPrefixes(s *Statement, used map[string]bool) {
+	if s == nil {
+		return
+	}
+	if prefix, _ := getPrefix(s.Keyword); prefix != "" {
+		used[prefix] = true
+	}
+	if kind, ok := prefixedKeywords[s.Keyword]; ok {
+		switch arg := strings.TrimSpace(s.Argument); {
+		case kind != identifierArgument:
+			for _, prefix := range argumentPrefixes(arg, kind == xpathArgument) {
+				used[prefix] = true
+			}
+		case isQName(arg):
+			if prefix, _ := getPrefix(arg); prefix != "" {
+				used[prefix] = true
+			}
+		}
+	}
+	for _, ss := range s.statements {
+		usedPrefixes(ss, used)
+	}
+}
+
+// argumentPrefixes returns the prefixes of the prefixed identifiers in arg,
+// which may be an identifier, a schema node identifier, an if-feature
+// expression or, if xpath is true, an XPath expression.  The names of XPath
+// axes such as child:: are skipped, and so are string literals, unless xpath
+// is true and the literal is a prefixed name, which in XPath is most likely
+// an identity compared with an identityref.  The parts of an argument that
+// are not XPath are tokenized on their own, as if-feature expressions and
+// the identifiers of unique statements are separated by spaces.
+func argumentPrefixes(arg string, xpath bool) []string {
+	parts := []string{arg}
+	if !xpath {
+		parts = strings.Fields(arg)
+	}
+	var prefixes []string
+	for _, part := range parts {
+		tokens, err := tokenizeXPath(part)
+		if err != nil {
+			continue
+		}
+		for _, t := range tokens {
+			name := t.text
+			switch t.kind {
+			case xpName, xpFunction:
+			case xpLiteral:
+				if name = strings.TrimSpace(name[1 : len(name)-1]); !xpath || !isQName(name) {
+					continue
+				}
+			default:
+				continue
+			}
+			if prefix, _ := getPrefix(name); prefix != "" {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+	return prefixes
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnusedImports(t *testing.T) {
+	defs := `module defs {
+  prefix "d";
+  namespace "urn:d";
+  typedef t { type string; }
+  grouping g { leaf gl { type string; } }
+  identity base-id;
+  feature f;
+  extension e;
+  container c { leaf l { type string; } }
+}`
+	tests := []struct {
+		desc string
+		in   string
+		want map[string]map[string]string
+	}{{
+		desc: "unused",
+		in:   `import defs { prefix "d"; } leaf x { type string; }`,
+		want: map[string]map[string]string{"user": {"d": "defs"}},
+	}, {
+		desc: "unused with prefix in description",
+		in:   `import defs { prefix "d"; } leaf x { type string; description "d:c"; }`,
+		want: map[string]map[string]string{"user": {"d": "defs"}},
+	}, {
+		desc: "unused with prefix in the string literal of a default",
+		in:   `import defs { prefix "d"; } leaf x { type string; default "'d:c'"; }`,
+		want: map[string]map[string]string{"user": {"d": "defs"}},
+	}, {
+		desc: "unused with prefix in a URL",
+		in:   `import defs { prefix "d"; } leaf x { type string; default "d://x"; description "see d://x"; }`,
+		want: map[string]map[string]string{"user": {"d": "defs"}},
+	}, {
+		desc: "identity default",
+		in: `import defs { prefix "d"; }
+  leaf x { type union { type string; type identityref { base u:local; } } default "d:base-id"; }
+  identity local;`,
+	}, {
+		desc: "identity in string literal",
+		in: `import defs { prefix "d"; }
+  leaf x { type string; when "derived-from-or-self(../y, 'd:base-id')"; }
+  leaf y { type identityref { base u:local; } }
+  identity local;`,
+	}, {
+		desc: "identity in compared string literal",
+		in:   `import defs { prefix "d"; } leaf x { type string; must "../y = \"d:base-id\""; } leaf y { type string; }`,
+	}, {
+		desc: "type",
+		in:   `import defs { prefix "d"; } leaf x { type d:t; }`,
+	}, {
+		desc: "uses",
+		in:   `import defs { prefix "d"; } container x { uses d:g; }`,
+	}, {
+		desc: "identityref base",
+		in:   `import defs { prefix "d"; } leaf x { type identityref { base d:base-id; } }`,
+	}, {
+		desc: "if-feature",
+		in:   `import defs { prefix "d"; } leaf x { type string; if-feature "not d:f"; }`,
+	}, {
+		desc: "augment",
+		in:   `import defs { prefix "d"; } augment "/d:c" { leaf x { type string; } }`,
+	}, {
+		desc: "when",
+		in:   `import defs { prefix "d"; } leaf x { type string; when "/d:c/d:l = 'up'"; }`,
+	}, {
+		desc: "must",
+		in:   `import defs { prefix "d"; } leaf x { type string; must "count(/d:c) > 0"; }`,
+	}, {
+		desc: "extension",
+		in:   `import defs { prefix "d"; } leaf x { type string; d:e; }`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for name, text := range map[string]string{
+				"defs": defs,
+				"user": `module user { prefix "u"; namespace "urn:u"; ` + tt.in + ` }`,
+			} {
+				if err := ms.Parse(text, name+".yang"); err != nil {
+					t.Fatalf("Parse %s: %v", name, err)
+				}
+			}
+			if errs := ms.Process(); errs != nil {
+				t.Fatalf("Process: %v", errs)
+			}
+			want := tt.want
+			if want == nil {
+				want = map[string]map[string]string{}
+			}
+			if diff := cmp.Diff(want, ms.UnusedImports()); diff != "" {
+				t.Errorf("UnusedImports (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestArgumentPrefixes(t *testing.T) {
+	tests := []struct {
+		in    string
+		xpath bool
+		want  []string
+	}{
+		{in: "string"},
+		{in: "a:b", want: []string{"a"}},
+		{in: "/a:b/c:d", want: []string{"a", "c"}},
+		{in: "a:f or (b:g and not c)", want: []string{"a", "b"}},
+		{in: "child::a:b", xpath: true, want: []string{"a"}},
+		{in: "../x = \"p:q\" and r:s", want: []string{"r"}},
+		{in: "../x = \"p:q\" and r:s", xpath: true, want: []string{"p", "r"}},
+		{in: "../x = 'a b:c' or ../y = 'http://x'", xpath: true},
+		{in: "12:30"},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, argumentPrefixes(tt.in, tt.xpath)); diff != "" {
+			t.Errorf("argumentPrefixes(%q, %v) (-want, +got):\n%s", tt.in, tt.xpath, diff)
+		}
+	}
+}
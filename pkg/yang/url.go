@@ -0,0 +1,95 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the parsing of YANG source fetched from a URL.
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// yangMediaType is the media type of YANG modules, registered by RFC 6020
+// section 14.
+const yangMediaType = "application/yang"
+
+// SetHTTPClient sets c as the client ParseURL uses to fetch http and https
+// URLs.  Setting c to nil restores the default of http.DefaultClient.
+func (ms *Modules) SetHTTPClient(c *http.Client) {
+	ms.httpClient = c
+}
+
+// ParseURL fetches the YANG source at rawURL and adds it to ms, as Parse
+// does, naming the source by rawURL.  The http, https and file schemes are
+// supported.  An error is returned if an http or https URL does not return a
+// status of 200 OK with a Content-Type of application/yang, or if ctx is
+// done before the source is fetched.  A file URL must have an absolute path,
+// and either no host or the host localhost.
+func (ms *Modules) ParseURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", rawURL, err)
+	}
+	var data []byte
+	switch u.Scheme {
+	case "http", "https":
+		if data, err = ms.fetch(ctx, u); err != nil {
+			return fmt.Errorf("%s: %v", rawURL, err)
+		}
+	case "file":
+		if u.Host != "" && u.Host != "localhost" {
+			return fmt.Errorf("%s: file URL has remote host %s", rawURL, u.Host)
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%s: %v", rawURL, err)
+		}
+		if data, err = ioutil.ReadFile(u.Path); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%s: unsupported URL scheme %q", rawURL, u.Scheme)
+	}
+	return ms.Parse(string(data), rawURL)
+}
+
+// fetch returns the YANG source returned by a GET of the http or https URL u.
+func (ms *Modules) fetch(ctx context.Context, u *url.URL) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", yangMediaType)
+	c := ms.httpClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	ct := resp.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != yangMediaType {
+		return nil, fmt.Errorf("unexpected Content-Type %q, want %s", ct, yangMediaType)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestParseURL(t *testing.T) {
+	const module = `module remote { prefix "r"; namespace "urn:r"; }`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/remote.yang":
+			w.Header().Set("Content-Type", "application/yang; charset=utf-8")
+			fmt.Fprint(w, module)
+		case "/remote.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, module)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "parseurl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "remote.yang")
+	if err := ioutil.WriteFile(file, []byte(module), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		desc       string
+		ctx        context.Context
+		url        string
+		wantErrSub string
+	}{{
+		desc: "http",
+		url:  srv.URL + "/remote.yang",
+	}, {
+		desc: "file",
+		url:  "file://" + filepath.ToSlash(file),
+	}, {
+		desc:       "wrong content type",
+		url:        srv.URL + "/remote.txt",
+		wantErrSub: `unexpected Content-Type "text/plain", want application/yang`,
+	}, {
+		desc:       "not found",
+		url:        srv.URL + "/missing.yang",
+		wantErrSub: "unexpected status 404 Not Found",
+	}, {
+		desc:       "canceled",
+		ctx:        canceled,
+		url:        srv.URL + "/remote.yang",
+		wantErrSub: "context canceled",
+	}, {
+		desc:       "missing file",
+		url:        "file://" + filepath.ToSlash(filepath.Join(dir, "missing.yang")),
+		wantErrSub: "missing.yang",
+	}, {
+		desc:       "remote file",
+		url:        "file://example.com/remote.yang",
+		wantErrSub: "file URL has remote host example.com",
+	}, {
+		desc:       "unsupported scheme",
+		url:        "ftp://example.com/remote.yang",
+		wantErrSub: `unsupported URL scheme "ftp"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			ms := NewModules()
+			ms.SetHTTPClient(srv.Client())
+			err := ms.ParseURL(ctx, tt.url)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("ParseURL: %s", diff)
+			}
+			if err == nil && ms.Modules["remote"] == nil {
+				t.Errorf("module remote not added")
+			}
+		})
+	}
+}
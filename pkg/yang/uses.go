@@ -0,0 +1,196 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the tracking of the groupings that entries were
+// expanded from, and the application of the refine statements of uses
+// statements.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupingOrigin returns the grouping that e was defined in, if e was added
+// to its tree by the expansion of a uses statement, or nil otherwise.  For a
+// node defined in a grouping that is used by another grouping, the grouping
+// that contains the node's statement is returned.
+func (e *Entry) GroupingOrigin() *Grouping {
+	return e.groupingOrigin
+}
+
+// UsesOrigin returns the uses statement whose expansion of the grouping
+// returned by GroupingOrigin added e to its tree, or nil if e was not added
+// by the expansion of a uses statement.  The statement of e itself, from
+// e.Node, is the statement within the grouping.  As with GroupingOrigin, for
+// a node defined in a grouping that is used by another grouping, the uses
+// statement within the other grouping is returned.
+func (e *Entry) UsesOrigin() *Uses {
+	return e.usesOrigin
+}
+
+// IsGroupingExpansion reports whether e was added to its tree by the
+// expansion of a uses statement.
+func (e *Entry) IsGroupingExpansion() bool {
+	return e.groupingOrigin != nil
+}
+
+// AppliedRefinements returns the refine statements that have been applied to
+// e by the uses statements that expanded it, in the order they were applied.
+func (e *Entry) AppliedRefinements() []*Statement {
+	return e.refinements
+}
+
+// expandedFrom records that the children of e, the expansion of the grouping
+// used by u, were defined in that grouping, and applies the refine statements
+// of u to them.  Children that were expanded from the uses statements of the
+// grouping keep the grouping they were defined in.
+func (e *Entry) expandedFrom(u *Uses) {
+	g := FindGrouping(u, u.Name, map[string]bool{})
+	if g == nil {
+		return
+	}
+	var mark func(e *Entry)
+	mark = func(e *Entry) {
+		for _, c := range e.Dir {
+			if c.groupingOrigin == nil {
+				c.groupingOrigin = g
+				c.usesOrigin = u
+			}
+			mark(c)
+		}
+	}
+	mark(e)
+
+	for _, r := range u.Refine {
+		t := e
+		for _, step := range strings.Split(strings.TrimSpace(r.Name), "/") {
+			_, name := getPrefix(step)
+			if t = t.Dir[name]; t == nil {
+				break
+			}
+		}
+		if t == nil || t == e {
+			e.addError(fmt.Errorf("%s: refine target %s not found in grouping %s", Source(r), r.Name, u.Name))
+			continue
+		}
+		e.addError(t.refine(r))
+	}
+}
+
+// ApplyRefine applies the properties set by r to e, as a uses statement
+// does for its refine statements when the grouping it uses is expanded.  The
+// target of r is e itself: the Name of r is not used to find it.  r need not
+// have been parsed from a YANG module, in which case it may have no Source,
+// and is then not returned by AppliedRefinements.  An error is returned if r
+// sets a property that does not apply to e, such as the presence of a leaf,
+// if the config or mandatory of r is neither true nor false, or if the
+// default set by r is not a valid value of the type of e.  e is unchanged if
+// an error is returned.
+func (e *Entry) ApplyRefine(r *Refine) error {
+	saved := *e
+	if err := e.refine(r); err != nil {
+		return err
+	}
+	if r.Default != nil {
+		if err := e.checkDefault(); err != nil {
+			*e = saved
+			return err
+		}
+	}
+	return nil
+}
+
+// refine applies the properties set by the refine statement r to e and
+// records r as applied to e.  If r cannot be applied, e is left unchanged;
+// refine replaces, rather than changes, the maps and lists that e refers to,
+// so restoring the fields of e undoes it.
+func (e *Entry) refine(r *Refine) (err error) {
+	saved := *e
+	defer func() {
+		if err != nil {
+			*e = saved
+		}
+	}()
+
+	triState := func(v *Value) (TriState, error) {
+		switch v.Name {
+		case "true":
+			return TSTrue, nil
+		case "false":
+			return TSFalse, nil
+		}
+		return TSUnset, fmt.Errorf("%s: invalid refine value: %s", Source(v), v.Name)
+	}
+
+	// The Extra map of e is shared with the grouping it was duplicated
+	// from, so it is copied before it is changed.
+	extra := make(map[string][]interface{}, len(e.Extra))
+	for k, v := range e.Extra {
+		extra[k] = v
+	}
+	e.Extra = extra
+
+	if r.Description != nil {
+		e.Description = r.Description.Name
+	}
+	if r.Reference != nil {
+		e.Extra["reference"] = []interface{}{r.Reference}
+	}
+	if r.Default != nil {
+		e.Default = r.Default.Name
+	}
+	if r.Config != nil {
+		ts, err := triState(r.Config)
+		if err != nil {
+			return err
+		}
+		e.Config = ts
+	}
+	if r.Mandatory != nil {
+		ts, err := triState(r.Mandatory)
+		if err != nil {
+			return err
+		}
+		e.Mandatory = ts
+	}
+	if r.Presence != nil {
+		if !e.IsContainer() {
+			return fmt.Errorf("%s: refine of %s sets presence, but it is not a container", Source(r), r.Name)
+		}
+		e.Presence = r.Presence
+		e.Extra["presence"] = []interface{}{r.Presence}
+	}
+	if len(r.Must) > 0 {
+		e.Extra["must"] = append(append([]interface{}(nil), e.Extra["must"]...), r.Must)
+	}
+	if r.MinElements != nil || r.MaxElements != nil {
+		if e.ListAttr == nil {
+			return fmt.Errorf("%s: refine of %s sets min-elements or max-elements, but it is not a list or leaf-list", Source(r), r.Name)
+		}
+		la := *e.ListAttr
+		if r.MinElements != nil {
+			la.MinElements = r.MinElements
+		}
+		if r.MaxElements != nil {
+			la.MaxElements = r.MaxElements
+		}
+		e.ListAttr = &la
+	}
+	if r.Source != nil {
+		e.refinements = append(e.refinements, r.Source)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestGroupingOrigin(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+
+  grouping inner {
+    leaf i { type string; }
+  }
+  grouping outer {
+    container c {
+      uses inner;
+      leaf o { type string; }
+    }
+  }
+  container top {
+    uses outer;
+    leaf own { type string; }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+	for path, want := range map[string]struct{ grouping, uses string }{
+		"top":     {},
+		"top/own": {},
+		"top/c":   {"outer", "m.yang:15:5"},
+		"top/c/o": {"outer", "m.yang:15:5"},
+		"top/c/i": {"inner", "m.yang:10:7"},
+	} {
+		e := mod.Find(path)
+		if e == nil {
+			t.Errorf("%s not found", path)
+			continue
+		}
+		var got string
+		if g := e.GroupingOrigin(); g != nil {
+			got = g.Name
+		}
+		if got != want.grouping {
+			t.Errorf("%s: GroupingOrigin() = %q, want %q", path, got, want.grouping)
+		}
+		if e.IsGroupingExpansion() != (want.grouping != "") {
+			t.Errorf("%s: IsGroupingExpansion() = %v, want %v", path, e.IsGroupingExpansion(), want.grouping != "")
+		}
+		got = ""
+		if u := e.UsesOrigin(); u != nil {
+			got = Source(u)
+		}
+		if got != want.uses {
+			t.Errorf("%s: UsesOrigin() at %q, want %q", path, got, want.uses)
+		}
+	}
+}
+
+func TestRefine(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+
+  grouping g {
+    container c {
+      leaf l {
+        type string;
+        description "original";
+      }
+      leaf-list ll { type string; }
+    }
+  }
+  container a {
+    uses g {
+      refine "c/l" {
+        description "refined";
+        default "x";
+        mandatory false;
+        config false;
+      }
+      refine "m:c" {
+        presence "enabled";
+      }
+      refine "c/ll" {
+        max-elements 3;
+        min-elements 1;
+      }
+    }
+  }
+  container b {
+    uses g;
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	mod := ToEntry(ms.Modules["m"])
+
+	type props struct {
+		Description, Default, Presence, Min, Max string
+		Config                                   TriState
+		Refinements                              []string
+	}
+	get := func(e *Entry) props {
+		p := props{
+			Description: e.Description,
+			Default:     e.Default,
+			Presence:    e.Presence.asString(),
+			Config:      e.Config,
+		}
+		if e.ListAttr != nil {
+			p.Min = e.ListAttr.MinElements.asString()
+			p.Max = e.ListAttr.MaxElements.asString()
+		}
+		for _, s := range e.AppliedRefinements() {
+			p.Refinements = append(p.Refinements, s.Argument)
+		}
+		return p
+	}
+	for path, want := range map[string]props{
+		"a/c":    {Presence: "enabled", Refinements: []string{"m:c"}},
+		"a/c/l":  {Description: "refined", Default: "x", Config: TSFalse, Refinements: []string{"c/l"}},
+		"a/c/ll": {Min: "1", Max: "3", Refinements: []string{"c/ll"}},
+		// The other use of the grouping is not refined.
+		"b/c":    {},
+		"b/c/l":  {Description: "original"},
+		"b/c/ll": {},
+	} {
+		e := mod.Find(path)
+		if e == nil {
+			t.Errorf("%s not found", path)
+			continue
+		}
+		if diff := cmp.Diff(want, get(e)); diff != "" {
+			t.Errorf("%s (-want, +got):\n%s", path, diff)
+		}
+	}
+}
+
+func TestRefineErrors(t *testing.T) {
+	tests := []struct {
+		desc       string
+		refine     string
+		wantErrSub string
+	}{{
+		desc:       "missing target",
+		refine:     `refine "c/missing" { description "d"; }`,
+		wantErrSub: "refine target c/missing not found in grouping g",
+	}, {
+		desc:       "presence on leaf",
+		refine:     `refine "c/l" { presence "p"; }`,
+		wantErrSub: "refine of c/l sets presence, but it is not a container",
+	}, {
+		desc:       "max-elements on leaf",
+		refine:     `refine "c/l" { max-elements 3; }`,
+		wantErrSub: "refine of c/l sets min-elements or max-elements, but it is not a list or leaf-list",
+	}, {
+		desc:       "invalid mandatory",
+		refine:     `refine "c/l" { mandatory maybe; }`,
+		wantErrSub: "invalid refine value: maybe",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  grouping g { container c { leaf l { type string; } } }
+  container top { uses g { `+tt.refine+` } }
+}`, "m.yang"); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			if errs := ms.Process(); len(errs) > 0 {
+				err = errs[0]
+			}
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("Process: %s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyRefine(t *testing.T) {
+	ms := NewModules()
+	if err := ms.Parse(`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    leaf l { type string; }
+    leaf color { type enumeration { enum red; enum blue; } }
+    leaf-list ll { type string; }
+  }
+}`, "m.yang"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	c := ToEntry(ms.Modules["m"]).Dir["c"]
+
+	l := c.Dir["l"]
+	if err := l.ApplyRefine(&Refine{
+		Description: &Value{Name: "refined"},
+		Default:     &Value{Name: "x"},
+		Config:      &Value{Name: "false"},
+		Mandatory:   &Value{Name: "false"},
+	}); err != nil {
+		t.Fatalf("ApplyRefine(l): %v", err)
+	}
+	if l.Description != "refined" || l.Default != "x" || l.Config != TSFalse || l.Mandatory != TSFalse {
+		t.Errorf("refined l: got description %q, default %q, config %v, mandatory %v", l.Description, l.Default, l.Config, l.Mandatory)
+	}
+	if got := l.AppliedRefinements(); len(got) != 0 {
+		t.Errorf("AppliedRefinements() = %v, want none", got)
+	}
+
+	ll := c.Dir["ll"]
+	if err := ll.ApplyRefine(&Refine{MinElements: &Value{Name: "1"}, MaxElements: &Value{Name: "4"}}); err != nil {
+		t.Fatalf("ApplyRefine(ll): %v", err)
+	}
+	if got, want := ll.ListAttr.MinElements.asString()+".."+ll.ListAttr.MaxElements.asString(), "1..4"; got != want {
+		t.Errorf("refined ll elements = %s, want %s", got, want)
+	}
+
+	for _, tt := range []struct {
+		desc       string
+		entry      *Entry
+		refine     *Refine
+		wantErrSub string
+	}{{
+		desc:       "invalid default",
+		entry:      c.Dir["color"],
+		refine:     &Refine{Default: &Value{Name: "green"}},
+		wantErrSub: `invalid default "green" of /m/c/color`,
+	}, {
+		desc:       "presence on leaf",
+		entry:      c.Dir["color"],
+		refine:     &Refine{Name: "color", Presence: &Value{Name: "p"}},
+		wantErrSub: "refine of color sets presence, but it is not a container",
+	}, {
+		desc:       "invalid config",
+		entry:      c,
+		refine:     &Refine{Config: &Value{Name: "maybe"}},
+		wantErrSub: "invalid refine value: maybe",
+	}, {
+		desc:       "invalid default after other properties",
+		entry:      c.Dir["color"],
+		refine:     &Refine{Description: &Value{Name: "refined"}, Reference: &Value{Name: "r"}, Config: &Value{Name: "false"}, Default: &Value{Name: "green"}},
+		wantErrSub: `invalid default "green" of /m/c/color`,
+	}, {
+		desc:       "invalid mandatory after other properties",
+		entry:      c.Dir["color"],
+		refine:     &Refine{Description: &Value{Name: "refined"}, Reference: &Value{Name: "r"}, Mandatory: &Value{Name: "maybe"}},
+		wantErrSub: "invalid refine value: maybe",
+	}} {
+		before := *tt.entry
+		err := tt.entry.ApplyRefine(tt.refine)
+		if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+			t.Errorf("%s: ApplyRefine: %s", tt.desc, diff)
+		}
+		// A failed ApplyRefine leaves the entry unchanged.
+		if got := tt.entry; got.Description != before.Description || got.Default != before.Default || got.Config != before.Config || got.Mandatory != before.Mandatory || !reflect.DeepEqual(got.Extra, before.Extra) {
+			t.Errorf("%s: entry changed by failed ApplyRefine: got description %q, default %q, config %v, mandatory %v, extra %v", tt.desc, got.Description, got.Default, got.Config, got.Mandatory, got.Extra)
+		}
+	}
+}
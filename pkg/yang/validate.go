@@ -0,0 +1,188 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the validation of processed modules against the
+// semantic rules of YANG.
+
+import (
+	"fmt"
+)
+
+// A ValidationCode identifies the rule a ValidationError reports the
+// violation of.
+type ValidationCode string
+
+// The codes of the errors returned by Validate.
+const (
+	InvalidMustXPath       ValidationCode = "invalid-must-xpath"
+	UnresolvedLeafref      ValidationCode = "unresolved-leafref"
+	UnresolvedAugment      ValidationCode = "unresolved-augment"
+	UnresolvedDeviation    ValidationCode = "unresolved-deviation"
+	UnresolvedIdentityBase ValidationCode = "unresolved-identity-base"
+	UnresolvedUses         ValidationCode = "unresolved-uses"
+)
+
+// A ValidationError is a violation of a semantic rule of YANG found by
+// Validate.
+type ValidationError struct {
+	Code      ValidationCode
+	Entry     *Entry     // the entry in error, if the error is about one
+	Statement *Statement // the statement in error
+	Message   string     // the description of the error, with its location
+}
+
+// Error implements the error interface.
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationOptions are the options of ValidateWithOptions.
+type ValidationOptions struct {
+	// Suppress are the codes of the errors that are not reported.
+	Suppress []ValidationCode
+}
+
+// Validate is ValidateWithOptions with the default options.
+func (ms *Modules) Validate() []ValidationError {
+	return ms.ValidateWithOptions(ValidationOptions{})
+}
+
+// ValidateWithOptions checks the modules of ms, and the submodules they
+// include, against the semantic rules of YANG that parsing does not check:
+//
+//   - the expression of each must statement of a node is a syntactically
+//     valid XPath expression (InvalidMustXPath)
+//   - the path of each leafref refers to a leaf or leaf-list, as reported by
+//     ValidateLeafrefs (UnresolvedLeafref)
+//   - the target of each augment statement of a module exists
+//     (UnresolvedAugment)
+//   - the target of each deviation statement exists, unless the deviation
+//     has removed it (UnresolvedDeviation)
+//   - the base of each identity is a known identity (UnresolvedIdentityBase)
+//   - the grouping of each uses statement is known (UnresolvedUses)
+//
+// The errors are ordered by module name and then by rule, and the errors
+// whose codes are in opts.Suppress are omitted.  Process must be called
+// before ValidateWithOptions; the rules are checked even if it returned
+// errors.
+func (ms *Modules) ValidateWithOptions(opts ValidationOptions) []ValidationError {
+	suppressed := map[ValidationCode]bool{}
+	for _, c := range opts.Suppress {
+		suppressed[c] = true
+	}
+	var errs []ValidationError
+	report := func(code ValidationCode, e *Entry, s *Statement, format string, args ...interface{}) {
+		if !suppressed[code] {
+			errs = append(errs, ValidationError{Code: code, Entry: e, Statement: s, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	names := ms.moduleNames()
+
+	leafrefs := map[string][]LeafrefError{}
+	if !suppressed[UnresolvedLeafref] {
+		for _, le := range ms.ValidateLeafrefs() {
+			// The leaf may be defined by a grouping of another
+			// module, so the error is reported for the module of the
+			// tree it is in.
+			r := le.Entry
+			for r.Parent != nil {
+				r = r.Parent
+			}
+			leafrefs[r.Name] = append(leafrefs[r.Name], le)
+		}
+	}
+
+	for _, name := range names {
+		m := ms.Modules[name]
+		root := ToEntry(m)
+
+		root.walk(func(e *Entry) bool {
+			for _, mc := range e.MustConditions() {
+				if err := checkXPath(mc.XPath); err != nil {
+					var s *Statement
+					n := e.Node
+					if mc.Must != nil {
+						s = mc.Must.Statement()
+						n = mc.Must
+					}
+					report(InvalidMustXPath, e, s, "%s: must %q of %s is not a valid XPath expression: %v", Source(n), mc.XPath, e.Path(), err)
+				}
+			}
+			return true
+		})
+
+		for _, le := range leafrefs[name] {
+			report(UnresolvedLeafref, le.Entry, le.Entry.Node.Statement(), "%v", le.Err)
+		}
+
+		mods := append([]*Module{m}, m.Submodules()...)
+		for _, sm := range mods {
+			// The augments that Process could not apply remain in
+			// Augments.
+			for _, a := range ToEntry(sm).Augments {
+				report(UnresolvedAugment, a, a.Node.Statement(), "%s: target %s of augment not found", Source(a.Node), a.Name)
+			}
+		}
+
+		for _, sm := range mods {
+			se := ToEntry(sm)
+			for _, d := range se.Deviations {
+				if _, ok := d.Deviate[DeviationNotSupported]; ok {
+					continue
+				}
+				// Find records an error for an unknown prefix, which
+				// is reported here instead.
+				saved := se.Errors
+				target := se.Find(d.DeviatedPath)
+				se.Errors = saved
+				if target == nil {
+					report(UnresolvedDeviation, d.Entry, d.Node.Statement(), "%s: target %s of deviation not found", Source(d.Node), d.DeviatedPath)
+				}
+			}
+		}
+
+		for _, sm := range mods {
+			for _, i := range sm.Identity {
+				if i.Base == nil {
+					continue
+				}
+				identities.mu.Lock()
+				base, berrs := sm.findIdentityBase(i.Base.Name)
+				identities.mu.Unlock()
+				if berrs != nil || base == nil || base.Identity == nil {
+					report(UnresolvedIdentityBase, nil, i.Statement(), "%s: base %s of identity %s not found", Source(i), i.Base.Name, i.Name)
+				}
+			}
+		}
+
+		for _, sm := range mods {
+			var checkUses func(n Node)
+			checkUses = func(n Node) {
+				if u, ok := n.(*Uses); ok && FindGrouping(u, u.Name, map[string]bool{}) == nil {
+					report(UnresolvedUses, nil, u.Statement(), "%s: grouping %s of uses not found", Source(u), u.Name)
+				}
+				for _, c := range childNodes(n) {
+					if _, ok := c.(*Value); !ok {
+						checkUses(c)
+					}
+				}
+			}
+			checkUses(sm)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	// The identities of all the modules processed are kept, so the
+	// identity with an unknown base must not outlive the test.
+	defer func() { identities.dict = map[string]resolvedIdentity{} }()
+
+	tests := []struct {
+		desc string
+		in   []string
+		opts ValidationOptions
+		want []string
+	}{{
+		desc: "valid",
+		in: []string{`module m {
+  prefix "m";
+  namespace "urn:m";
+  identity base;
+  identity derived { base base; }
+  grouping g { leaf l { type string; } }
+  container c {
+    must "count(l) > 0";
+    uses g;
+    leaf r { type leafref { path "../l"; } }
+  }
+  augment "/c" { leaf a { type string; } }
+  deviation "/c/a" { deviate not-supported; }
+  deviation "/c/r" { deviate add { config false; } }
+}`},
+	}, {
+		desc: "invalid must, leafref, augment and deviation",
+		in: []string{`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    must "count(l) >";
+    leaf l { type string; }
+    leaf r { type leafref { path "../nosuch"; } }
+  }
+  augment "/nosuch" { leaf a { type string; } }
+  deviation "/c/nosuch" { deviate add { config false; } }
+}`},
+		want: []string{
+			`invalid-must-xpath: m.yang:5:5: must "count(l) >" of /m/c is not a valid XPath expression: unexpected end of expression`,
+			`unresolved-leafref: m.yang:7:5: leafref path "../nosuch" of /m/c/r: nosuch not found in /m/c`,
+			`unresolved-augment: m.yang:9:3: target /nosuch of augment not found`,
+			`unresolved-deviation: m.yang:10:3: target /c/nosuch of deviation not found`,
+		},
+	}, {
+		desc: "suppressed",
+		in: []string{`module m {
+  prefix "m";
+  namespace "urn:m";
+  container c {
+    must "count(l) >";
+    leaf r { type leafref { path "../nosuch"; } }
+  }
+}`},
+		opts: ValidationOptions{Suppress: []ValidationCode{InvalidMustXPath}},
+		want: []string{
+			`unresolved-leafref: m.yang:6:5: leafref path "../nosuch" of /m/c/r: nosuch not found in /m/c`,
+		},
+	}, {
+		desc: "unknown identity base and grouping",
+		in: []string{`module m {
+  prefix "m";
+  namespace "urn:m";
+  identity derived { base nosuch; }
+  container c { uses nosuch; }
+}`},
+		want: []string{
+			`unresolved-identity-base: m.yang:4:3: base nosuch of identity derived not found`,
+			`unresolved-uses: m.yang:5:17: grouping nosuch of uses not found`,
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for _, in := range tt.in {
+				if err := ms.Parse(in, "m.yang"); err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+			}
+			// The errors of Process are those that Validate reports.
+			ms.Process()
+			var got []string
+			for _, err := range ms.ValidateWithOptions(tt.opts) {
+				got = append(got, string(err.Code)+": "+err.Message)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ValidateWithOptions (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
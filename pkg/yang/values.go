@@ -0,0 +1,368 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the parsing of instance values against a YangType.
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A TypedValue is the Go representation of a YANG value returned by
+// ParseValue.  The dynamic type of a TypedValue depends on the Kind of the
+// YangType it was parsed with:
+//
+//	int8, int16, int32, int64      int64
+//	uint8, uint16, uint32, uint64  uint64
+//	decimal64                      *Number
+//	boolean                        bool
+//	string                         string
+//	binary                         []byte
+//	bits                           []int64 (the positions of the bits, in increasing order)
+//	enumeration                    string (the enum name)
+//	identityref                    *Identity
+//	empty                          nil
+//	leafref, instance-identifier   string
+//
+// A union returns the TypedValue of the first member type that accepts the
+// value.
+type TypedValue interface{}
+
+// ParseValue parses s as a value of type y and returns its TypedValue.  An
+// error is returned if s is not a valid value of y, naming the constraint
+// that was not met.
+//
+// The target of a leafref is not known to its YangType, so leafref values
+// are returned as a string without further validation.
+func (y *YangType) ParseValue(s string) (TypedValue, error) {
+	if y == nil {
+		return nil, fmt.Errorf("cannot parse value %q with nil type", s)
+	}
+	switch y.Kind {
+	case Yint8, Yint16, Yint32, Yint64, Yuint8, Yuint16, Yuint32, Yuint64:
+		return y.parseInteger(s)
+	case Ydecimal64:
+		return y.parseDecimal(s)
+	case Ybool:
+		switch s {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean value %q, must be true or false", s)
+	case Ystring:
+		if err := y.checkLength(s, utf8.RuneCountInString(s)); err != nil {
+			return nil, err
+		}
+		if err := y.checkPatterns(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case Ybinary:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid binary value %q: %v", s, err)
+		}
+		if err := y.checkLength(s, len(b)); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case Ybits:
+		return y.parseBits(s)
+	case Yenum:
+		if y.Enum == nil || !y.Enum.IsDefined(s) {
+			return nil, fmt.Errorf("%q is not a valid value of enumeration %s", s, y.Name)
+		}
+		return s, nil
+	case Yidentityref:
+		return y.parseIdentityref(s)
+	case Yempty:
+		if s != "" {
+			return nil, fmt.Errorf("type empty does not accept a value, got %q", s)
+		}
+		return nil, nil
+	case Yleafref, YinstanceIdentifier:
+		return s, nil
+	case Yunion:
+		var errs []string
+		for _, t := range y.Type {
+			v, err := t.ParseValue(s)
+			if err == nil {
+				return v, nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return nil, fmt.Errorf("%q does not match any member of union %s: %s", s, y.Name, strings.Join(errs, "; "))
+	}
+	return nil, fmt.Errorf("cannot parse value %q of type %s (%s)", s, y.Name, y.Kind)
+}
+
+// CanonicalValue returns the canonical form of s as a value of type y, as
+// defined for each builtin type by RFC 7950 section 9.  For example, the
+// decimal64 value "01.50" is canonically "1.5" and the bits value "b a" is
+// canonically ordered by bit position.  An error is returned if s is not a
+// valid value of y.
+//
+// The canonical form of an identityref is the bare identity name if no other
+// identity derived from its base has the same name, otherwise it is
+// prefixed with the prefix of the module defining the identity.
+func (y *YangType) CanonicalValue(s string) (string, error) {
+	if y != nil && y.Kind == Yunion {
+		for _, t := range y.Type {
+			if c, err := t.CanonicalValue(s); err == nil {
+				return c, nil
+			}
+		}
+		// Report the error for each of the member types.
+		_, err := y.ParseValue(s)
+		return "", err
+	}
+	v, err := y.ParseValue(s)
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case *Number:
+		return canonicalDecimal(v), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case []int64:
+		names := make([]string, len(v))
+		for i, pos := range v {
+			names[i] = y.Bit.Name(pos)
+		}
+		return strings.Join(names, " "), nil
+	case *Identity:
+		for _, id := range y.IdentityBase.Values {
+			if id != v && id.Name == v.Name {
+				return v.PrefixedName(), nil
+			}
+		}
+		return v.Name, nil
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("no canonical form for value %q of type %s", s, y.Name)
+}
+
+// canonicalDecimal returns the canonical form of the decimal64 n, which has
+// no leading zeros before the decimal point and no trailing zeros after the
+// first digit following the decimal point.
+func canonicalDecimal(n *Number) string {
+	s := n.String()
+	if !strings.Contains(s, ".") {
+		return s + ".0"
+	}
+	s = strings.TrimRight(s, "0")
+	if strings.HasSuffix(s, ".") {
+		s += "0"
+	}
+	return s
+}
+
+// containsNumber returns true if n is within one of the ranges in r.  An
+// empty range contains all numbers.
+func (r YangRange) containsNumber(n Number) bool {
+	if len(r) == 0 {
+		return true
+	}
+	for _, yr := range r {
+		if !n.Less(yr.Min) && !yr.Max.Less(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRange returns an error if n is outside of the range of y or of y's
+// builtin type.
+func (y *YangType) checkRange(s string, n Number) error {
+	if br := y.fullRange(); !br.containsNumber(n) {
+		return fmt.Errorf("value %s out of range %v for type %s", s, br, y.Kind)
+	}
+	if !y.Range.containsNumber(n) {
+		return fmt.Errorf("value %s out of range %v for type %s", s, y.Range, y.Name)
+	}
+	return nil
+}
+
+// ParseRange returns the range of values allowed by the integer or decimal64
+// type y: the ranges of its range statement, or of the typedefs it is derived
+// from, or else the range of its builtin type.  The min and max keywords of a
+// range statement are replaced by the minimum and maximum values of the
+// builtin type, which for decimal64 depend on the fraction-digits of y.  An
+// error is returned if y is not an integer or decimal64 type.
+func (y *YangType) ParseRange() (YangRange, error) {
+	full := y.fullRange()
+	if full == nil {
+		return nil, fmt.Errorf("type %s is not an integer or decimal64 type", y.Name)
+	}
+	return y.Range.resolve(full), nil
+}
+
+// ValidateNumber returns an error if n is not a value of the integer or
+// decimal64 type y: if it is not within the range returned by ParseRange, if
+// it has a fractional part and y is an integer type, or if it has more
+// fraction digits than y.
+func (y *YangType) ValidateNumber(n Number) error {
+	r, err := y.ParseRange()
+	if err != nil {
+		return err
+	}
+	switch {
+	case n.Kind == MinNumber || n.Kind == MaxNumber:
+		return fmt.Errorf("%s is not a number", n)
+	case y.Kind == Ydecimal64 && int(n.FractionDigits) > y.FractionDigits:
+		return fmt.Errorf("value %s has more than %d fraction digits for type %s", n, y.FractionDigits, y.Name)
+	case y.Kind != Ydecimal64 && n.IsDecimal() && n.frac() != 0:
+		return fmt.Errorf("value %s is not an integer for type %s", n, y.Name)
+	case !r.containsNumber(n):
+		return fmt.Errorf("value %s out of range %v for type %s", n, r, y.Name)
+	}
+	return nil
+}
+
+// parseInteger parses s as an integer of type y.  Unlike ParseInt, which is
+// used for values within a YANG module, s must use the decimal lexical
+// representation of RFC 7950 section 9.2.1.
+func (y *YangType) parseInteger(s string) (TypedValue, error) {
+	n := Number{Kind: Positive}
+	ds := s
+	switch {
+	case strings.HasPrefix(s, "+"):
+		ds = s[1:]
+	case strings.HasPrefix(s, "-"):
+		n.Kind = Negative
+		ds = s[1:]
+	}
+	v, err := strconv.ParseUint(ds, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q: %v", y.Kind, s, err)
+	}
+	if n.Value = v; v == 0 {
+		n.Kind = Positive
+	}
+	if err := y.checkRange(s, n); err != nil {
+		return nil, err
+	}
+	switch y.Kind {
+	case Yuint8, Yuint16, Yuint32, Yuint64:
+		return n.Value, nil
+	}
+	return n.Int()
+}
+
+// decimalSyntax matches the lexical representation of a decimal64 value.
+var decimalSyntax = regexp.MustCompile(`^[-+]?[0-9]+(\.[0-9]+)?$`)
+
+// parseDecimal parses s as a decimal64 of type y.
+func (y *YangType) parseDecimal(s string) (TypedValue, error) {
+	fd := uint8(y.FractionDigits)
+	br := y.fullRange()
+	n, err := ParseDecimal(s, fd)
+	switch {
+	case err == nil && (n.Kind == MinNumber || n.Kind == MaxNumber):
+		err = fmt.Errorf("%s is not a number", s)
+	case err != nil && decimalSyntax.MatchString(s):
+		// A value that is well formed and not too precise does not fit
+		// in a decimal64.
+		if i := strings.Index(s, "."); i < 0 || len(s)-i-1 <= int(fd) {
+			return nil, fmt.Errorf("value %s out of range %v for type %s", s, br, y.Kind)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid decimal64 value %q: %v", s, err)
+	}
+	if !br.containsNumber(n) {
+		return nil, fmt.Errorf("value %s out of range %v for type %s", s, br, y.Kind)
+	}
+	if !y.Range.containsNumber(n) {
+		return nil, fmt.Errorf("value %s out of range %v for type %s", s, y.Range, y.Name)
+	}
+	return &n, nil
+}
+
+// checkLength returns an error if length l of value s is not within the
+// length restriction of y.
+func (y *YangType) checkLength(s string, l int) error {
+	if !y.Length.containsNumber(FromInt(int64(l))) {
+		return fmt.Errorf("length %d of value %q out of range %v for type %s", l, s, y.Length, y.Name)
+	}
+	return nil
+}
+
+// checkPatterns returns an error if s does not match every pattern of y.
+// YANG patterns are implicitly anchored at both ends.
+func (y *YangType) checkPatterns(s string) error {
+	for _, p := range y.Pattern {
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return fmt.Errorf("bad pattern %q for type %s: %v", p, y.Name, err)
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("value %q does not match pattern %q for type %s", s, p, y.Name)
+		}
+	}
+	return nil
+}
+
+// parseBits parses s, a space separated list of bit names, as a bits value
+// of type y: the positions of the bits, in increasing order.
+func (y *YangType) parseBits(s string) (TypedValue, error) {
+	names := strings.Fields(s)
+	if err := y.ValidateBits(names); err != nil {
+		return nil, err
+	}
+	positions := make([]int64, 0, len(names))
+	for _, name := range names {
+		positions = append(positions, y.Bit.Value(name))
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+	return positions, nil
+}
+
+// parseIdentityref parses s, an optionally prefixed identity name, as an
+// identity derived from the base of y.  The prefix may be either the prefix
+// or the name of the module that defines the identity.
+func (y *YangType) parseIdentityref(s string) (TypedValue, error) {
+	if y.IdentityBase == nil {
+		return nil, fmt.Errorf("identityref %s has no base", y.Name)
+	}
+	prefix, name := getPrefix(s)
+	for _, id := range y.IdentityBase.Values {
+		if id.Name != name {
+			continue
+		}
+		if prefix == "" || prefix == RootNode(id).GetPrefix() || prefix == moduleName(id) {
+			return id, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not an identity derived from %s", s, y.IdentityBase.PrefixedName())
+}
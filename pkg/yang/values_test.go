@@ -0,0 +1,259 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+// valuesTestModule is used to build the types used in the value tests.
+const valuesTestModule = `
+module values {
+  prefix "v";
+  namespace "urn:v";
+
+  identity base-id;
+  identity derived-id { base base-id; }
+
+  typedef percent {
+    type uint8 { range "0..100"; }
+  }
+
+  leaf int8 { type int8; }
+  leaf uint16 { type uint16; }
+  leaf percent { type percent; }
+  leaf decimal { type decimal64 { fraction-digits 2; range "-1.5..10"; } }
+  leaf bool { type boolean; }
+  leaf string { type string { length "1..5"; pattern "[a-z]*"; } }
+  leaf binary { type binary { length "2"; } }
+  leaf bits { type bits { bit zero; bit two { position 2; } bit last { position 4294967295; } } }
+  leaf enum { type enumeration { enum one; enum two; } }
+  leaf identityref { type identityref { base base-id; } }
+  leaf empty { type empty; }
+  leaf leafref { type leafref { path "../string"; } }
+  leaf union { type union { type int8; type boolean; type string; } }
+  leaf unbounded { type int16 { range "min..-10 | 10..max"; } }
+  leaf any-decimal { type decimal64 { fraction-digits 1; } }
+}
+`
+
+// valuesTestEntry returns the module entry built from valuesTestModule.
+func valuesTestEntry(t *testing.T) *Entry {
+	t.Helper()
+	ms := NewModules()
+	if err := ms.Parse(valuesTestModule, "values.yang"); err != nil {
+		t.Fatalf("cannot parse values module: %v", err)
+	}
+	e, errs := ms.GetModule("values")
+	if errs != nil {
+		t.Fatalf("cannot process values module: %v", errs)
+	}
+	return e
+}
+
+func TestParseValue(t *testing.T) {
+	e := valuesTestEntry(t)
+	tests := []struct {
+		desc       string
+		leaf       string
+		in         string
+		want       TypedValue
+		wantErrSub string
+	}{
+		{desc: "int8", leaf: "int8", in: "-12", want: int64(-12)},
+		{desc: "int8 too large", leaf: "int8", in: "128", wantErrSub: "out of range -128..127"},
+		{desc: "int8 not a number", leaf: "int8", in: "twelve", wantErrSub: "invalid int8 value"},
+		{desc: "int8 max keyword", leaf: "int8", in: "max", wantErrSub: "invalid int8 value"},
+		{desc: "uint16", leaf: "uint16", in: "65535", want: uint64(65535)},
+		{desc: "uint16 negative", leaf: "uint16", in: "-1", wantErrSub: "out of range 0..65535"},
+		{desc: "restricted uint8", leaf: "percent", in: "100", want: uint64(100)},
+		{desc: "restricted uint8 out of range", leaf: "percent", in: "101", wantErrSub: "out of range 0..100 for type percent"},
+		{desc: "decimal64", leaf: "decimal", in: "1.5", want: &Number{Kind: Positive, Value: 150, FractionDigits: 2}},
+		{desc: "decimal64 negative", leaf: "decimal", in: "-1.5", want: &Number{Kind: Negative, Value: 150, FractionDigits: 2}},
+		{desc: "decimal64 out of range", leaf: "decimal", in: "10.01", wantErrSub: "out of range"},
+		{desc: "decimal64 too precise", leaf: "decimal", in: "1.001", wantErrSub: "too much precision"},
+		{desc: "decimal64 builtin maximum", leaf: "any-decimal", in: "922337203685477580.7", want: &Number{Kind: Positive, Value: 9223372036854775807, FractionDigits: 1}},
+		{desc: "decimal64 above builtin range", leaf: "any-decimal", in: "922337203685477580.8", wantErrSub: "out of range -922337203685477580.8..922337203685477580.7 for type decimal64"},
+		{desc: "decimal64 below builtin range", leaf: "any-decimal", in: "-922337203685477581", wantErrSub: "out of range -922337203685477580.8..922337203685477580.7 for type decimal64"},
+		{desc: "boolean", leaf: "bool", in: "true", want: true},
+		{desc: "boolean not lowercase", leaf: "bool", in: "True", wantErrSub: "invalid boolean"},
+		{desc: "string", leaf: "string", in: "abc", want: "abc"},
+		{desc: "string too long", leaf: "string", in: "abcdef", wantErrSub: "length 6"},
+		{desc: "string bad pattern", leaf: "string", in: "a1", wantErrSub: `does not match pattern "[a-z]*"`},
+		{desc: "binary", leaf: "binary", in: "AQI=", want: []byte{1, 2}},
+		{desc: "binary wrong length", leaf: "binary", in: "AQ==", wantErrSub: "length 1"},
+		{desc: "binary not base64", leaf: "binary", in: "!!", wantErrSub: "invalid binary"},
+		{desc: "bits", leaf: "bits", in: "two zero", want: []int64{0, 2}},
+		{desc: "bits none", leaf: "bits", in: "", want: []int64{}},
+		{desc: "bits highest position", leaf: "bits", in: "last", want: []int64{4294967295}},
+		{desc: "bits unknown", leaf: "bits", in: "one", wantErrSub: `"one" is not a valid bit`},
+		{desc: "bits repeated", leaf: "bits", in: "two two", wantErrSub: "more than once"},
+		{desc: "enum", leaf: "enum", in: "two", want: "two"},
+		{desc: "enum unknown", leaf: "enum", in: "three", wantErrSub: "not a valid value of enumeration"},
+		{desc: "identityref unknown", leaf: "identityref", in: "other-id", wantErrSub: "not an identity derived from v:base-id"},
+		{desc: "identityref base is not derived", leaf: "identityref", in: "base-id", wantErrSub: "not an identity derived"},
+		{desc: "empty", leaf: "empty", in: "", want: nil},
+		{desc: "empty with value", leaf: "empty", in: "x", wantErrSub: "does not accept a value"},
+		{desc: "leafref", leaf: "leafref", in: "anything", want: "anything"},
+		{desc: "union first member", leaf: "union", in: "12", want: int64(12)},
+		{desc: "union second member", leaf: "union", in: "false", want: false},
+		{desc: "union last member", leaf: "union", in: "1000", want: "1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := e.Dir[tt.leaf].Type.ParseValue(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("ParseValue(%q): %s", tt.in, diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseValue(%q) (-want, +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func TestParseValueIdentityref(t *testing.T) {
+	e := valuesTestEntry(t)
+	y := e.Dir["identityref"].Type
+	for _, in := range []string{"derived-id", "v:derived-id", "values:derived-id"} {
+		got, err := y.ParseValue(in)
+		if err != nil {
+			t.Errorf("ParseValue(%q): %v", in, err)
+			continue
+		}
+		if id, ok := got.(*Identity); !ok || id.Name != "derived-id" {
+			t.Errorf("ParseValue(%q): got %v, want identity derived-id", in, got)
+		}
+	}
+	if _, err := y.ParseValue("x:derived-id"); err == nil {
+		t.Errorf("ParseValue(x:derived-id): did not get expected error")
+	}
+}
+
+func TestCanonicalValue(t *testing.T) {
+	e := valuesTestEntry(t)
+	tests := []struct {
+		desc       string
+		leaf       string
+		in         string
+		want       string
+		wantErrSub string
+	}{
+		{desc: "int8 with sign", leaf: "int8", in: "+012", want: "12"},
+		{desc: "int8 negative", leaf: "int8", in: "-0012", want: "-12"},
+		{desc: "uint16", leaf: "uint16", in: "00042", want: "42"},
+		{desc: "decimal64 trailing zeros", leaf: "decimal", in: "1.00", want: "1.0"},
+		{desc: "decimal64 leading zeros", leaf: "decimal", in: "01.50", want: "1.5"},
+		{desc: "decimal64 no point", leaf: "decimal", in: "2", want: "2.0"},
+		{desc: "decimal64 fraction only", leaf: "decimal", in: "-.5", want: "-0.5"},
+		{desc: "boolean", leaf: "bool", in: "false", want: "false"},
+		{desc: "string", leaf: "string", in: "abc", want: "abc"},
+		{desc: "binary", leaf: "binary", in: "AQI=", want: "AQI="},
+		{desc: "bits ordered by position", leaf: "bits", in: "two  zero", want: "zero two"},
+		{desc: "enum", leaf: "enum", in: "one", want: "one"},
+		{desc: "identityref unambiguous", leaf: "identityref", in: "v:derived-id", want: "derived-id"},
+		{desc: "empty", leaf: "empty", in: "", want: ""},
+		{desc: "union member", leaf: "union", in: "+1", want: "1"},
+		{desc: "invalid value", leaf: "int8", in: "300", wantErrSub: "out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := e.Dir[tt.leaf].Type.CanonicalValue(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("CanonicalValue(%q): %s", tt.in, diff)
+			}
+			if got != tt.want {
+				t.Errorf("CanonicalValue(%q): got %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	e := valuesTestEntry(t)
+	tests := []struct {
+		leaf       string
+		want       string
+		wantErrSub string
+	}{
+		{leaf: "int8", want: "-128..127"},
+		{leaf: "percent", want: "0..100"},
+		{leaf: "unbounded", want: "-32768..-10|10..32767"},
+		{leaf: "decimal", want: "-1.50..10.00"},
+		{leaf: "any-decimal", want: "-922337203685477580.8..922337203685477580.7"},
+		{leaf: "string", wantErrSub: "type string is not an integer or decimal64 type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.leaf, func(t *testing.T) {
+			r, err := e.Dir[tt.leaf].Type.ParseRange()
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Fatalf("ParseRange: %s", diff)
+			}
+			if err == nil && r.String() != tt.want {
+				t.Errorf("ParseRange() = %s, want %s", r, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNumber(t *testing.T) {
+	e := valuesTestEntry(t)
+	tests := []struct {
+		desc       string
+		leaf       string
+		in         Number
+		wantErrSub string
+	}{
+		{desc: "int8", leaf: "int8", in: FromInt(-128)},
+		{desc: "int8 too large", leaf: "int8", in: FromInt(128), wantErrSub: "value 128 out of range -128..127 for type int8"},
+		{desc: "restricted", leaf: "percent", in: FromInt(100)},
+		{desc: "restricted out of range", leaf: "percent", in: FromInt(101), wantErrSub: "out of range 0..100 for type percent"},
+		{desc: "below max interval", leaf: "unbounded", in: FromInt(32767)},
+		{desc: "between intervals", leaf: "unbounded", in: FromInt(0), wantErrSub: "out of range"},
+		{desc: "above max", leaf: "unbounded", in: FromInt(32768), wantErrSub: "out of range"},
+		{desc: "integer with fraction", leaf: "int8", in: mustParseDecimal(t, "1.5"), wantErrSub: "value 1.5 is not an integer"},
+		{desc: "decimal64", leaf: "decimal", in: mustParseDecimal(t, "-1.5")},
+		{desc: "decimal64 out of range", leaf: "decimal", in: mustParseDecimal(t, "10.01"), wantErrSub: "out of range -1.50..10.00"},
+		{desc: "decimal64 too precise", leaf: "any-decimal", in: mustParseDecimal(t, "1.25"), wantErrSub: "more than 1 fraction digits"},
+		{desc: "unbounded decimal64", leaf: "any-decimal", in: mustParseDecimal(t, "922337203685477580.7")},
+		{desc: "max keyword", leaf: "int8", in: Number{Kind: MaxNumber}, wantErrSub: "max is not a number"},
+		{desc: "not numeric", leaf: "string", in: FromInt(1), wantErrSub: "not an integer or decimal64 type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := e.Dir[tt.leaf].Type.ValidateNumber(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("ValidateNumber(%s): %s", tt.in, diff)
+			}
+		})
+	}
+}
+
+// mustParseDecimal returns the decimal64 number s, which has as many
+// fraction digits as s does.
+func mustParseDecimal(t *testing.T, s string) Number {
+	t.Helper()
+	n, err := ParseDecimal(s, uint8(len(s)-strings.Index(s, ".")-1))
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q): %v", s, err)
+	}
+	return n
+}
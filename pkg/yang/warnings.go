@@ -0,0 +1,167 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the warnings reported by Process for conditions that
+// are valid YANG but are discouraged or likely to be mistakes.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Warnings returns the warnings found by the last call to Process, sorted by
+// location.  They are found whether or not Process returns errors, in the
+// modules and submodules that could be parsed.  Warnings do not stop Process
+// and are reported for:
+//
+//   - a module or submodule without an organization, contact, description or
+//     revision statement, which RFC 8407 recommends
+//   - a definition that is current but uses a deprecated or obsolete
+//     typedef, grouping or identity
+//   - an extension statement whose prefix is unknown or whose extension is
+//     not defined by the module of its prefix
+//   - a statement within an extension statement that has no prefix but is
+//     not a YANG statement, which is most likely a misspelled YANG statement,
+//     unless ParseOptions.StrictStatements makes it an error
+//   - an import cycle, unless ParseOptions.RejectImportCycles makes it an
+//     error
+func (ms *Modules) Warnings() []error {
+	return append([]error(nil), ms.warnings...)
+}
+
+// recommendedStatements are the statements that RFC 8407 recommends every
+// module and submodule has.
+var recommendedStatements = []string{"organization", "contact", "description", "revision"}
+
+// lint returns the warnings for the modules and submodules of ms.
+func (ms *Modules) lint() []error {
+	var warnings []error
+	for _, mods := range []map[string]*Module{ms.Modules, ms.SubModules} {
+		for _, name := range sortedModuleNames(mods, false) {
+			m := mods[name]
+			for _, kw := range recommendedStatements {
+				if !m.Source.HasKeyword(kw) {
+					warnings = append(warnings, fmt.Errorf("%s: %s %s has no %s statement", Source(m), m.Kind(), m.Name, kw))
+				}
+			}
+			warnings = append(warnings, lintExtensions(m, m.Source, false)...)
+			warnings = append(warnings, lintStatus(m, map[Node]bool{})...)
+		}
+	}
+	if !ParseOptions.RejectImportCycles {
+		if err := ms.CyclicDependencyCheck(); err != nil {
+			warnings = append(warnings, err)
+		}
+	}
+	return errorSort(warnings)
+}
+
+// lintExtensions returns the warnings for the extension statements in s, a
+// statement of the module or submodule m, which is within an extension
+// statement if inExtension is true.
+func lintExtensions(m *Module, s *Statement, inExtension bool) []error {
+	var warnings []error
+	if prefix, name := getPrefix(s.Keyword); prefix != "" {
+		switch em := FindModuleByPrefix(m, prefix); {
+		case em == nil:
+			warnings = append(warnings, fmt.Errorf("%s: extension statement %s has unknown prefix %s", Source(s), s.Keyword, prefix))
+		case findExtension(em, name) == nil:
+			warnings = append(warnings, fmt.Errorf("%s: extension %s is not defined by module %s", Source(s), name, em.Name))
+		}
+		if !inExtension {
+			warnings = append(warnings, lintUnknownStatements(s)...)
+			inExtension = true
+		}
+	}
+	for _, ss := range s.statements {
+		warnings = append(warnings, lintExtensions(m, ss, inExtension)...)
+	}
+	return warnings
+}
+
+// lintUnknownStatements returns the warnings for the substatements of the
+// extension statement s, and their substatements, whose keyword has no
+// prefix and is not a YANG keyword.
+func lintUnknownStatements(s *Statement) []error {
+	var warnings []error
+	for _, ss := range s.statements {
+		if !strings.Contains(ss.Keyword, ":") && !isKeyword(ss.Keyword) {
+			warnings = append(warnings, fmt.Errorf("%s: unknown statement %s within extension statement %s", Source(ss), ss.Keyword, s.Keyword))
+		}
+		warnings = append(warnings, lintUnknownStatements(ss)...)
+	}
+	return warnings
+}
+
+// lintStatus returns the warnings for the uses of deprecated and obsolete
+// definitions by the current definitions in n and the nodes within it.
+func lintStatus(n Node, visited map[Node]bool) []error {
+	if n == nil || visited[n] {
+		return nil
+	}
+	visited[n] = true
+
+	var warnings []error
+	used := func(kind string, def Node, status *Value) {
+		if st, _ := statusValue(status); st != StatusCurrent && nodeStatus(n) == StatusCurrent {
+			warnings = append(warnings, fmt.Errorf("%s: %s %s refers to %s %s %s", Source(n), n.Kind(), n.NName(), st, kind, def.NName()))
+		}
+	}
+	switch n := n.(type) {
+	case *Type:
+		if y := n.YangType; y != nil {
+			if y.Base != nil {
+				if td, ok := y.Base.Parent.(*Typedef); ok {
+					used("typedef", td, td.Status)
+				}
+			}
+			if y.IdentityBase != nil {
+				used("identity", y.IdentityBase, y.IdentityBase.Status)
+			}
+		}
+	case *Uses:
+		if g := FindGrouping(n, n.Name, map[string]bool{}); g != nil {
+			used("grouping", g, g.Status)
+		}
+	}
+
+	for _, c := range childNodes(n) {
+		if _, ok := c.(*Value); !ok {
+			warnings = append(warnings, lintStatus(c, visited)...)
+		}
+	}
+	return warnings
+}
+
+// nodeStatus returns the status of n, which is the status of the nearest of
+// n and its ancestors that has a status statement, or StatusCurrent if none
+// do.
+func nodeStatus(n Node) Status {
+	for ; n != nil; n = n.ParentNode() {
+		v := reflect.ValueOf(n).Elem()
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		if f := v.FieldByName("Status"); f.IsValid() {
+			if s, ok := f.Interface().(*Value); ok && s != nil {
+				st, _ := statusValue(s)
+				return st
+			}
+		}
+	}
+	return StatusCurrent
+}
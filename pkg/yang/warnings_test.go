@@ -0,0 +1,133 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWarnings(t *testing.T) {
+	// documented is the header of a module with all the recommended
+	// statements.
+	const documented = `
+  organization "Example";
+  contact "noc@example.com";
+  description "Example module.";
+  revision 2020-01-01;
+`
+	tests := []struct {
+		desc        string
+		in          []string
+		wantProcErr bool
+		want        []string
+	}{{
+		desc: "no warnings",
+		in:   []string{`module m { prefix "m"; namespace "urn:m";` + documented + `leaf l { type string; } }`},
+	}, {
+		desc: "missing recommended statements",
+		in:   []string{`module m { prefix "m"; namespace "urn:m"; description "d"; }`},
+		want: []string{
+			"m.yang:1:1: module m has no contact statement",
+			"m.yang:1:1: module m has no organization statement",
+			"m.yang:1:1: module m has no revision statement",
+		},
+	}, {
+		desc: "deprecated definitions",
+		in: []string{`module m { prefix "m"; namespace "urn:m";` + documented + `
+  typedef old { type string; status deprecated; }
+  grouping gone { leaf g { type string; } status obsolete; }
+  identity base-id { status deprecated; }
+  leaf a { type old; }
+  leaf b { type old; status deprecated; }
+  container c { uses gone; }
+  container d { status deprecated; uses gone; }
+  leaf e { type identityref { base base-id; } }
+}`},
+		want: []string{
+			"m.yang:10:12: type old refers to deprecated typedef old",
+			"m.yang:12:17: uses gone refers to obsolete grouping gone",
+			"m.yang:14:12: type identityref refers to deprecated identity base-id",
+		},
+	}, {
+		desc: "unknown extensions",
+		in: []string{
+			`module ext { prefix "x"; namespace "urn:x";` + documented + `extension known; }`,
+			`module m { prefix "m"; namespace "urn:m";` + documented + `
+  import ext { prefix x; }
+  x:known;
+  x:unknown;
+  y:other;
+}`,
+		},
+		want: []string{
+			"m.yang:9:3: extension unknown is not defined by module ext",
+			"m.yang:10:3: extension statement y:other has unknown prefix y",
+		},
+	}, {
+		desc: "unknown statements within extensions",
+		in: []string{
+			`module ext { prefix "x"; namespace "urn:x";` + documented + `extension known { argument name; } }`,
+			`module m { prefix "m"; namespace "urn:m";` + documented + `
+  import ext { prefix x; }
+  x:known "a" {
+    description "a";
+    x:known "b" { descripton "b"; }
+    mandatroy true;
+  }
+}`,
+		},
+		want: []string{
+			"m.yang:10:19: unknown statement descripton within extension statement x:known",
+			"m.yang:11:5: unknown statement mandatroy within extension statement x:known",
+		},
+	}, {
+		desc: "warnings alongside errors",
+		in: []string{`module m { prefix "m"; namespace "urn:m"; description "d";
+  leaf l { type nosuch; }
+}`},
+		wantProcErr: true,
+		want: []string{
+			"m.yang:1:1: module m has no contact statement",
+			"m.yang:1:1: module m has no organization statement",
+			"m.yang:1:1: module m has no revision statement",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			for _, in := range tt.in {
+				name := "m.yang"
+				if in != tt.in[len(tt.in)-1] {
+					name = "ext.yang"
+				}
+				if err := ms.Parse(in, name); err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+			}
+			if errs := ms.Process(); (errs != nil) != tt.wantProcErr {
+				t.Fatalf("Process: got errors %v, want errors %v", errs, tt.wantProcErr)
+			}
+			var got []string
+			for _, w := range ms.Warnings() {
+				got = append(got, w.Error())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Warnings (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements the when statements that entries are conditional on,
+// and their static evaluation.  Only the subset of XPath whose value does not
+// depend on instance data is evaluated, as described by StaticWhen.
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// A whenValue is the value of a statically evaluated when expression.  The
+// value of an expression that depends on instance data is whenUnknown.
+type whenValue int
+
+const (
+	whenFalse = whenValue(iota)
+	whenTrue
+	whenUnknown
+)
+
+// A WhenCondition is a when statement that an entry is conditional on.
+type WhenCondition struct {
+	XPath     string     // the expression of the when statement
+	Statement *Statement // the when statement
+	// Node is the node the when statement is a substatement of: the
+	// *Augment or *Uses that added the entry to the tree, or the node of
+	// the entry itself.
+	Node Node
+}
+
+// WhenConditions returns the when statements that e is conditional on, in
+// order of their scope: the when statements of the augment and uses
+// statements that added e to the tree, outermost first, followed by the when
+// statement of e itself, if any.  The context node of the when statement of
+// an augment or uses statement is the closest ancestor of e that is a data
+// node.  Only the nodes that an augment or uses statement adds directly are
+// conditional on its when statement; their descendants are conditional on it
+// through them.
+func (e *Entry) WhenConditions() []WhenCondition {
+	conds := append([]WhenCondition(nil), e.inheritedWhen...)
+	if expr, ok := e.GetWhenXPath(); ok {
+		var stmt *Statement
+		if v, ok := whenValueOf(e.Node); ok {
+			stmt = v.Statement()
+		}
+		conds = append(conds, WhenCondition{XPath: expr, Statement: stmt, Node: e.Node})
+	}
+	return conds
+}
+
+// inheritWhen records that e was added to the tree by n, an *Augment or
+// *Uses, and so is conditional on the when statement of n, if any.  The
+// conditions already recorded for e were added by statements within n, so
+// the when statement of n is placed before them.
+func (e *Entry) inheritWhen(n Node) {
+	v, ok := whenValueOf(n)
+	if !ok {
+		return
+	}
+	arg, _ := v.Statement().Arg()
+	e.inheritedWhen = append([]WhenCondition{{XPath: arg, Statement: v.Statement(), Node: n}}, e.inheritedWhen...)
+}
+
+// whenValueOf returns the when statement of n, if it has one.
+func whenValueOf(n Node) (*Value, bool) {
+	if n == nil {
+		return nil, false
+	}
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+	f := v.Elem().FieldByName("When")
+	if !f.IsValid() {
+		return nil, false
+	}
+	w, ok := f.Interface().(*Value)
+	if !ok || w == nil || w.Statement() == nil {
+		return nil, false
+	}
+	return w, true
+}
+
+// StaticWhen evaluates the when statements that e is conditional on, as
+// returned by WhenConditions, without instance data.  If their value is the
+// same for every data tree, value is that value and ok is true: false if any
+// of them is false, and true if all of them are true.  Otherwise ok is false,
+// and the when statements must be evaluated against instance data.  If e is
+// not conditional on any when statement ok is false.
+//
+// The expressions evaluated are:
+//
+//	true() and false()
+//	not(expr)
+//	expr and expr, expr or expr, and (expr)
+//	location paths
+//
+// A location path is made of the steps "..", "." and [prefix:]name separated
+// by "/", optionally starting with "/".  Axes, predicates and wildcards are
+// not supported.  A location path that names a node that is not in the schema
+// always selects an empty node-set, and so is false.  A location path that
+// names a node in the schema depends on instance data.  Choice and case nodes
+// are not data nodes and are not named by location paths.  A step that names
+// a top level node of a module other than that of the tree of e, such as an
+// unprefixed step in a when statement that augments another module, is not
+// evaluated.  The value of not, and, and or follows from the values of the
+// expressions they are given that are known, so "false() and ../x" is false
+// whatever ../x is.  Any other expression, such as a comparison or a call to
+// any other function, is not evaluated.
+func (e *Entry) StaticWhen() (value, ok bool) {
+	conds := e.WhenConditions()
+	if len(conds) == 0 {
+		return false, false
+	}
+	v := whenTrue
+	for i, c := range conds {
+		context := e.whenContext()
+		if i < len(e.inheritedWhen) {
+			// The context node of the when statement of an augment
+			// or uses statement is the data node that e was added
+			// to.
+			context = e.Parent.whenContext()
+		}
+		p := &whenParser{tokens: whenTokens(c.XPath), context: context, node: c.Node}
+		cv, ok := p.expr()
+		switch {
+		case !ok || len(p.tokens) != 0:
+			v = whenUnknown
+		case cv == whenFalse:
+			return false, true
+		case cv == whenUnknown:
+			v = whenUnknown
+		}
+	}
+	if v == whenUnknown {
+		return false, false
+	}
+	return true, true
+}
+
+// whenContext returns the context node of the when statement of e, which is
+// e itself unless e is a choice or case, in which case it is the closest
+// ancestor of e that is a data node.
+func (e *Entry) whenContext() *Entry {
+	c := e
+	for c != nil && (c.IsChoice() || c.IsCase()) {
+		c = c.Parent
+	}
+	return c
+}
+
+// pruneStaticWhen removes the nodes of the tree rooted at e whose when
+// statement is statically false, as reported by StaticWhen.  It returns true
+// if any node was removed.  As removing a node may change the value of other
+// when statements, pruneStaticWhen should be called until it returns false.
+func (e *Entry) pruneStaticWhen() bool {
+	if e == nil {
+		return false
+	}
+	pruned := false
+	for _, k := range e.orderedKeys() {
+		ce := e.Dir[k]
+		if v, ok := ce.StaticWhen(); ok && !v {
+			e.delete(k)
+			pruned = true
+			continue
+		}
+		if ce.pruneStaticWhen() {
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// whenTokens splits expr into parentheses and the words between them.
+func whenTokens(expr string) []string {
+	var tokens []string
+	word := -1
+	for i, c := range expr {
+		switch {
+		case c == '(' || c == ')' || unicode.IsSpace(c):
+			if word >= 0 {
+				tokens = append(tokens, expr[word:i])
+				word = -1
+			}
+			if !unicode.IsSpace(c) {
+				tokens = append(tokens, string(c))
+			}
+		case word < 0:
+			word = i
+		}
+	}
+	if word >= 0 {
+		tokens = append(tokens, expr[word:])
+	}
+	return tokens
+}
+
+// A whenParser evaluates the tokens of a when expression, in the context of
+// the node context, as it parses them.  The prefixes of the expression, and
+// the names of its top level nodes, are those of the module of node, the
+// node the when statement is a substatement of.  Each method returns false if
+// the tokens are not an expression that can be evaluated statically.
+type whenParser struct {
+	tokens  []string
+	context *Entry
+	node    Node
+}
+
+// peek returns the next token, or "" if there are no more tokens.
+func (p *whenParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+// next returns and consumes the next token.
+func (p *whenParser) next() string {
+	t := p.peek()
+	if len(p.tokens) > 0 {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+// expr parses an or expression.
+func (p *whenParser) expr() (whenValue, bool) {
+	v, ok := p.and()
+	for ok && p.peek() == "or" {
+		p.next()
+		var w whenValue
+		if w, ok = p.and(); ok {
+			switch {
+			case v == whenTrue || w == whenTrue:
+				v = whenTrue
+			case v == whenUnknown || w == whenUnknown:
+				v = whenUnknown
+			}
+		}
+	}
+	return v, ok
+}
+
+// and parses an and expression.
+func (p *whenParser) and() (whenValue, bool) {
+	v, ok := p.unary()
+	for ok && p.peek() == "and" {
+		p.next()
+		var w whenValue
+		if w, ok = p.unary(); ok {
+			switch {
+			case v == whenFalse || w == whenFalse:
+				v = whenFalse
+			case v == whenUnknown || w == whenUnknown:
+				v = whenUnknown
+			}
+		}
+	}
+	return v, ok
+}
+
+// unary parses a function call, a parenthesized expression or a location
+// path.
+func (p *whenParser) unary() (whenValue, bool) {
+	t := p.next()
+	if t == "(" {
+		v, ok := p.expr()
+		return v, ok && p.next() == ")"
+	}
+	if p.peek() == "(" {
+		p.next()
+		switch t {
+		case "true", "false":
+			if p.next() != ")" {
+				return whenUnknown, false
+			}
+			if t == "true" {
+				return whenTrue, true
+			}
+			return whenFalse, true
+		case "not":
+			v, ok := p.expr()
+			if !ok || p.next() != ")" {
+				return whenUnknown, false
+			}
+			switch v {
+			case whenTrue:
+				return whenFalse, true
+			case whenFalse:
+				return whenTrue, true
+			}
+			return whenUnknown, true
+		}
+		return whenUnknown, false
+	}
+	return p.path(t)
+}
+
+// path evaluates the location path t.
+func (p *whenParser) path(t string) (whenValue, bool) {
+	if t == "" || t == "/" || strings.HasSuffix(t, "/") {
+		return whenUnknown, false
+	}
+	e := p.context
+	if strings.HasPrefix(t, "/") {
+		for e.Parent != nil {
+			e = e.Parent
+		}
+		t = t[1:]
+	}
+	for _, step := range strings.Split(t, "/") {
+		switch step {
+		case ".":
+			continue
+		case "..":
+			if e = e.DataParent(); e == nil {
+				// The parent of the root of the data tree.
+				return whenUnknown, false
+			}
+			continue
+		}
+		prefix, name := getPrefix(step)
+		if (strings.Contains(step, ":") && !isIdentifier(prefix)) || !isIdentifier(name) {
+			return whenUnknown, false
+		}
+		if e.Parent == nil {
+			// The top level nodes of other modules, including that
+			// of the when statement when it augments another
+			// module, are not in the tree rooted at e.
+			m := FindModuleByPrefix(p.node, prefix)
+			if m == nil || moduleName(m) != e.Name {
+				return whenUnknown, false
+			}
+		}
+		if e = e.dataChild(name); e == nil {
+			return whenFalse, true
+		}
+	}
+	return whenUnknown, true
+}
+
+// dataChild returns the data node child of e named name, looking within the
+// cases of the choices of e, or nil if there is no such child.
+func (e *Entry) dataChild(name string) *Entry {
+	if ce := e.Dir[name]; ce != nil && !ce.IsChoice() && !ce.IsCase() {
+		return ce
+	}
+	for _, ce := range e.Dir {
+		if ce.IsChoice() || ce.IsCase() {
+			if de := ce.dataChild(name); de != nil {
+				return de
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const whenModule = `
+module when {
+  namespace "urn:when";
+  prefix "w";
+
+  import other { prefix "o"; }
+
+  container c {
+    leaf always-false { type string; when "false()"; }
+    leaf always-true { type string; when "true ( )"; }
+    leaf missing { type string; when "../no-such-leaf"; }
+    leaf present { type string; when "../always-true"; }
+    leaf not-missing { type string; when "not(../no-such-leaf)"; }
+    leaf and-unknown { type string; when "not(../no-such-leaf) and ../always-true"; }
+    leaf and-false { type string; when "../always-true and false()"; }
+    leaf or-false { type string; when "(../no-such-leaf or false())"; }
+    leaf or-true { type string; when "../always-true or true()"; }
+    leaf comparison { type string; when "../always-true = 'x'"; }
+    leaf predicate { type string; when "../l[name='x']"; }
+    leaf other-function { type string; when "count(../always-true) = 0"; }
+    leaf absolute { type string; when "/w:c/w:always-true"; }
+    leaf absolute-missing { type string; when "/w:c/w:no-such-leaf"; }
+    leaf other-module { type string; when "/o:top"; }
+    leaf after-pruning { type string; when "../always-false"; }
+    leaf in-choice { type string; when "../in-case"; }
+    choice ch {
+      case cs {
+        when "always-false";
+        leaf in-case { type string; }
+      }
+    }
+    leaf no-when { type string; }
+    uses g { when "false()"; }
+  }
+  grouping g {
+    leaf from-uses { type string; }
+  }
+}
+`
+
+const whenOtherModule = `
+module other {
+  namespace "urn:other";
+  prefix "o";
+  container top {}
+}
+`
+
+const whenAugmentModule = `
+module augment {
+  namespace "urn:augment";
+  prefix "a";
+
+  import when { prefix "w"; }
+
+  augment "/w:c" {
+    leaf from-augment { type string; when "/q"; }
+    leaf from-augment-missing { type string; when "/w:c/w:no-such-leaf"; }
+  }
+  augment "/w:c" {
+    when "false()";
+    leaf augment-false { type string; }
+    leaf augment-false-true { type string; when "true()"; }
+  }
+  container q {}
+}
+`
+
+func TestStaticWhen(t *testing.T) {
+	ms := NewModules()
+	for name, text := range map[string]string{"when.yang": whenModule, "other.yang": whenOtherModule, "augment.yang": whenAugmentModule} {
+		if err := ms.Parse(text, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e, errs := ms.GetModule("when")
+	if errs != nil {
+		t.Fatalf("GetModule: %v", errs)
+	}
+	c := e.Dir["c"]
+
+	tests := []struct {
+		name      string
+		wantValue bool
+		wantOK    bool
+	}{
+		{name: "always-false", wantOK: true},
+		{name: "always-true", wantValue: true, wantOK: true},
+		{name: "missing", wantOK: true},
+		{name: "present"},
+		{name: "not-missing", wantValue: true, wantOK: true},
+		{name: "and-unknown"},
+		{name: "and-false", wantOK: true},
+		{name: "or-false", wantOK: true},
+		{name: "or-true", wantValue: true, wantOK: true},
+		{name: "comparison"},
+		{name: "predicate"},
+		{name: "other-function"},
+		{name: "absolute"},
+		{name: "absolute-missing", wantOK: true},
+		{name: "other-module"},
+		{name: "after-pruning"},
+		{name: "in-choice"},
+		{name: "no-when"},
+		{name: "from-augment"},
+		{name: "from-augment-missing", wantOK: true},
+		{name: "from-uses", wantOK: true},
+		{name: "augment-false", wantOK: true},
+		{name: "augment-false-true", wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := c.Dir[tt.name].StaticWhen()
+			if value != tt.wantValue || ok != tt.wantOK {
+				t.Errorf("StaticWhen: got (%v, %v), want (%v, %v)", value, ok, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+	if value, ok := c.Dir["ch"].Dir["cs"].StaticWhen(); value || ok {
+		t.Errorf("StaticWhen of case: got (%v, %v), want (false, false)", value, ok)
+	}
+}
+
+func TestPruneStaticWhenOption(t *testing.T) {
+	for _, prune := range []bool{false, true} {
+		t.Run(fmt.Sprintf("PruneStaticWhen=%v", prune), func(t *testing.T) {
+			ParseOptions.PruneStaticWhen = prune
+			defer func() { ParseOptions.PruneStaticWhen = false }()
+
+			ms := NewModules()
+			for name, text := range map[string]string{"when.yang": whenModule, "other.yang": whenOtherModule, "augment.yang": whenAugmentModule} {
+				if err := ms.Parse(text, name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			e, errs := ms.GetModule("when")
+			if errs != nil {
+				t.Fatalf("GetModule: %v", errs)
+			}
+			c := e.Dir["c"]
+
+			var got []string
+			for _, ce := range c.OrderedChildren() {
+				got = append(got, ce.Name)
+			}
+			var choice []string
+			for _, ce := range c.Dir["ch"].OrderedChildren() {
+				choice = append(choice, ce.Name)
+			}
+			want := []string{"always-true", "present", "not-missing", "and-unknown", "or-true", "comparison", "predicate", "other-function", "absolute", "other-module", "ch", "no-when", "from-augment"}
+			wantChoice := []string{}
+			if !prune {
+				want = []string{"always-false", "always-true", "missing", "present", "not-missing", "and-unknown", "and-false", "or-false", "or-true", "comparison", "predicate", "other-function", "absolute", "absolute-missing", "other-module", "after-pruning", "in-choice", "ch", "no-when", "from-uses", "from-augment", "from-augment-missing", "augment-false", "augment-false-true"}
+				wantChoice = []string{"cs"}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("children of c (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(wantChoice, choice, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("cases of ch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWhenConditions(t *testing.T) {
+	ms := NewModules()
+	for name, in := range map[string]string{
+		"m.yang": `module m {
+  prefix "m";
+  namespace "urn:m";
+  grouping inner {
+    leaf i { type string; when "../own = 'i'"; }
+  }
+  grouping outer {
+    uses inner { when "../own = 'inner'"; }
+    leaf o { type string; }
+  }
+  container c {
+    leaf own { type string; }
+    uses outer { when "own = 'outer'"; }
+  }
+}`,
+		"a.yang": `module a {
+  prefix "a";
+  namespace "urn:a";
+  import m { prefix m; }
+  augment "/m:c" {
+    when "m:own = 'augment'";
+    uses m2;
+    container added { leaf x { type string; } }
+  }
+  grouping m2 {
+    leaf y { type string; }
+  }
+}`,
+	} {
+		if err := ms.Parse(in, name); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	c := ToEntry(ms.Modules["m"]).Dir["c"]
+
+	for path, want := range map[string][]string{
+		"own":     nil,
+		"o":       {"own = 'outer'"},
+		"i":       {"own = 'outer'", "../own = 'inner'", "../own = 'i'"},
+		"added":   {"m:own = 'augment'"},
+		"added/x": nil,
+		"y":       {"m:own = 'augment'"},
+	} {
+		e := c.Find(path)
+		if e == nil {
+			t.Errorf("%s not found", path)
+			continue
+		}
+		var got []string
+		for _, w := range e.WhenConditions() {
+			got = append(got, w.XPath)
+			if w.Statement == nil || w.Statement.Keyword != "when" {
+				t.Errorf("%s: condition %q has statement %v", path, w.XPath, w.Statement)
+			}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: WhenConditions (-want, +got):\n%s", path, diff)
+		}
+	}
+}
@@ -0,0 +1,542 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements checking the syntax of the XPath 1.0 expressions of
+// must and when statements, and finding the location paths within them.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An xpathKind is the kind of a token of an XPath expression.
+type xpathKind int
+
+const (
+	xpOperator xpathKind = iota // and or div mod * / // | + - = != < <= > >=
+	xpPunct                     // ( ) [ ] . .. @ , :: $
+	xpName                      // a name test: a QName, prefix:* or *
+	xpFunction                  // the name of a function
+	xpAxis                      // the name of an axis
+	xpNodeType                  // comment, text, processing-instruction or node
+	xpLiteral
+	xpNumber
+)
+
+type xpathToken struct {
+	kind xpathKind
+	text string
+	pos  int // the index of the token in the expression
+}
+
+// xpathAxes are the axis names of section 2.2 of XPath 1.0.
+var xpathAxes = map[string]bool{
+	"ancestor":           true,
+	"ancestor-or-self":   true,
+	"attribute":          true,
+	"child":              true,
+	"descendant":         true,
+	"descendant-or-self": true,
+	"following":          true,
+	"following-sibling":  true,
+	"namespace":          true,
+	"parent":             true,
+	"preceding":          true,
+	"preceding-sibling":  true,
+	"self":               true,
+}
+
+// xpathNodeTypes are the node types of section 2.3 of XPath 1.0.
+var xpathNodeTypes = map[string]bool{
+	"comment":                true,
+	"text":                   true,
+	"processing-instruction": true,
+	"node":                   true,
+}
+
+// An xpathPath is a location path of an XPath expression.
+type xpathPath struct {
+	absolute bool // the path starts at the root
+	current  bool // the path starts at current()
+	steps    []*xpathStep
+}
+
+// An xpathStep is a step of an xpathPath.
+type xpathStep struct {
+	// name is the name test of a step along the child axis, "." or "..",
+	// or "" if the step cannot be followed in the schema tree, such as a
+	// step along the descendant axis.
+	name  string
+	preds []*xpathPath // the location paths within the predicates of the step
+}
+
+// checkXPath returns an error if expr is not a syntactically valid XPath 1.0
+// expression.  Function names and prefixes are not checked.
+func checkXPath(expr string) error {
+	_, err := xpathPaths(expr)
+	return err
+}
+
+// xpathPaths returns the location paths of expr that are not within the
+// predicates of other location paths, or an error if expr is not a
+// syntactically valid XPath 1.0 expression.  A path that follows a filter
+// expression other than current() starts with a step that cannot be
+// followed.
+func xpathPaths(expr string) ([]*xpathPath, error) {
+	tokens, err := tokenizeXPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	var paths []*xpathPath
+	p := &xpathParser{tokens: tokens, paths: &paths}
+	if err := p.expr(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != nil {
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+	return paths, nil
+}
+
+// tokenizeXPath splits expr into tokens, following the rules of section 3.7
+// of XPath 1.0 to tell operators from names.
+func tokenizeXPath(expr string) ([]xpathToken, error) {
+	var tokens []xpathToken
+	i := 0
+	add := func(kind xpathKind, text string) {
+		tokens = append(tokens, xpathToken{kind: kind, text: text, pos: i})
+	}
+	// operand reports whether the previous token ends an operand, in which
+	// case * and the operator names are operators.
+	operand := func() bool {
+		if len(tokens) == 0 {
+			return false
+		}
+		switch t := tokens[len(tokens)-1]; t.kind {
+		case xpOperator:
+			return false
+		case xpPunct:
+			switch t.text {
+			case "@", "::", "(", "[", ",", "$":
+				return false
+			}
+		}
+		return true
+	}
+	for i < len(expr) {
+		c := expr[i]
+		rest := expr[i:]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := strings.IndexByte(rest[1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated literal %s", rest)
+			}
+			add(xpLiteral, rest[:j+2])
+			i += j + 2
+		case isXPathDigit(c) || (c == '.' && len(rest) > 1 && isXPathDigit(rest[1])):
+			j := 0
+			for j < len(rest) && isXPathDigit(rest[j]) {
+				j++
+			}
+			if j < len(rest) && rest[j] == '.' {
+				for j++; j < len(rest) && isXPathDigit(rest[j]); j++ {
+				}
+			}
+			add(xpNumber, rest[:j])
+			i += j
+		case strings.HasPrefix(rest, ".."), strings.HasPrefix(rest, "::"):
+			add(xpPunct, rest[:2])
+			i += 2
+		case strings.HasPrefix(rest, "//"), strings.HasPrefix(rest, "!="), strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, ">="):
+			add(xpOperator, rest[:2])
+			i += 2
+		case strings.IndexByte("/|+-=<>", c) >= 0:
+			add(xpOperator, rest[:1])
+			i++
+		case strings.IndexByte("()[].@,$", c) >= 0:
+			add(xpPunct, rest[:1])
+			i++
+		case c == '*':
+			if operand() {
+				add(xpOperator, "*")
+			} else {
+				add(xpName, "*")
+			}
+			i++
+		case isXPathNameStart(c):
+			j := scanXPathName(expr, i)
+			name := expr[i:j]
+			if operand() {
+				switch name {
+				case "and", "or", "div", "mod":
+					add(xpOperator, name)
+					i = j
+					continue
+				}
+				return nil, fmt.Errorf("unexpected name %q", name)
+			}
+			wildcard := false
+			if j+1 < len(expr) && expr[j] == ':' && expr[j+1] != ':' {
+				switch {
+				case expr[j+1] == '*':
+					j += 2
+					wildcard = true
+				case isXPathNameStart(expr[j+1]):
+					j = scanXPathName(expr, j+1)
+				default:
+					return nil, fmt.Errorf("invalid name %q", expr[i:j+1])
+				}
+				name = expr[i:j]
+			}
+			k := j
+			for k < len(expr) && strings.IndexByte(" \t\n\r", expr[k]) >= 0 {
+				k++
+			}
+			switch {
+			case !wildcard && k < len(expr) && expr[k] == '(':
+				if xpathNodeTypes[name] {
+					add(xpNodeType, name)
+				} else {
+					add(xpFunction, name)
+				}
+			case strings.HasPrefix(expr[k:], "::"):
+				if !xpathAxes[name] {
+					return nil, fmt.Errorf("unknown axis %q", name)
+				}
+				add(xpAxis, name)
+			default:
+				add(xpName, name)
+			}
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+// isQName reports whether s is a name with an optional prefix.
+func isQName(s string) bool {
+	tokens, err := tokenizeXPath(s)
+	return err == nil && len(tokens) == 1 && tokens[0].kind == xpName && tokens[0].text == s && !strings.HasSuffix(s, "*")
+}
+
+func isXPathDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isXPathNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// scanXPathName returns the index of the end of the name that starts at
+// index i of s.
+func scanXPathName(s string, i int) int {
+	for i < len(s) && (isXPathNameStart(s[i]) || isXPathDigit(s[i]) || s[i] == '-' || s[i] == '.') {
+		i++
+	}
+	return i
+}
+
+// An xpathParser parses the tokens of an XPath expression by recursive
+// descent over the grammar of XPath 1.0.
+type xpathParser struct {
+	tokens []xpathToken
+	pos    int
+	paths  *[]*xpathPath // where the location paths found are added
+}
+
+// newPath adds lp to the paths found by p and returns it.
+func (p *xpathParser) newPath(lp *xpathPath) *xpathPath {
+	*p.paths = append(*p.paths, lp)
+	return lp
+}
+
+// peek returns the next token, or nil at the end of the expression.
+func (p *xpathParser) peek() *xpathToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+// accept consumes the next token if it has kind and one of texts.
+func (p *xpathParser) accept(kind xpathKind, texts ...string) bool {
+	t := p.peek()
+	if t == nil || t.kind != kind {
+		return false
+	}
+	for _, text := range texts {
+		if t.text == text {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// expect consumes the next token, which must have kind and text.
+func (p *xpathParser) expect(kind xpathKind, text string) error {
+	if p.accept(kind, text) {
+		return nil
+	}
+	if t := p.peek(); t != nil {
+		return fmt.Errorf("expected %q, found %q", text, t.text)
+	}
+	return fmt.Errorf("expected %q at end of expression", text)
+}
+
+// xpathLevels are the binary operators, from the lowest precedence to the
+// highest.
+var xpathLevels = [][]string{
+	{"or"},
+	{"and"},
+	{"=", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "div", "mod"},
+}
+
+func (p *xpathParser) expr() error {
+	return p.binary(0)
+}
+
+// binary parses an expression of the binary operators of level and above.
+func (p *xpathParser) binary(level int) error {
+	if level == len(xpathLevels) {
+		return p.unary()
+	}
+	if err := p.binary(level + 1); err != nil {
+		return err
+	}
+	for p.accept(xpOperator, xpathLevels[level]...) {
+		if err := p.binary(level + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unary parses a UnaryExpr, which is a UnionExpr preceded by any number of
+// minus signs.
+func (p *xpathParser) unary() error {
+	for p.accept(xpOperator, "-") {
+	}
+	if err := p.path(); err != nil {
+		return err
+	}
+	for p.accept(xpOperator, "|") {
+		if err := p.path(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startsStep reports whether the next token starts a location step.
+func (p *xpathParser) startsStep() bool {
+	t := p.peek()
+	if t == nil {
+		return false
+	}
+	switch t.kind {
+	case xpName, xpAxis, xpNodeType:
+		return true
+	case xpPunct:
+		return t.text == "." || t.text == ".." || t.text == "@"
+	}
+	return false
+}
+
+// path parses a PathExpr: a location path or a filter expression optionally
+// followed by a relative location path.
+func (p *xpathParser) path() error {
+	switch {
+	case p.accept(xpOperator, "/"):
+		lp := p.newPath(&xpathPath{absolute: true})
+		if p.startsStep() {
+			return p.relativePath(lp)
+		}
+		return nil
+	case p.accept(xpOperator, "//"):
+		lp := p.newPath(&xpathPath{absolute: true, steps: []*xpathStep{{}}})
+		return p.relativePath(lp)
+	case p.startsStep():
+		return p.relativePath(p.newPath(&xpathPath{}))
+	}
+	current := p.isCurrent()
+	if err := p.primary(); err != nil {
+		return err
+	}
+	// The paths within the predicates of a filter expression are relative
+	// to nodes that are not known.
+	if err := p.predicates(new([]*xpathPath)); err != nil {
+		return err
+	}
+	if t := p.peek(); t != nil && t.kind == xpOperator && (t.text == "/" || t.text == "//") {
+		p.pos++
+		lp := p.newPath(&xpathPath{current: current})
+		if !current || t.text == "//" {
+			lp.steps = append(lp.steps, &xpathStep{})
+		}
+		return p.relativePath(lp)
+	}
+	return nil
+}
+
+// isCurrent reports whether the next tokens are a call of current().
+func (p *xpathParser) isCurrent() bool {
+	if p.pos+2 >= len(p.tokens) {
+		return false
+	}
+	t := p.tokens[p.pos : p.pos+3]
+	return t[0].kind == xpFunction && t[0].text == "current" && t[1].text == "(" && t[2].text == ")"
+}
+
+func (p *xpathParser) relativePath(lp *xpathPath) error {
+	if err := p.step(lp); err != nil {
+		return err
+	}
+	for {
+		switch {
+		case p.accept(xpOperator, "/"):
+		case p.accept(xpOperator, "//"):
+			lp.steps = append(lp.steps, &xpathStep{})
+		default:
+			return nil
+		}
+		if err := p.step(lp); err != nil {
+			return err
+		}
+	}
+}
+
+// step parses a step and adds it to lp.
+func (p *xpathParser) step(lp *xpathPath) error {
+	st := &xpathStep{}
+	lp.steps = append(lp.steps, st)
+	if t := p.peek(); t != nil && p.accept(xpPunct, ".", "..") {
+		st.name = t.text
+		return nil
+	}
+	axis := "child"
+	if t := p.peek(); t != nil && t.kind == xpAxis {
+		axis = t.text
+		p.pos++
+		if err := p.expect(xpPunct, "::"); err != nil {
+			return err
+		}
+	} else if p.accept(xpPunct, "@") {
+		axis = "attribute"
+	}
+	t := p.peek()
+	switch {
+	case t == nil:
+		return fmt.Errorf("expected a node test at end of expression")
+	case t.kind == xpName:
+		p.pos++
+		if axis == "child" && !strings.HasSuffix(t.text, "*") {
+			st.name = t.text
+		}
+	case t.kind == xpNodeType:
+		p.pos++
+		if err := p.expect(xpPunct, "("); err != nil {
+			return err
+		}
+		if t.text == "processing-instruction" {
+			if l := p.peek(); l != nil && l.kind == xpLiteral {
+				p.pos++
+			}
+		}
+		if err := p.expect(xpPunct, ")"); err != nil {
+			return err
+		}
+		if t.text == "node" {
+			switch axis {
+			case "parent":
+				st.name = ".."
+			case "self":
+				st.name = "."
+			}
+		}
+	default:
+		return fmt.Errorf("expected a node test, found %q", t.text)
+	}
+	return p.predicates(&st.preds)
+}
+
+// predicates parses the predicates of a step or filter expression, adding
+// the location paths within them to paths.
+func (p *xpathParser) predicates(paths *[]*xpathPath) error {
+	saved := p.paths
+	p.paths = paths
+	defer func() { p.paths = saved }()
+	for p.accept(xpPunct, "[") {
+		if err := p.expr(); err != nil {
+			return err
+		}
+		if err := p.expect(xpPunct, "]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// primary parses a PrimaryExpr: a variable reference, a parenthesized
+// expression, a literal, a number or a function call.
+func (p *xpathParser) primary() error {
+	t := p.peek()
+	if t == nil {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	switch {
+	case t.kind == xpPunct && t.text == "$":
+		if n := p.peek(); n == nil || n.kind != xpName || strings.HasSuffix(n.text, "*") {
+			return fmt.Errorf("expected a variable name after $")
+		}
+		p.pos++
+	case t.kind == xpPunct && t.text == "(":
+		if err := p.expr(); err != nil {
+			return err
+		}
+		return p.expect(xpPunct, ")")
+	case t.kind == xpLiteral, t.kind == xpNumber:
+	case t.kind == xpFunction:
+		if err := p.expect(xpPunct, "("); err != nil {
+			return err
+		}
+		if p.accept(xpPunct, ")") {
+			return nil
+		}
+		if err := p.expr(); err != nil {
+			return err
+		}
+		for p.accept(xpPunct, ",") {
+			if err := p.expr(); err != nil {
+				return err
+			}
+		}
+		return p.expect(xpPunct, ")")
+	default:
+		return fmt.Errorf("unexpected %q", t.text)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestCheckXPath(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		wantErr string
+	}{
+		{in: "count(interface) > 0"},
+		{in: "../type = 'ethernet' or ../type = 'loopback'"},
+		{in: "/if:interfaces/if:interface[if:name = current()/../name]/if:enabled"},
+		{in: "derived-from-or-self(../type, 'ianaift:ethernetCsmacd')"},
+		{in: ". * 2 div 3 mod 4 - -1 >= 0.5"},
+		{in: "not(boolean(ancestor-or-self::node()/@x | .//*))"},
+		{in: "string-length(.) <= 64 and $v != \"x\""},
+		{in: "ns:*[position() = last()]"},
+		{in: "", wantErr: "empty expression"},
+		{in: "count(x", wantErr: `expected ")" at end of expression`},
+		{in: "a b", wantErr: `unexpected name "b"`},
+		{in: "a = ", wantErr: "unexpected end of expression"},
+		{in: "'unterminated", wantErr: "unterminated literal"},
+		{in: "a[b", wantErr: `expected "]" at end of expression`},
+		{in: "foo::bar", wantErr: `unknown axis "foo"`},
+		{in: "a )", wantErr: `unexpected ")"`},
+		{in: "a # b", wantErr: "unexpected character '#'"},
+		{in: "/a/", wantErr: "expected a node test at end of expression"},
+	} {
+		if diff := errdiff.Substring(checkXPath(tt.in), tt.wantErr); diff != "" {
+			t.Errorf("checkXPath(%q): %s", tt.in, diff)
+		}
+	}
+}
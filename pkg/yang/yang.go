@@ -14,7 +14,10 @@
 
 package yang
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // This file contains the definitions for all nodes of the yang AST.
 // The actual building of the AST is in ast.go
@@ -34,11 +37,13 @@ type Value struct {
 	Description *Value `yang:"description" json:",omitempty"`
 }
 
-func (Value) Kind() string             { return "string" }
-func (s *Value) ParentNode() Node      { return s.Parent }
-func (s *Value) NName() string         { return s.Name }
-func (s *Value) Statement() *Statement { return s.Source }
-func (s *Value) Exts() []*Statement    { return s.Extensions }
+func (Value) Kind() string                    { return "string" }
+func (s *Value) ParentNode() Node             { return s.Parent }
+func (s *Value) NName() string                { return s.Name }
+func (s *Value) Statement() *Statement        { return s.Source }
+func (s *Value) Exts() []*Statement           { return s.Extensions }
+func (s *Value) NumChildren() int             { return numChildNodes(s) }
+func (s *Value) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // asRangeInt returns the value v as an int64 if it is between the values of
 // min and max inclusive.  An error is returned if v is out of range or does
@@ -150,13 +155,15 @@ func (s *Module) Kind() string {
 	}
 	return "module"
 }
-func (s *Module) ParentNode() Node        { return s.Parent }
-func (s *Module) NName() string           { return s.Name }
-func (s *Module) Statement() *Statement   { return s.Source }
-func (s *Module) Exts() []*Statement      { return s.Extensions }
-func (s *Module) Groupings() []*Grouping  { return s.Grouping }
-func (s *Module) Typedefs() []*Typedef    { return s.Typedef }
-func (s *Module) Identities() []*Identity { return s.Identity }
+func (s *Module) ParentNode() Node             { return s.Parent }
+func (s *Module) NName() string                { return s.Name }
+func (s *Module) Statement() *Statement        { return s.Source }
+func (s *Module) Exts() []*Statement           { return s.Extensions }
+func (s *Module) NumChildren() int             { return numChildNodes(s) }
+func (s *Module) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *Module) Groupings() []*Grouping       { return s.Grouping }
+func (s *Module) Typedefs() []*Typedef         { return s.Typedef }
+func (s *Module) Identities() []*Identity      { return s.Identity }
 
 // Current returns the most recent revision of this module, or "" if the module
 // has no revisions.
@@ -179,6 +186,28 @@ func (s *Module) FullName() string {
 	return s.Name
 }
 
+// OrganizationString returns the argument of the organization statement of
+// the module, or "" if it has none.
+func (s *Module) OrganizationString() string { return s.Organization.asString() }
+
+// ContactString returns the argument of the contact statement of the module,
+// or "" if it has none.
+func (s *Module) ContactString() string { return s.Contact.asString() }
+
+// DescriptionString returns the argument of the description statement of the
+// module, or "" if it has none.
+func (s *Module) DescriptionString() string { return s.Description.asString() }
+
+// ReferenceString returns the argument of the reference statement of the
+// module, or "" if it has none.
+func (s *Module) ReferenceString() string { return s.Reference.asString() }
+
+// OrganizationAndContact returns the arguments of the organization and
+// contact statements of the module, using "" for a missing statement.
+func (s *Module) OrganizationAndContact() (org, contact string) {
+	return s.OrganizationString(), s.ContactString()
+}
+
 // GetPrefix returns the proper prefix of m.  Useful when looking up types
 // in modules found by FindModuleByPrefix.
 func (s *Module) GetPrefix() string {
@@ -203,6 +232,47 @@ func (s *Module) getPrefix() *Value {
 	}
 }
 
+// The Sorted methods of Module return the definitions of the module sorted
+// by name, for iterating over them in a deterministic order.  The slices are
+// copies: the fields of the module retain the order of their definition and
+// are used for lookups.  Definitions in the submodules included by the module
+// are not included.
+
+// SortedTypedefs returns the typedefs of the module sorted by name.
+func (s *Module) SortedTypedefs() []*Typedef {
+	t := append([]*Typedef{}, s.Typedef...)
+	sort.SliceStable(t, func(i, j int) bool { return t[i].Name < t[j].Name })
+	return t
+}
+
+// SortedGroupings returns the groupings of the module sorted by name.
+func (s *Module) SortedGroupings() []*Grouping {
+	g := append([]*Grouping{}, s.Grouping...)
+	sort.SliceStable(g, func(i, j int) bool { return g[i].Name < g[j].Name })
+	return g
+}
+
+// SortedIdentities returns the identities of the module sorted by name.
+func (s *Module) SortedIdentities() []*Identity {
+	ids := append([]*Identity{}, s.Identity...)
+	sort.SliceStable(ids, func(i, j int) bool { return ids[i].Name < ids[j].Name })
+	return ids
+}
+
+// SortedFeatures returns the features of the module sorted by name.
+func (s *Module) SortedFeatures() []*Feature {
+	f := append([]*Feature{}, s.Feature...)
+	sort.SliceStable(f, func(i, j int) bool { return f[i].Name < f[j].Name })
+	return f
+}
+
+// SortedExtensions returns the extensions of the module sorted by name.
+func (s *Module) SortedExtensions() []*Extension {
+	e := append([]*Extension{}, s.Extension...)
+	sort.SliceStable(e, func(i, j int) bool { return e[i].Name < e[j].Name })
+	return e
+}
+
 // An Import is defined in: http://tools.ietf.org/html/rfc6020#section-7.1.5
 type Import struct {
 	Name       string       `yang:"Name,nomerge"`
@@ -220,11 +290,13 @@ type Import struct {
 	Module *Module
 }
 
-func (Import) Kind() string             { return "import" }
-func (s *Import) ParentNode() Node      { return s.Parent }
-func (s *Import) NName() string         { return s.Name }
-func (s *Import) Statement() *Statement { return s.Source }
-func (s *Import) Exts() []*Statement    { return s.Extensions }
+func (Import) Kind() string                    { return "import" }
+func (s *Import) ParentNode() Node             { return s.Parent }
+func (s *Import) NName() string                { return s.Name }
+func (s *Import) Statement() *Statement        { return s.Source }
+func (s *Import) Exts() []*Statement           { return s.Extensions }
+func (s *Import) NumChildren() int             { return numChildNodes(s) }
+func (s *Import) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An Include is defined in: http://tools.ietf.org/html/rfc6020#section-7.1.6
 type Include struct {
@@ -240,11 +312,13 @@ type Include struct {
 	Module *Module
 }
 
-func (Include) Kind() string             { return "include" }
-func (s *Include) ParentNode() Node      { return s.Parent }
-func (s *Include) NName() string         { return s.Name }
-func (s *Include) Statement() *Statement { return s.Source }
-func (s *Include) Exts() []*Statement    { return s.Extensions }
+func (Include) Kind() string                    { return "include" }
+func (s *Include) ParentNode() Node             { return s.Parent }
+func (s *Include) NName() string                { return s.Name }
+func (s *Include) Statement() *Statement        { return s.Source }
+func (s *Include) Exts() []*Statement           { return s.Extensions }
+func (s *Include) NumChildren() int             { return numChildNodes(s) }
+func (s *Include) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Revision is defined in: http://tools.ietf.org/html/rfc6020#section-7.1.9
 type Revision struct {
@@ -257,11 +331,13 @@ type Revision struct {
 	Reference   *Value `yang:"reference"`
 }
 
-func (Revision) Kind() string             { return "revision" }
-func (s *Revision) ParentNode() Node      { return s.Parent }
-func (s *Revision) NName() string         { return s.Name }
-func (s *Revision) Statement() *Statement { return s.Source }
-func (s *Revision) Exts() []*Statement    { return s.Extensions }
+func (Revision) Kind() string                    { return "revision" }
+func (s *Revision) ParentNode() Node             { return s.Parent }
+func (s *Revision) NName() string                { return s.Name }
+func (s *Revision) Statement() *Statement        { return s.Source }
+func (s *Revision) Exts() []*Statement           { return s.Extensions }
+func (s *Revision) NumChildren() int             { return numChildNodes(s) }
+func (s *Revision) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A BelongsTo is defined in: http://tools.ietf.org/html/rfc6020#section-7.2.2
 type BelongsTo struct {
@@ -273,11 +349,13 @@ type BelongsTo struct {
 	Prefix *Value `yang:"prefix,required"`
 }
 
-func (BelongsTo) Kind() string             { return "belongs-to" }
-func (s *BelongsTo) ParentNode() Node      { return s.Parent }
-func (s *BelongsTo) NName() string         { return s.Name }
-func (s *BelongsTo) Statement() *Statement { return s.Source }
-func (s *BelongsTo) Exts() []*Statement    { return s.Extensions }
+func (BelongsTo) Kind() string                    { return "belongs-to" }
+func (s *BelongsTo) ParentNode() Node             { return s.Parent }
+func (s *BelongsTo) NName() string                { return s.Name }
+func (s *BelongsTo) Statement() *Statement        { return s.Source }
+func (s *BelongsTo) Exts() []*Statement           { return s.Extensions }
+func (s *BelongsTo) NumChildren() int             { return numChildNodes(s) }
+func (s *BelongsTo) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Typedef is defined in: http://tools.ietf.org/html/rfc6020#section-7.3
 type Typedef struct {
@@ -296,11 +374,13 @@ type Typedef struct {
 	YangType *YangType `json:"-"`
 }
 
-func (Typedef) Kind() string             { return "typedef" }
-func (s *Typedef) ParentNode() Node      { return s.Parent }
-func (s *Typedef) NName() string         { return s.Name }
-func (s *Typedef) Statement() *Statement { return s.Source }
-func (s *Typedef) Exts() []*Statement    { return s.Extensions }
+func (Typedef) Kind() string                    { return "typedef" }
+func (s *Typedef) ParentNode() Node             { return s.Parent }
+func (s *Typedef) NName() string                { return s.Name }
+func (s *Typedef) Statement() *Statement        { return s.Source }
+func (s *Typedef) Exts() []*Statement           { return s.Extensions }
+func (s *Typedef) NumChildren() int             { return numChildNodes(s) }
+func (s *Typedef) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Type is defined in: http://tools.ietf.org/html/rfc6020#section-7.4
 // Note that Name is the name of the type we want, it is what must
@@ -325,11 +405,13 @@ type Type struct {
 	YangType *YangType
 }
 
-func (Type) Kind() string             { return "type" }
-func (s *Type) ParentNode() Node      { return s.Parent }
-func (s *Type) NName() string         { return s.Name }
-func (s *Type) Statement() *Statement { return s.Source }
-func (s *Type) Exts() []*Statement    { return s.Extensions }
+func (Type) Kind() string                    { return "type" }
+func (s *Type) ParentNode() Node             { return s.Parent }
+func (s *Type) NName() string                { return s.Name }
+func (s *Type) Statement() *Statement        { return s.Source }
+func (s *Type) Exts() []*Statement           { return s.Extensions }
+func (s *Type) NumChildren() int             { return numChildNodes(s) }
+func (s *Type) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Container is defined in: http://tools.ietf.org/html/rfc6020#section-7.5
 // and http://tools.ietf.org/html/rfc7950#section-7.5 ("container" sub-statement)
@@ -360,13 +442,15 @@ type Container struct {
 	When        *Value       `yang:"when"`
 }
 
-func (Container) Kind() string              { return "container" }
-func (s *Container) ParentNode() Node       { return s.Parent }
-func (s *Container) NName() string          { return s.Name }
-func (s *Container) Statement() *Statement  { return s.Source }
-func (s *Container) Exts() []*Statement     { return s.Extensions }
-func (s *Container) Groupings() []*Grouping { return s.Grouping }
-func (s *Container) Typedefs() []*Typedef   { return s.Typedef }
+func (Container) Kind() string                    { return "container" }
+func (s *Container) ParentNode() Node             { return s.Parent }
+func (s *Container) NName() string                { return s.Name }
+func (s *Container) Statement() *Statement        { return s.Source }
+func (s *Container) Exts() []*Statement           { return s.Extensions }
+func (s *Container) NumChildren() int             { return numChildNodes(s) }
+func (s *Container) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *Container) Groupings() []*Grouping       { return s.Grouping }
+func (s *Container) Typedefs() []*Typedef         { return s.Typedef }
 
 // A Must is defined in: http://tools.ietf.org/html/rfc6020#section-7.5.3
 type Must struct {
@@ -381,11 +465,13 @@ type Must struct {
 	Reference    *Value `yang:"reference"`
 }
 
-func (Must) Kind() string             { return "must" }
-func (s *Must) ParentNode() Node      { return s.Parent }
-func (s *Must) NName() string         { return s.Name }
-func (s *Must) Statement() *Statement { return s.Source }
-func (s *Must) Exts() []*Statement    { return s.Extensions }
+func (Must) Kind() string                    { return "must" }
+func (s *Must) ParentNode() Node             { return s.Parent }
+func (s *Must) NName() string                { return s.Name }
+func (s *Must) Statement() *Statement        { return s.Source }
+func (s *Must) Exts() []*Statement           { return s.Extensions }
+func (s *Must) NumChildren() int             { return numChildNodes(s) }
+func (s *Must) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Leaf is defined in: http://tools.ietf.org/html/rfc6020#section-7.6
 type Leaf struct {
@@ -407,11 +493,13 @@ type Leaf struct {
 	When        *Value   `yang:"when"`
 }
 
-func (Leaf) Kind() string             { return "leaf" }
-func (s *Leaf) ParentNode() Node      { return s.Parent }
-func (s *Leaf) NName() string         { return s.Name }
-func (s *Leaf) Statement() *Statement { return s.Source }
-func (s *Leaf) Exts() []*Statement    { return s.Extensions }
+func (Leaf) Kind() string                    { return "leaf" }
+func (s *Leaf) ParentNode() Node             { return s.Parent }
+func (s *Leaf) NName() string                { return s.Name }
+func (s *Leaf) Statement() *Statement        { return s.Source }
+func (s *Leaf) Exts() []*Statement           { return s.Extensions }
+func (s *Leaf) NumChildren() int             { return numChildNodes(s) }
+func (s *Leaf) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A LeafList is defined in: http://tools.ietf.org/html/rfc6020#section-7.7
 // It this is supposed to be an array of nodes..
@@ -422,6 +510,7 @@ type LeafList struct {
 	Extensions []*Statement `yang:"Ext"`
 
 	Config      *Value   `yang:"config"`
+	Default     []*Value `yang:"default"`
 	Description *Value   `yang:"description"`
 	IfFeature   []*Value `yang:"if-feature"`
 	MaxElements *Value   `yang:"max-elements"`
@@ -435,11 +524,13 @@ type LeafList struct {
 	When        *Value   `yang:"when"`
 }
 
-func (LeafList) Kind() string             { return "leaf-list" }
-func (s *LeafList) ParentNode() Node      { return s.Parent }
-func (s *LeafList) NName() string         { return s.Name }
-func (s *LeafList) Statement() *Statement { return s.Source }
-func (s *LeafList) Exts() []*Statement    { return s.Extensions }
+func (LeafList) Kind() string                    { return "leaf-list" }
+func (s *LeafList) ParentNode() Node             { return s.Parent }
+func (s *LeafList) NName() string                { return s.Name }
+func (s *LeafList) Statement() *Statement        { return s.Source }
+func (s *LeafList) Exts() []*Statement           { return s.Extensions }
+func (s *LeafList) NumChildren() int             { return numChildNodes(s) }
+func (s *LeafList) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A List is defined in: http://tools.ietf.org/html/rfc6020#section-7.8
 // and http://tools.ietf.org/html/rfc7950#section-7.8 ("list" sub-statement)
@@ -474,13 +565,15 @@ type List struct {
 	When        *Value       `yang:"when"`
 }
 
-func (List) Kind() string              { return "list" }
-func (s *List) ParentNode() Node       { return s.Parent }
-func (s *List) NName() string          { return s.Name }
-func (s *List) Statement() *Statement  { return s.Source }
-func (s *List) Exts() []*Statement     { return s.Extensions }
-func (s *List) Groupings() []*Grouping { return s.Grouping }
-func (s *List) Typedefs() []*Typedef   { return s.Typedef }
+func (List) Kind() string                    { return "list" }
+func (s *List) ParentNode() Node             { return s.Parent }
+func (s *List) NName() string                { return s.Name }
+func (s *List) Statement() *Statement        { return s.Source }
+func (s *List) Exts() []*Statement           { return s.Extensions }
+func (s *List) NumChildren() int             { return numChildNodes(s) }
+func (s *List) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *List) Groupings() []*Grouping       { return s.Grouping }
+func (s *List) Typedefs() []*Typedef         { return s.Typedef }
 
 // A Choice is defined in: http://tools.ietf.org/html/rfc6020#section-7.9
 type Choice struct {
@@ -506,11 +599,13 @@ type Choice struct {
 	When        *Value       `yang:"when"`
 }
 
-func (Choice) Kind() string             { return "choice" }
-func (s *Choice) ParentNode() Node      { return s.Parent }
-func (s *Choice) NName() string         { return s.Name }
-func (s *Choice) Statement() *Statement { return s.Source }
-func (s *Choice) Exts() []*Statement    { return s.Extensions }
+func (Choice) Kind() string                    { return "choice" }
+func (s *Choice) ParentNode() Node             { return s.Parent }
+func (s *Choice) NName() string                { return s.Name }
+func (s *Choice) Statement() *Statement        { return s.Source }
+func (s *Choice) Exts() []*Statement           { return s.Extensions }
+func (s *Choice) NumChildren() int             { return numChildNodes(s) }
+func (s *Choice) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Case is defined in: http://tools.ietf.org/html/rfc6020#section-7.9.2
 type Case struct {
@@ -534,11 +629,13 @@ type Case struct {
 	When        *Value       `yang:"when"`
 }
 
-func (Case) Kind() string             { return "case" }
-func (s *Case) ParentNode() Node      { return s.Parent }
-func (s *Case) NName() string         { return s.Name }
-func (s *Case) Statement() *Statement { return s.Source }
-func (s *Case) Exts() []*Statement    { return s.Extensions }
+func (Case) Kind() string                    { return "case" }
+func (s *Case) ParentNode() Node             { return s.Parent }
+func (s *Case) NName() string                { return s.Name }
+func (s *Case) Statement() *Statement        { return s.Source }
+func (s *Case) Exts() []*Statement           { return s.Extensions }
+func (s *Case) NumChildren() int             { return numChildNodes(s) }
+func (s *Case) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An AnyXML is defined in: http://tools.ietf.org/html/rfc6020#section-7.10
 type AnyXML struct {
@@ -557,11 +654,13 @@ type AnyXML struct {
 	When        *Value   `yang:"when"`
 }
 
-func (AnyXML) Kind() string             { return "anyxml" }
-func (s *AnyXML) ParentNode() Node      { return s.Parent }
-func (s *AnyXML) NName() string         { return s.Name }
-func (s *AnyXML) Statement() *Statement { return s.Source }
-func (s *AnyXML) Exts() []*Statement    { return s.Extensions }
+func (AnyXML) Kind() string                    { return "anyxml" }
+func (s *AnyXML) ParentNode() Node             { return s.Parent }
+func (s *AnyXML) NName() string                { return s.Name }
+func (s *AnyXML) Statement() *Statement        { return s.Source }
+func (s *AnyXML) Exts() []*Statement           { return s.Extensions }
+func (s *AnyXML) NumChildren() int             { return numChildNodes(s) }
+func (s *AnyXML) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An AnyData is defined in: http://tools.ietf.org/html/rfc7950#section-7.10
 //
@@ -583,11 +682,13 @@ type AnyData struct {
 	When        *Value   `yang:"when"`
 }
 
-func (AnyData) Kind() string             { return "anydata" }
-func (s *AnyData) ParentNode() Node      { return s.Parent }
-func (s *AnyData) NName() string         { return s.Name }
-func (s *AnyData) Statement() *Statement { return s.Source }
-func (s *AnyData) Exts() []*Statement    { return s.Extensions }
+func (AnyData) Kind() string                    { return "anydata" }
+func (s *AnyData) ParentNode() Node             { return s.Parent }
+func (s *AnyData) NName() string                { return s.Name }
+func (s *AnyData) Statement() *Statement        { return s.Source }
+func (s *AnyData) Exts() []*Statement           { return s.Extensions }
+func (s *AnyData) NumChildren() int             { return numChildNodes(s) }
+func (s *AnyData) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Grouping is defined in: http://tools.ietf.org/html/rfc6020#section-7.11
 // and http://tools.ietf.org/html/rfc7950#section-7.12 ("grouping" sub-statement)
@@ -613,13 +714,15 @@ type Grouping struct {
 	Uses        []*Uses      `yang:"uses"`
 }
 
-func (Grouping) Kind() string              { return "grouping" }
-func (s *Grouping) ParentNode() Node       { return s.Parent }
-func (s *Grouping) NName() string          { return s.Name }
-func (s *Grouping) Statement() *Statement  { return s.Source }
-func (s *Grouping) Exts() []*Statement     { return s.Extensions }
-func (s *Grouping) Groupings() []*Grouping { return s.Grouping }
-func (s *Grouping) Typedefs() []*Typedef   { return s.Typedef }
+func (Grouping) Kind() string                    { return "grouping" }
+func (s *Grouping) ParentNode() Node             { return s.Parent }
+func (s *Grouping) NName() string                { return s.Name }
+func (s *Grouping) Statement() *Statement        { return s.Source }
+func (s *Grouping) Exts() []*Statement           { return s.Extensions }
+func (s *Grouping) NumChildren() int             { return numChildNodes(s) }
+func (s *Grouping) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *Grouping) Groupings() []*Grouping       { return s.Grouping }
+func (s *Grouping) Typedefs() []*Typedef         { return s.Typedef }
 
 // A Uses is defined in: http://tools.ietf.org/html/rfc6020#section-7.12
 type Uses struct {
@@ -637,11 +740,13 @@ type Uses struct {
 	When        *Value    `yang:"when" json:",omitempty"`
 }
 
-func (Uses) Kind() string             { return "uses" }
-func (s *Uses) ParentNode() Node      { return s.Parent }
-func (s *Uses) NName() string         { return s.Name }
-func (s *Uses) Statement() *Statement { return s.Source }
-func (s *Uses) Exts() []*Statement    { return s.Extensions }
+func (Uses) Kind() string                    { return "uses" }
+func (s *Uses) ParentNode() Node             { return s.Parent }
+func (s *Uses) NName() string                { return s.Name }
+func (s *Uses) Statement() *Statement        { return s.Source }
+func (s *Uses) Exts() []*Statement           { return s.Extensions }
+func (s *Uses) NumChildren() int             { return numChildNodes(s) }
+func (s *Uses) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Refine is defined in: http://tools.ietf.org/html/rfc6020#section-7.12.2
 type Refine struct {
@@ -661,11 +766,13 @@ type Refine struct {
 	MinElements *Value  `yang:"min-elements"`
 }
 
-func (Refine) Kind() string             { return "refine" }
-func (s *Refine) ParentNode() Node      { return s.Parent }
-func (s *Refine) NName() string         { return s.Name }
-func (s *Refine) Statement() *Statement { return s.Source }
-func (s *Refine) Exts() []*Statement    { return s.Extensions }
+func (Refine) Kind() string                    { return "refine" }
+func (s *Refine) ParentNode() Node             { return s.Parent }
+func (s *Refine) NName() string                { return s.Name }
+func (s *Refine) Statement() *Statement        { return s.Source }
+func (s *Refine) Exts() []*Statement           { return s.Extensions }
+func (s *Refine) NumChildren() int             { return numChildNodes(s) }
+func (s *Refine) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An RPC is defined in: http://tools.ietf.org/html/rfc6020#section-7.13
 type RPC struct {
@@ -684,13 +791,15 @@ type RPC struct {
 	Typedef     []*Typedef  `yang:"typedef"`
 }
 
-func (RPC) Kind() string              { return "rpc" }
-func (s *RPC) ParentNode() Node       { return s.Parent }
-func (s *RPC) NName() string          { return s.Name }
-func (s *RPC) Statement() *Statement  { return s.Source }
-func (s *RPC) Exts() []*Statement     { return s.Extensions }
-func (s *RPC) Groupings() []*Grouping { return s.Grouping }
-func (s *RPC) Typedefs() []*Typedef   { return s.Typedef }
+func (RPC) Kind() string                    { return "rpc" }
+func (s *RPC) ParentNode() Node             { return s.Parent }
+func (s *RPC) NName() string                { return s.Name }
+func (s *RPC) Statement() *Statement        { return s.Source }
+func (s *RPC) Exts() []*Statement           { return s.Extensions }
+func (s *RPC) NumChildren() int             { return numChildNodes(s) }
+func (s *RPC) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *RPC) Groupings() []*Grouping       { return s.Grouping }
+func (s *RPC) Typedefs() []*Typedef         { return s.Typedef }
 
 // An Input is defined in: http://tools.ietf.org/html/rfc6020#section-7.13.2
 type Input struct {
@@ -711,13 +820,15 @@ type Input struct {
 	Uses      []*Uses      `yang:"uses"`
 }
 
-func (Input) Kind() string              { return "input" }
-func (s *Input) ParentNode() Node       { return s.Parent }
-func (s *Input) NName() string          { return s.Name }
-func (s *Input) Statement() *Statement  { return s.Source }
-func (s *Input) Exts() []*Statement     { return s.Extensions }
-func (s *Input) Groupings() []*Grouping { return s.Grouping }
-func (s *Input) Typedefs() []*Typedef   { return s.Typedef }
+func (Input) Kind() string                    { return "input" }
+func (s *Input) ParentNode() Node             { return s.Parent }
+func (s *Input) NName() string                { return s.Name }
+func (s *Input) Statement() *Statement        { return s.Source }
+func (s *Input) Exts() []*Statement           { return s.Extensions }
+func (s *Input) NumChildren() int             { return numChildNodes(s) }
+func (s *Input) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *Input) Groupings() []*Grouping       { return s.Grouping }
+func (s *Input) Typedefs() []*Typedef         { return s.Typedef }
 
 // An Output is defined in: http://tools.ietf.org/html/rfc6020#section-7.13.3
 type Output struct {
@@ -738,13 +849,15 @@ type Output struct {
 	Uses      []*Uses      `yang:"uses"`
 }
 
-func (Output) Kind() string              { return "output" }
-func (s *Output) ParentNode() Node       { return s.Parent }
-func (s *Output) NName() string          { return s.Name }
-func (s *Output) Statement() *Statement  { return s.Source }
-func (s *Output) Exts() []*Statement     { return s.Extensions }
-func (s *Output) Groupings() []*Grouping { return s.Grouping }
-func (s *Output) Typedefs() []*Typedef   { return s.Typedef }
+func (Output) Kind() string                    { return "output" }
+func (s *Output) ParentNode() Node             { return s.Parent }
+func (s *Output) NName() string                { return s.Name }
+func (s *Output) Statement() *Statement        { return s.Source }
+func (s *Output) Exts() []*Statement           { return s.Extensions }
+func (s *Output) NumChildren() int             { return numChildNodes(s) }
+func (s *Output) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *Output) Groupings() []*Grouping       { return s.Grouping }
+func (s *Output) Typedefs() []*Typedef         { return s.Typedef }
 
 // A Notification is defined in: http://tools.ietf.org/html/rfc6020#section-7.14
 type Notification struct {
@@ -769,13 +882,15 @@ type Notification struct {
 	Uses        []*Uses      `yang:"uses"`
 }
 
-func (Notification) Kind() string              { return "notification" }
-func (s *Notification) ParentNode() Node       { return s.Parent }
-func (s *Notification) NName() string          { return s.Name }
-func (s *Notification) Statement() *Statement  { return s.Source }
-func (s *Notification) Exts() []*Statement     { return s.Extensions }
-func (s *Notification) Groupings() []*Grouping { return s.Grouping }
-func (s *Notification) Typedefs() []*Typedef   { return s.Typedef }
+func (Notification) Kind() string                    { return "notification" }
+func (s *Notification) ParentNode() Node             { return s.Parent }
+func (s *Notification) NName() string                { return s.Name }
+func (s *Notification) Statement() *Statement        { return s.Source }
+func (s *Notification) Exts() []*Statement           { return s.Extensions }
+func (s *Notification) NumChildren() int             { return numChildNodes(s) }
+func (s *Notification) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *Notification) Groupings() []*Grouping       { return s.Grouping }
+func (s *Notification) Typedefs() []*Typedef         { return s.Typedef }
 
 // An Augment is defined in: http://tools.ietf.org/html/rfc6020#section-7.15
 // and http://tools.ietf.org/html/rfc7950#section-7.17 ("augment" sub-statement)
@@ -802,11 +917,13 @@ type Augment struct {
 	When        *Value       `yang:"when"`
 }
 
-func (Augment) Kind() string             { return "augment" }
-func (s *Augment) ParentNode() Node      { return s.Parent }
-func (s *Augment) NName() string         { return s.Name }
-func (s *Augment) Statement() *Statement { return s.Source }
-func (s *Augment) Exts() []*Statement    { return s.Extensions }
+func (Augment) Kind() string                    { return "augment" }
+func (s *Augment) ParentNode() Node             { return s.Parent }
+func (s *Augment) NName() string                { return s.Name }
+func (s *Augment) Statement() *Statement        { return s.Source }
+func (s *Augment) Exts() []*Statement           { return s.Extensions }
+func (s *Augment) NumChildren() int             { return numChildNodes(s) }
+func (s *Augment) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An Identity is defined in: http://tools.ietf.org/html/rfc6020#section-7.16
 type Identity struct {
@@ -822,11 +939,13 @@ type Identity struct {
 	Values      []*Identity `json:",omitempty"`
 }
 
-func (Identity) Kind() string             { return "identity" }
-func (s *Identity) ParentNode() Node      { return s.Parent }
-func (s *Identity) NName() string         { return s.Name }
-func (s *Identity) Statement() *Statement { return s.Source }
-func (s *Identity) Exts() []*Statement    { return s.Extensions }
+func (Identity) Kind() string                    { return "identity" }
+func (s *Identity) ParentNode() Node             { return s.Parent }
+func (s *Identity) NName() string                { return s.Name }
+func (s *Identity) Statement() *Statement        { return s.Source }
+func (s *Identity) Exts() []*Statement           { return s.Extensions }
+func (s *Identity) NumChildren() int             { return numChildNodes(s) }
+func (s *Identity) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // PrefixedName returns the prefix-qualified name for the identity
 func (s *Identity) PrefixedName() string {
@@ -867,11 +986,13 @@ type Extension struct {
 	Status      *Value    `yang:"status"`
 }
 
-func (Extension) Kind() string             { return "extension" }
-func (s *Extension) ParentNode() Node      { return s.Parent }
-func (s *Extension) NName() string         { return s.Name }
-func (s *Extension) Statement() *Statement { return s.Source }
-func (s *Extension) Exts() []*Statement    { return s.Extensions }
+func (Extension) Kind() string                    { return "extension" }
+func (s *Extension) ParentNode() Node             { return s.Parent }
+func (s *Extension) NName() string                { return s.Name }
+func (s *Extension) Statement() *Statement        { return s.Source }
+func (s *Extension) Exts() []*Statement           { return s.Extensions }
+func (s *Extension) NumChildren() int             { return numChildNodes(s) }
+func (s *Extension) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An Argument is defined in: http://tools.ietf.org/html/rfc6020#section-7.17.2
 type Argument struct {
@@ -883,11 +1004,13 @@ type Argument struct {
 	YinElement *Value `yang:"yin-element"`
 }
 
-func (Argument) Kind() string             { return "argument" }
-func (s *Argument) ParentNode() Node      { return s.Parent }
-func (s *Argument) NName() string         { return s.Name }
-func (s *Argument) Statement() *Statement { return s.Source }
-func (s *Argument) Exts() []*Statement    { return s.Extensions }
+func (Argument) Kind() string                    { return "argument" }
+func (s *Argument) ParentNode() Node             { return s.Parent }
+func (s *Argument) NName() string                { return s.Name }
+func (s *Argument) Statement() *Statement        { return s.Source }
+func (s *Argument) Exts() []*Statement           { return s.Extensions }
+func (s *Argument) NumChildren() int             { return numChildNodes(s) }
+func (s *Argument) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An Element is defined in: http://tools.ietf.org/html/rfc6020#section-7.17.2.2
 type Element struct {
@@ -899,11 +1022,13 @@ type Element struct {
 	YinElement *Value `yang:"yin-element"`
 }
 
-func (Element) Kind() string             { return "element" }
-func (s *Element) ParentNode() Node      { return s.Parent }
-func (s *Element) NName() string         { return s.Name }
-func (s *Element) Statement() *Statement { return s.Source }
-func (s *Element) Exts() []*Statement    { return s.Extensions }
+func (Element) Kind() string                    { return "element" }
+func (s *Element) ParentNode() Node             { return s.Parent }
+func (s *Element) NName() string                { return s.Name }
+func (s *Element) Statement() *Statement        { return s.Source }
+func (s *Element) Exts() []*Statement           { return s.Extensions }
+func (s *Element) NumChildren() int             { return numChildNodes(s) }
+func (s *Element) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Feature is defined in: http://tools.ietf.org/html/rfc6020#section-7.18.1
 type Feature struct {
@@ -918,11 +1043,13 @@ type Feature struct {
 	Reference   *Value   `yang:"reference"`
 }
 
-func (Feature) Kind() string             { return "feature" }
-func (s *Feature) ParentNode() Node      { return s.Parent }
-func (s *Feature) NName() string         { return s.Name }
-func (s *Feature) Statement() *Statement { return s.Source }
-func (s *Feature) Exts() []*Statement    { return s.Extensions }
+func (Feature) Kind() string                    { return "feature" }
+func (s *Feature) ParentNode() Node             { return s.Parent }
+func (s *Feature) NName() string                { return s.Name }
+func (s *Feature) Statement() *Statement        { return s.Source }
+func (s *Feature) Exts() []*Statement           { return s.Extensions }
+func (s *Feature) NumChildren() int             { return numChildNodes(s) }
+func (s *Feature) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Deviation is defined in: http://tools.ietf.org/html/rfc6020#section-7.18.3
 type Deviation struct {
@@ -936,11 +1063,13 @@ type Deviation struct {
 	Reference   *Value     `yang:"reference"`
 }
 
-func (Deviation) Kind() string             { return "deviation" }
-func (s *Deviation) ParentNode() Node      { return s.Parent }
-func (s *Deviation) NName() string         { return s.Name }
-func (s *Deviation) Statement() *Statement { return s.Source }
-func (s *Deviation) Exts() []*Statement    { return s.Extensions }
+func (Deviation) Kind() string                    { return "deviation" }
+func (s *Deviation) ParentNode() Node             { return s.Parent }
+func (s *Deviation) NName() string                { return s.Name }
+func (s *Deviation) Statement() *Statement        { return s.Source }
+func (s *Deviation) Exts() []*Statement           { return s.Extensions }
+func (s *Deviation) NumChildren() int             { return numChildNodes(s) }
+func (s *Deviation) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Deviate is defined in: http://tools.ietf.org/html/rfc6020#section-7.18.3.2
 type Deviate struct {
@@ -960,11 +1089,13 @@ type Deviate struct {
 	Units       *Value   `yang:"units"`
 }
 
-func (Deviate) Kind() string             { return "deviate" }
-func (s *Deviate) ParentNode() Node      { return s.Parent }
-func (s *Deviate) NName() string         { return s.Name }
-func (s *Deviate) Statement() *Statement { return s.Source }
-func (s *Deviate) Exts() []*Statement    { return s.Extensions }
+func (Deviate) Kind() string                    { return "deviate" }
+func (s *Deviate) ParentNode() Node             { return s.Parent }
+func (s *Deviate) NName() string                { return s.Name }
+func (s *Deviate) Statement() *Statement        { return s.Source }
+func (s *Deviate) Exts() []*Statement           { return s.Extensions }
+func (s *Deviate) NumChildren() int             { return numChildNodes(s) }
+func (s *Deviate) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An Enum is defined in: http://tools.ietf.org/html/rfc6020#section-9.6.4
 type Enum struct {
@@ -979,11 +1110,13 @@ type Enum struct {
 	Value       *Value `yang:"value"`
 }
 
-func (Enum) Kind() string             { return "enum" }
-func (s *Enum) ParentNode() Node      { return s.Parent }
-func (s *Enum) NName() string         { return s.Name }
-func (s *Enum) Statement() *Statement { return s.Source }
-func (s *Enum) Exts() []*Statement    { return s.Extensions }
+func (Enum) Kind() string                    { return "enum" }
+func (s *Enum) ParentNode() Node             { return s.Parent }
+func (s *Enum) NName() string                { return s.Name }
+func (s *Enum) Statement() *Statement        { return s.Source }
+func (s *Enum) Exts() []*Statement           { return s.Extensions }
+func (s *Enum) NumChildren() int             { return numChildNodes(s) }
+func (s *Enum) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Bit is defined in: http://tools.ietf.org/html/rfc6020#section-9.7.4
 type Bit struct {
@@ -998,11 +1131,13 @@ type Bit struct {
 	Position    *Value `yang:"position"`
 }
 
-func (Bit) Kind() string             { return "bit" }
-func (s *Bit) ParentNode() Node      { return s.Parent }
-func (s *Bit) NName() string         { return s.Name }
-func (s *Bit) Statement() *Statement { return s.Source }
-func (s *Bit) Exts() []*Statement    { return s.Extensions }
+func (Bit) Kind() string                    { return "bit" }
+func (s *Bit) ParentNode() Node             { return s.Parent }
+func (s *Bit) NName() string                { return s.Name }
+func (s *Bit) Statement() *Statement        { return s.Source }
+func (s *Bit) Exts() []*Statement           { return s.Extensions }
+func (s *Bit) NumChildren() int             { return numChildNodes(s) }
+func (s *Bit) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Range is defined in: http://tools.ietf.org/html/rfc6020#section-9.2.4
 type Range struct {
@@ -1017,11 +1152,13 @@ type Range struct {
 	Reference    *Value `yang:"reference"`
 }
 
-func (Range) Kind() string             { return "range" }
-func (s *Range) ParentNode() Node      { return s.Parent }
-func (s *Range) NName() string         { return s.Name }
-func (s *Range) Statement() *Statement { return s.Source }
-func (s *Range) Exts() []*Statement    { return s.Extensions }
+func (Range) Kind() string                    { return "range" }
+func (s *Range) ParentNode() Node             { return s.Parent }
+func (s *Range) NName() string                { return s.Name }
+func (s *Range) Statement() *Statement        { return s.Source }
+func (s *Range) Exts() []*Statement           { return s.Extensions }
+func (s *Range) NumChildren() int             { return numChildNodes(s) }
+func (s *Range) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Length is defined in: http://tools.ietf.org/html/rfc6020#section-9.4.4
 type Length struct {
@@ -1036,11 +1173,13 @@ type Length struct {
 	Reference    *Value `yang:"reference"`
 }
 
-func (Length) Kind() string             { return "length" }
-func (s *Length) ParentNode() Node      { return s.Parent }
-func (s *Length) NName() string         { return s.Name }
-func (s *Length) Statement() *Statement { return s.Source }
-func (s *Length) Exts() []*Statement    { return s.Extensions }
+func (Length) Kind() string                    { return "length" }
+func (s *Length) ParentNode() Node             { return s.Parent }
+func (s *Length) NName() string                { return s.Name }
+func (s *Length) Statement() *Statement        { return s.Source }
+func (s *Length) Exts() []*Statement           { return s.Extensions }
+func (s *Length) NumChildren() int             { return numChildNodes(s) }
+func (s *Length) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // A Pattern is defined in: http://tools.ietf.org/html/rfc6020#section-9.4.6
 type Pattern struct {
@@ -1055,11 +1194,13 @@ type Pattern struct {
 	Reference    *Value `yang:"reference"`
 }
 
-func (Pattern) Kind() string             { return "pattern" }
-func (s *Pattern) ParentNode() Node      { return s.Parent }
-func (s *Pattern) NName() string         { return s.Name }
-func (s *Pattern) Statement() *Statement { return s.Source }
-func (s *Pattern) Exts() []*Statement    { return s.Extensions }
+func (Pattern) Kind() string                    { return "pattern" }
+func (s *Pattern) ParentNode() Node             { return s.Parent }
+func (s *Pattern) NName() string                { return s.Name }
+func (s *Pattern) Statement() *Statement        { return s.Source }
+func (s *Pattern) Exts() []*Statement           { return s.Extensions }
+func (s *Pattern) NumChildren() int             { return numChildNodes(s) }
+func (s *Pattern) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
 
 // An Action is defined in http://tools.ietf.org/html/rfc7950#section-7.15
 //
@@ -1084,10 +1225,12 @@ type Action struct {
 	Typedef     []*Typedef  `yang:"typedef"`
 }
 
-func (Action) Kind() string              { return "action" }
-func (s *Action) ParentNode() Node       { return s.Parent }
-func (s *Action) NName() string          { return s.Name }
-func (s *Action) Statement() *Statement  { return s.Source }
-func (s *Action) Exts() []*Statement     { return s.Extensions }
-func (s *Action) Groupings() []*Grouping { return s.Grouping }
-func (s *Action) Typedefs() []*Typedef   { return s.Typedef }
+func (Action) Kind() string                    { return "action" }
+func (s *Action) ParentNode() Node             { return s.Parent }
+func (s *Action) NName() string                { return s.Name }
+func (s *Action) Statement() *Statement        { return s.Source }
+func (s *Action) Exts() []*Statement           { return s.Extensions }
+func (s *Action) NumChildren() int             { return numChildNodes(s) }
+func (s *Action) NthChild(n int) (Node, error) { return nthChild(s, childNodes(s), n) }
+func (s *Action) Groupings() []*Grouping       { return s.Grouping }
+func (s *Action) Typedefs() []*Typedef         { return s.Typedef }
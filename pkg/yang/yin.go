@@ -0,0 +1,463 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+// This file implements WriteYIN and ParseYIN, which convert between
+// Statement trees and YIN, the XML form of YANG defined in RFC 6020 and
+// RFC 7950.
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// YINNamespace is the XML namespace of the YIN elements of the YANG
+// statements.
+const YINNamespace = "urn:ietf:params:xml:ns:yang:yin:1"
+
+// A yinArgument describes how the argument of a statement is represented in
+// YIN.  The argument is either the attribute or the child element named
+// name.  Statements with an empty name have no argument.
+type yinArgument struct {
+	name    string
+	element bool // the argument is a child element rather than an attribute
+}
+
+// yinArguments is the mapping of YANG keywords to their YIN arguments, from
+// section 13.1 of RFC 7950.
+var yinArguments = map[string]yinArgument{
+	"action":           {name: "name"},
+	"anydata":          {name: "name"},
+	"anyxml":           {name: "name"},
+	"argument":         {name: "name"},
+	"augment":          {name: "target-node"},
+	"base":             {name: "name"},
+	"belongs-to":       {name: "module"},
+	"bit":              {name: "name"},
+	"case":             {name: "name"},
+	"choice":           {name: "name"},
+	"config":           {name: "value"},
+	"contact":          {name: "text", element: true},
+	"container":        {name: "name"},
+	"default":          {name: "value"},
+	"description":      {name: "text", element: true},
+	"deviate":          {name: "value"},
+	"deviation":        {name: "target-node"},
+	"enum":             {name: "name"},
+	"error-app-tag":    {name: "value"},
+	"error-message":    {name: "value", element: true},
+	"extension":        {name: "name"},
+	"feature":          {name: "name"},
+	"fraction-digits":  {name: "value"},
+	"grouping":         {name: "name"},
+	"identity":         {name: "name"},
+	"if-feature":       {name: "name"},
+	"import":           {name: "module"},
+	"include":          {name: "module"},
+	"input":            {},
+	"key":              {name: "value"},
+	"leaf":             {name: "name"},
+	"leaf-list":        {name: "name"},
+	"length":           {name: "value"},
+	"list":             {name: "name"},
+	"mandatory":        {name: "value"},
+	"max-elements":     {name: "value"},
+	"min-elements":     {name: "value"},
+	"modifier":         {name: "value"},
+	"module":           {name: "name"},
+	"must":             {name: "condition"},
+	"namespace":        {name: "uri"},
+	"notification":     {name: "name"},
+	"ordered-by":       {name: "value"},
+	"organization":     {name: "text", element: true},
+	"output":           {},
+	"path":             {name: "value"},
+	"pattern":          {name: "value"},
+	"position":         {name: "value"},
+	"prefix":           {name: "value"},
+	"presence":         {name: "value"},
+	"range":            {name: "value"},
+	"reference":        {name: "text", element: true},
+	"refine":           {name: "target-node"},
+	"require-instance": {name: "value"},
+	"revision":         {name: "date"},
+	"revision-date":    {name: "date"},
+	"rpc":              {name: "name"},
+	"status":           {name: "value"},
+	"submodule":        {name: "name"},
+	"type":             {name: "name"},
+	"typedef":          {name: "name"},
+	"unique":           {name: "tag"},
+	"units":            {name: "name"},
+	"uses":             {name: "name"},
+	"value":            {name: "value"},
+	"when":             {name: "condition"},
+	"yang-version":     {name: "value"},
+	"yin-element":      {name: "value"},
+}
+
+// WriteYIN writes the YIN form of the module or submodule m to w.  The
+// modules imported by m must have been read and m must have been processed,
+// as the namespaces of the prefixes used by m, and the extensions of the
+// extension statements in m, are looked up in them.
+//
+// The argument of each YANG statement is written as an attribute or a child
+// element as required by RFC 7950.  The argument of an extension statement
+// is written as the attribute or child element named by the argument
+// statement of its extension, and is a child element if the argument has
+// yin-element true.  The YIN element of the module or submodule declares the
+// YIN namespace as the default namespace and the namespaces of the prefix
+// of m and of each of its imports.  Nothing is written to w if an error is
+// returned.
+func WriteYIN(w io.Writer, m *Module) error {
+	if m == nil || m.Source == nil {
+		return errors.New("no module to write")
+	}
+	namespaces, err := yinNamespaces(m)
+	if err != nil {
+		return err
+	}
+	y := &yinWriter{mod: m}
+	y.buf.WriteString(xml.Header)
+	if err := y.write(m.Source, "", namespaces); err != nil {
+		return err
+	}
+	_, err = w.Write(y.buf.Bytes())
+	return err
+}
+
+// A yinNamespace is an XML namespace declared by the YIN element of a module.
+type yinNamespace struct {
+	prefix, uri string
+}
+
+// yinNamespaces returns the namespaces of the prefix of m and of the prefixes
+// of its imports.
+func yinNamespaces(m *Module) ([]yinNamespace, error) {
+	namespaces := []yinNamespace{}
+	switch {
+	case m.BelongsTo != nil:
+		var bm *Module
+		if m.modules != nil {
+			bm = m.modules.Modules[m.BelongsTo.Name]
+		}
+		if bm == nil || bm.Namespace == nil {
+			return nil, fmt.Errorf("%s: module %s, which %s belongs to, has not been read", Source(m.BelongsTo), m.BelongsTo.Name, m.Name)
+		}
+		namespaces = append(namespaces, yinNamespace{m.BelongsTo.Prefix.Name, bm.Namespace.Name})
+	case m.Prefix != nil && m.Namespace != nil:
+		namespaces = append(namespaces, yinNamespace{m.Prefix.Name, m.Namespace.Name})
+	}
+	for _, i := range m.Import {
+		if i.Module == nil || i.Module.Namespace == nil {
+			return nil, fmt.Errorf("%s: imported module %s has not been processed", Source(i), i.Name)
+		}
+		namespaces = append(namespaces, yinNamespace{i.Prefix.Name, i.Module.Namespace.Name})
+	}
+	return namespaces, nil
+}
+
+// A yinWriter writes the YIN form of the statements of a module.
+type yinWriter struct {
+	mod *Module
+	buf bytes.Buffer
+}
+
+// write writes the YIN element of s, indented by indent.  The namespaces are
+// declared by the element, after its argument attribute, along with the YIN
+// namespace, if namespaces is not nil.
+func (y *yinWriter) write(s *Statement, indent string, namespaces []yinNamespace) error {
+	arg, err := y.argument(s)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&y.buf, "%s<%s", indent, s.Keyword)
+	if arg.name != "" && !arg.element {
+		fmt.Fprintf(&y.buf, " %s=\"%s\"", arg.name, yinAttrEscape(s.Argument))
+	}
+	if namespaces != nil {
+		fmt.Fprintf(&y.buf, " xmlns=%q", YINNamespace)
+		for _, ns := range namespaces {
+			fmt.Fprintf(&y.buf, " xmlns:%s=\"%s\"", ns.prefix, yinAttrEscape(ns.uri))
+		}
+	}
+	if len(s.statements) == 0 && !arg.element {
+		y.buf.WriteString("/>\n")
+		return nil
+	}
+	y.buf.WriteString(">\n")
+	if arg.element {
+		name := arg.name
+		if prefix, _ := getPrefix(s.Keyword); prefix != "" {
+			// The argument element is in the namespace of the extension.
+			name = prefix + ":" + name
+		}
+		fmt.Fprintf(&y.buf, "%s  <%s>", indent, name)
+		yinTextEscaper.WriteString(&y.buf, s.Argument)
+		fmt.Fprintf(&y.buf, "</%s>\n", name)
+	}
+	for _, ss := range s.statements {
+		if err := y.write(ss, indent+"  ", nil); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(&y.buf, "%s</%s>\n", indent, s.Keyword)
+	return nil
+}
+
+// argument returns the YIN argument of s.  The argument of an extension
+// statement is looked up in the argument statement of its extension.
+func (y *yinWriter) argument(s *Statement) (yinArgument, error) {
+	prefix, name := getPrefix(s.Keyword)
+	if prefix == "" {
+		arg, ok := yinArguments[s.Keyword]
+		if !ok {
+			return arg, fmt.Errorf("%s: unknown statement %s", Source(s), s.Keyword)
+		}
+		if arg.name == "" && s.HasArgument {
+			return arg, fmt.Errorf("%s: %s statement has an argument", Source(s), s.Keyword)
+		}
+		if arg.name != "" && !s.HasArgument {
+			return arg, fmt.Errorf("%s: %s statement has no argument", Source(s), s.Keyword)
+		}
+		return arg, nil
+	}
+
+	m := FindModuleByPrefix(y.mod, prefix)
+	if m == nil {
+		return yinArgument{}, fmt.Errorf("%s: unknown prefix %s in %s", Source(s), prefix, s.Keyword)
+	}
+	ext := findExtension(m, name)
+	if ext == nil {
+		return yinArgument{}, fmt.Errorf("%s: extension %s not found in module %s", Source(s), name, m.Name)
+	}
+	var arg yinArgument
+	if ext.Argument != nil {
+		arg.name = ext.Argument.Name
+		arg.element = ext.Argument.YinElement != nil && ext.Argument.YinElement.Name == "true"
+	}
+	if arg.name == "" && s.HasArgument {
+		return arg, fmt.Errorf("%s: %s has an argument but extension %s has none", Source(s), s.Keyword, name)
+	}
+	return arg, nil
+}
+
+// findExtension returns the extension named name defined by m or by the
+// submodules it includes, or nil if there is no such extension.
+func findExtension(m *Module, name string) *Extension {
+	seen := map[*Module]bool{}
+	for mods := []*Module{m}; len(mods) > 0; mods = mods[1:] {
+		m := mods[0]
+		if m == nil || seen[m] {
+			continue
+		}
+		seen[m] = true
+		for _, ext := range m.Extension {
+			if ext.Name == name {
+				return ext
+			}
+		}
+		for _, i := range m.Include {
+			mods = append(mods, i.Module)
+		}
+	}
+	return nil
+}
+
+// yinTextEscaper escapes text for use as the content of an XML element.
+// Unlike xml.EscapeText, it does not escape newlines, so multi-line
+// arguments remain readable.
+var yinTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+// yinAttrEscape returns s escaped for use as the value of an XML attribute.
+func yinAttrEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// ParseYIN parses input as the YIN form of a module or submodule and returns
+// its statement.  The path parameter should be the source name where input
+// was read from, and is used as the location of the statements.
+//
+// Elements in the YIN namespace are YANG statements, and the other elements
+// are extension statements with the keyword prefix:name, where prefix is the
+// prefix that the element's namespace was declared with.  As the extensions
+// of extension statements may not be available, the argument of an extension
+// statement is its only attribute or, if it has no attributes, the first
+// element it contains if that element is in the same namespace and contains
+// only text.
+func ParseYIN(input, path string) (*Statement, error) {
+	root, err := parseYINTree(input, path)
+	if err != nil {
+		return nil, err
+	}
+	return root.statement(path)
+}
+
+// A yinNode is an XML element of a YIN document.
+type yinNode struct {
+	name     xml.Name
+	attr     []xml.Attr // the attributes that are not namespace declarations
+	children []*yinNode
+	text     strings.Builder
+	hasText  bool // text contains characters other than white space
+	line     int
+	col      int
+	prefixes map[string]string // the prefixes of the namespaces, by URI
+}
+
+// parseYINTree returns the root element of the XML document input.
+func parseYINTree(input, path string) (*yinNode, error) {
+	// lines holds the offset of the start of each line of input.
+	lines := []int{0}
+	for i, c := range input {
+		if c == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	position := func(off int64) (int, int) {
+		l := sort.Search(len(lines), func(i int) bool { return int64(lines[i]) > off })
+		return l, int(off) - lines[l-1] + 1
+	}
+
+	prefixes := map[string]string{}
+	d := xml.NewDecoder(strings.NewReader(input))
+	var root *yinNode
+	var stack []*yinNode
+	for {
+		off := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &yinNode{name: tok.Name, prefixes: prefixes}
+			n.line, n.col = position(off)
+			for _, a := range tok.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					if _, ok := prefixes[a.Value]; !ok {
+						prefixes[a.Value] = a.Name.Local
+					}
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+				default:
+					n.attr = append(n.attr, a)
+				}
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("%s:%d:%d: more than one root element", path, n.line, n.col)
+				}
+				root = n
+			} else {
+				p := stack[len(stack)-1]
+				p.children = append(p.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				n := stack[len(stack)-1]
+				n.text.Write(tok)
+				if len(bytes.TrimSpace(tok)) > 0 {
+					n.hasText = true
+				}
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("%s: no YIN element found", path)
+	}
+	return root, nil
+}
+
+// location returns the location of n in the file path.
+func (n *yinNode) location(path string) string {
+	return fmt.Sprintf("%s:%d:%d", path, n.line, n.col)
+}
+
+// statement returns the statement of the element n of the file path.
+func (n *yinNode) statement(path string) (*Statement, error) {
+	s := &Statement{file: path, line: n.line, col: n.col}
+	children := n.children
+	if n.name.Space == YINNamespace {
+		s.Keyword = n.name.Local
+		arg, ok := yinArguments[s.Keyword]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown statement %s", n.location(path), s.Keyword)
+		}
+		switch {
+		case arg.name == "":
+		case arg.element:
+			if len(children) == 0 || children[0].name.Space != YINNamespace || children[0].name.Local != arg.name {
+				return nil, fmt.Errorf("%s: %s has no %s element", n.location(path), s.Keyword, arg.name)
+			}
+			s.HasArgument = true
+			s.Argument = children[0].text.String()
+			children = children[1:]
+		default:
+			for _, a := range n.attr {
+				if a.Name.Space == "" && a.Name.Local == arg.name {
+					s.HasArgument = true
+					s.Argument = a.Value
+				}
+			}
+			if !s.HasArgument {
+				return nil, fmt.Errorf("%s: %s has no %s attribute", n.location(path), s.Keyword, arg.name)
+			}
+		}
+	} else {
+		prefix, ok := n.prefixes[n.name.Space]
+		if !ok {
+			return nil, fmt.Errorf("%s: element %s is in undeclared namespace %q", n.location(path), n.name.Local, n.name.Space)
+		}
+		s.Keyword = prefix + ":" + n.name.Local
+		switch {
+		case len(n.attr) == 1:
+			s.HasArgument = true
+			s.Argument = n.attr[0].Value
+		case len(n.attr) > 1:
+			return nil, fmt.Errorf("%s: extension statement %s has more than one attribute", n.location(path), s.Keyword)
+		case len(children) > 0:
+			if c := children[0]; c.name.Space == n.name.Space && len(c.attr) == 0 && len(c.children) == 0 {
+				s.HasArgument = true
+				s.Argument = c.text.String()
+				children = children[1:]
+			}
+		}
+	}
+	if n.hasText {
+		return nil, fmt.Errorf("%s: unexpected text in %s", n.location(path), s.Keyword)
+	}
+	for _, c := range children {
+		cs, err := c.statement(path)
+		if err != nil {
+			return nil, err
+		}
+		s.statements = append(s.statements, cs)
+	}
+	return s, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yang
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+const yinExtModule = `module ext {
+  prefix "x";
+  namespace "urn:x";
+  extension note { argument text { yin-element true; } }
+  extension tag { argument name; }
+  extension flag;
+}`
+
+const yinModule = `module sys {
+  yang-version 1.1;
+  prefix "s";
+  namespace "urn:sys";
+  import ext { prefix x; }
+
+  description "The system
+    model, <with> & more.";
+  x:flag;
+  container system {
+    x:tag "main";
+    x:note "a note";
+    leaf name {
+      type string { length "1..10"; }
+      must "string-length(.) > 1" { error-message "too short"; }
+    }
+  }
+  rpc reboot { input { leaf delay { type uint8; } } }
+}`
+
+const yinWant = `<?xml version="1.0" encoding="UTF-8"?>
+<module name="sys" xmlns="urn:ietf:params:xml:ns:yang:yin:1" xmlns:s="urn:sys" xmlns:x="urn:x">
+  <yang-version value="1.1"/>
+  <prefix value="s"/>
+  <namespace uri="urn:sys"/>
+  <import module="ext">
+    <prefix value="x"/>
+  </import>
+  <description>
+    <text>The system
+model, &lt;with&gt; &amp; more.</text>
+  </description>
+  <x:flag/>
+  <container name="system">
+    <x:tag name="main"/>
+    <x:note>
+      <x:text>a note</x:text>
+    </x:note>
+    <leaf name="name">
+      <type name="string">
+        <length value="1..10"/>
+      </type>
+      <must condition="string-length(.) &gt; 1">
+        <error-message>
+          <value>too short</value>
+        </error-message>
+      </must>
+    </leaf>
+  </container>
+  <rpc name="reboot">
+    <input>
+      <leaf name="delay">
+        <type name="uint8"/>
+      </leaf>
+    </input>
+  </rpc>
+</module>
+`
+
+func TestYIN(t *testing.T) {
+	ms := NewModules()
+	for name, in := range map[string]string{"ext": yinExtModule, "sys": yinModule} {
+		if err := ms.Parse(in, name+".yang"); err != nil {
+			t.Fatalf("Parse %s: %v", name, err)
+		}
+	}
+	if errs := ms.Process(); errs != nil {
+		t.Fatalf("Process: %v", errs)
+	}
+	m := ms.Modules["sys"]
+
+	var b bytes.Buffer
+	if err := WriteYIN(&b, m); err != nil {
+		t.Fatalf("WriteYIN: %v", err)
+	}
+	if diff := cmp.Diff(yinWant, b.String()); diff != "" {
+		t.Errorf("WriteYIN (-want, +got):\n%s", diff)
+	}
+
+	s, err := ParseYIN(b.String(), "sys.yin")
+	if err != nil {
+		t.Fatalf("ParseYIN: %v", err)
+	}
+	if diff := cmp.Diff(m.Source.String(), s.String()); diff != "" {
+		t.Errorf("ParseYIN of WriteYIN (-want, +got):\n%s", diff)
+	}
+	if got, want := s.statements[6].Location(), "sys.yin:14:3"; got != want {
+		t.Errorf("ParseYIN: container location got %s, want %s", got, want)
+	}
+
+	// The extension module round trips as well.
+	b.Reset()
+	if err := WriteYIN(&b, ms.Modules["ext"]); err != nil {
+		t.Fatalf("WriteYIN ext: %v", err)
+	}
+	s, err = ParseYIN(b.String(), "ext.yin")
+	if err != nil {
+		t.Fatalf("ParseYIN ext: %v", err)
+	}
+	if diff := cmp.Diff(ms.Modules["ext"].Source.String(), s.String()); diff != "" {
+		t.Errorf("ParseYIN of WriteYIN ext (-want, +got):\n%s", diff)
+	}
+}
+
+func TestWriteYINErrors(t *testing.T) {
+	tests := []struct {
+		desc       string
+		in         string
+		wantErrSub string
+	}{{
+		desc: "unknown extension",
+		in: `module sys {
+  prefix "s";
+  namespace "urn:sys";
+  import ext { prefix x; }
+  x:missing "arg";
+}`,
+		wantErrSub: "extension missing not found in module ext",
+	}, {
+		desc: "argument to extension without argument",
+		in: `module sys {
+  prefix "s";
+  namespace "urn:sys";
+  import ext { prefix x; }
+  x:flag "arg";
+}`,
+		wantErrSub: "x:flag has an argument but extension flag has none",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ms := NewModules()
+			if err := ms.Parse(yinExtModule, "ext.yang"); err != nil {
+				t.Fatalf("Parse ext: %v", err)
+			}
+			if err := ms.Parse(tt.in, "sys.yang"); err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if errs := ms.Process(); errs != nil {
+				t.Fatalf("Process: %v", errs)
+			}
+			var b bytes.Buffer
+			err := WriteYIN(&b, ms.Modules["sys"])
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("WriteYIN: %s", diff)
+			}
+			if err != nil && b.Len() != 0 {
+				t.Errorf("WriteYIN: wrote %q on error", b.String())
+			}
+		})
+	}
+}
+
+func TestParseYINErrors(t *testing.T) {
+	tests := []struct {
+		desc       string
+		in         string
+		wantErrSub string
+	}{{
+		desc:       "not XML",
+		in:         `module sys {}`,
+		wantErrSub: "no YIN element found",
+	}, {
+		desc:       "unknown statement",
+		in:         `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1"><bogus/></module>`,
+		wantErrSub: "t.yin:1:60: unknown statement bogus",
+	}, {
+		desc:       "missing attribute",
+		in:         `<module xmlns="urn:ietf:params:xml:ns:yang:yin:1"/>`,
+		wantErrSub: "module has no name attribute",
+	}, {
+		desc:       "missing argument element",
+		in:         `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1"><description/></module>`,
+		wantErrSub: "description has no text element",
+	}, {
+		desc:       "unexpected text",
+		in:         `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1">text</module>`,
+		wantErrSub: "unexpected text in module",
+	}, {
+		desc:       "malformed",
+		in:         `<module name="m" xmlns="urn:ietf:params:xml:ns:yang:yin:1">`,
+		wantErrSub: "t.yin: XML syntax error",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := ParseYIN(tt.in, "t.yin")
+			if diff := errdiff.Substring(err, tt.wantErrSub); diff != "" {
+				t.Errorf("ParseYIN: %s", diff)
+			}
+		})
+	}
+}
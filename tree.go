@@ -81,14 +81,6 @@ func Write(w io.Writer, e *yang.Entry) {
 	default:
 		fmt.Fprintf(w, "%s {\n", name) //}
 	}
-	if r := e.RPC; r != nil {
-		if r.Input != nil {
-			Write(indent.NewWriter(w, "  "), r.Input)
-		}
-		if r.Output != nil {
-			Write(indent.NewWriter(w, "  "), r.Output)
-		}
-	}
 	var names []string
 	for k := range e.Dir {
 		names = append(names, k)
@@ -42,7 +42,6 @@ package main
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"runtime/trace"
 	"sort"
@@ -91,12 +90,17 @@ func main() {
 
 	var traceP string
 	var help bool
+	var warnings bool
 	var paths []string
 	getopt.ListVarLong(&paths, "path", 'p', "comma separated list of directories to add to search path", "DIR[,DIR...]")
 	getopt.StringVarLong(&format, "format", 'f', "format to display: "+strings.Join(formats, ", "), "FORMAT")
 	getopt.StringVarLong(&traceP, "trace", 't', "write trace into to TRACEFILE", "TRACEFILE")
 	getopt.BoolVarLong(&help, "help", 'h', "display help")
+	getopt.BoolVarLong(&warnings, "warnings", 'w', "display warnings found while processing")
 	getopt.BoolVarLong(&yang.ParseOptions.IgnoreSubmoduleCircularDependencies, "ignore-circdep", 'g', "ignore circular dependencies between submodules")
+	getopt.BoolVarLong(&yang.ParseOptions.StrictStatements, "strict", 0, "reject unknown statements within extension statements")
+	getopt.BoolVarLong(&yang.ParseOptions.RequireListKeys, "require-keys", 0, "reject config lists without a key")
+	getopt.BoolVarLong(&yang.ParseOptions.RejectImportCycles, "reject-import-cycles", 0, "reject modules that import each other")
 	getopt.SetParameters("[FORMAT OPTIONS] [SOURCE] [...]")
 
 	if err := getopt.Getopt(func(o getopt.Option) bool {
@@ -171,11 +175,7 @@ Formats:
 	ms := yang.NewModules()
 
 	if len(files) == 0 {
-		data, err := ioutil.ReadAll(os.Stdin)
-		if err == nil {
-			err = ms.Parse(string(data), "<STDIN>")
-		}
-		if err != nil {
+		if err := ms.ParseReader(os.Stdin, "<STDIN>"); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			stop(1)
 		}
@@ -188,8 +188,15 @@ Formats:
 		}
 	}
 
-	// Process the read files, exiting if any errors were found.
-	exitIfError(ms.Process())
+	// Process the read files, exiting if any errors were found.  The
+	// warnings are printed first, as they may explain the errors.
+	errs := ms.Process()
+	if warnings {
+		for _, w := range ms.Warnings() {
+			fmt.Fprintln(os.Stderr, "warning:", w)
+		}
+	}
+	exitIfError(errs)
 
 	// Keep track of the top level modules we read in.
 	// Those are the only modules we want to print below.